@@ -3,16 +3,32 @@ package main
 import (
 	"FairLAP/internal/app"
 	"FairLAP/internal/config"
+	"flag"
 	"log"
+	"os"
 )
 
 const configPath = "config/config.yaml"
 
 func main() {
+	migrateCmd := flag.String("migrate", "", "up: apply pending migrations and start, down: roll back the last migration, status: print migrations, baseline: mark the initial schema of an existing database as applied")
+	flag.Parse()
+
 	cfg, err := config.ReadConfig(configPath)
 	if err != nil {
 		log.Fatal("read config file error:", err)
 	}
 
+	switch *migrateCmd {
+	case "":
+	case "up":
+		cfg.AutoMigrate = true
+	default:
+		if err := app.Migrate(cfg, *migrateCmd, os.Stdout); err != nil {
+			log.Fatal("migrate error: ", err)
+		}
+		return
+	}
+
 	app.Run(cfg)
 }
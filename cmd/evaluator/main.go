@@ -0,0 +1,63 @@
+package main
+
+import (
+	"FairLAP/internal/app"
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/service/modeleval"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const configPath = "config/config.yaml"
+
+func main() {
+	groups := flag.String("groups", "", "comma separated ids of the groups with reviewed images")
+	model := flag.String("model", "", "ONNX file of the evaluated model, the configured model by default")
+	modelConfig := flag.String("model-config", "", "config of the evaluated model, the configured one by default")
+	version := flag.String("version", "", "model name of the run, the model file name by default")
+	conf := flag.Float64("conf", modeleval.DefaultConfThreshold, "confidence threshold of precision, recall and the confusion matrix")
+	comment := flag.String("comment", "", "comment of the run")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -groups <id,...> [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	req := modeleval.Request{
+		ConfThreshold: float32(*conf),
+		Comment:       *comment,
+	}
+	for _, v := range strings.Split(*groups, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			flag.Usage()
+			os.Exit(2)
+		}
+		req.GroupIds = append(req.GroupIds, id)
+	}
+
+	cfg, err := config.ReadConfig(configPath)
+	if err != nil {
+		log.Fatal("read config file error:", err)
+	}
+
+	if *model != "" {
+		cfg.YoloModel.Model = *model
+		// the name of the configured model doesn't apply to another file
+		cfg.YoloModel.Version = ""
+	}
+	if *modelConfig != "" {
+		cfg.YoloModel.ModelConfig = *modelConfig
+	}
+	if *version != "" {
+		cfg.YoloModel.Version = *version
+	}
+
+	if err := app.Evaluate(cfg, req, os.Stdout); err != nil {
+		log.Fatal("evaluate error: ", err)
+	}
+}
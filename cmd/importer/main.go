@@ -0,0 +1,63 @@
+package main
+
+import (
+	"FairLAP/internal/app"
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/service/datasetimport"
+	"FairLAP/pkg/dataset"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const configPath = "config/config.yaml"
+
+func main() {
+	lapId := flag.String("lap", "", "lap the datasets are imported to")
+	format := flag.String("format", string(dataset.FormatYOLO), "dataset format: yolo or coco")
+	reviewer := flag.String("reviewer", datasetimport.DefaultReviewer, "reviewer recorded on the imported labels")
+	date := flag.String("date", "", "date of the groups, YYYY-MM-DD, the import time by default")
+	classMap := flag.String("class-map", "", "dataset classes renamed to model classes, e.g. bird_nest:nest,glass:insulator")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -lap <lap_id> [flags] <dir or zip>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *lapId == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := datasetimport.Options{
+		LapId:    *lapId,
+		Format:   dataset.Format(*format),
+		Reviewer: *reviewer,
+	}
+	if *date != "" {
+		createAt, err := time.Parse(time.DateOnly, *date)
+		if err != nil {
+			log.Fatal("invalid date: ", err)
+		}
+		opts.CreateAt = createAt
+	}
+
+	if *classMap != "" {
+		mapping, err := datasetimport.ParseClassMap(*classMap)
+		if err != nil {
+			log.Fatal("invalid class map: ", err)
+		}
+		opts.ClassMap = mapping
+	}
+
+	cfg, err := config.ReadConfig(configPath)
+	if err != nil {
+		log.Fatal("read config file error:", err)
+	}
+
+	if err := app.Import(cfg, opts, flag.Args(), os.Stdout); err != nil {
+		log.Fatal("import error: ", err)
+	}
+}
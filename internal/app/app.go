@@ -2,20 +2,34 @@ package app
 
 import (
 	"FairLAP/internal/config"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/annotations"
+	"FairLAP/internal/domain/service/batch"
+	"FairLAP/internal/domain/service/datasetexport"
+	"FairLAP/internal/domain/service/datasetimport"
 	"FairLAP/internal/domain/service/detector"
+	"FairLAP/internal/domain/service/geoexport"
 	"FairLAP/internal/domain/service/groups"
+	"FairLAP/internal/domain/service/jobs"
 	"FairLAP/internal/domain/service/lapconfig"
+	"FairLAP/internal/domain/service/laps"
 	"FairLAP/internal/domain/service/mask"
 	"FairLAP/internal/domain/service/metrics"
+	"FairLAP/internal/domain/service/modeleval"
+	"FairLAP/internal/domain/service/review"
+	"FairLAP/internal/domain/service/tasks"
+	"FairLAP/internal/domain/service/towers"
 	"FairLAP/internal/infrastructure/persistence/images"
-	"FairLAP/internal/infrastructure/persistence/mysql"
+	"FairLAP/internal/infrastructure/upload"
 	"FairLAP/internal/server"
+	"FairLAP/pkg/cache"
 	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/inference"
 	"FairLAP/pkg/logx"
 	"FairLAP/pkg/middlewarex"
-	"FairLAP/pkg/yolo_model"
 	"context"
 	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	"github.com/lmittmann/tint"
 	"io"
@@ -25,6 +39,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -35,40 +50,30 @@ func Run(cfg *config.Config) {
 
 	l := initLogger(cfg.Debug)
 
-	db, err := mysql.Connect(cfg.MySQL)
+	db, err := connect(cfg)
 	if err != nil {
-		log.Fatal("connect to mysql fail: ", err)
+		log.Fatal("connect to storage fail: ", err)
 	}
 	defer db.Close()
 
-	detectionsRepo := mysql.NewDetectionsRepo(db)
-	groupsRepo := mysql.NewGroupsRepo(db)
-	lapConfigRepo := mysql.NewLapConfigRepo(db)
-
-	imagesRepo := images.New(cfg.ImagesPath)
-
-	yoloConfig, err := yolo_model.ReadConfig(cfg.YoloModel.ModelConfig)
-	if err != nil {
-		log.Fatal("read yolo model config error: ", err)
+	if err := migrateSchema(db, cfg.Storage, cfg.AutoMigrate, l); err != nil {
+		log.Fatal("schema migration fail: ", err)
 	}
 
-	yoloCegConfig, err := yolo_model.ReadSegConfig(cfg.YoloModel.ModelSegConfig)
+	imagesRepo, err := newImages(cfg)
 	if err != nil {
-		log.Fatal("read yolo model seg config error: ", err)
+		log.Fatal("init images storage fail: ", err)
 	}
 
-	yoloModel := yolo_model.NewModel(cfg.YoloModel.Model, yoloConfig)
+	yoloModel, yoloModelSeg := initModels(cfg.YoloModel)
 	defer yoloModel.Close()
+	defer yoloModelSeg.Close()
 
-	yoloModelSeg := yolo_model.NewModelSeg(cfg.YoloModel.ModelSeg, yoloCegConfig)
-
-	detectorService := detector.NewService(yoloModel, detectionsRepo, imagesRepo)
-	groupsService := groups.NewService(groupsRepo, imagesRepo)
-	lapConfigService := lapconfig.NewService(lapConfigRepo, cfg.DefaultLapConfig)
-	metricsService := metrics.NewService(groupsRepo, detectionsRepo, lapConfigService)
-	maskService := mask.NewService(detectionsRepo, yoloModelSeg, imagesRepo)
+	s := newServices(cfg, l, db, imagesRepo, yoloModel, yoloModelSeg)
+	httpServer := s.httpServer
 
-	httpServer := newHttpServer(l, detectorService, groupsService, metricsService, lapConfigService, maskService, imagesRepo, cfg.Http)
+	jobsCtx, stopJobs := context.WithCancel(contextx.WithLogger(context.Background(), l))
+	jobsDone := s.start(jobsCtx)
 
 	go func() {
 		if cfg.Http.SSLCertPath != "" && cfg.Http.SSLKeyPath != "" {
@@ -93,23 +98,134 @@ func Run(cfg *config.Config) {
 	}
 	cancel()
 
+	stopJobs()
+	<-jobsDone
+
 	os.Exit(0)
 }
 
+// services are the wired services of the detector behind its http server.
+type services struct {
+	jobs       *jobs.Service
+	tasks      *tasks.Service
+	httpServer *http.Server
+}
+
+// newServices wires the services of the detector, the background workers are
+// started with start.
+func newServices(cfg *config.Config, l *slog.Logger, db *sqlx.DB, imagesRepo *images.Images, yoloModel inference.Detector, yoloModelSeg inference.Segmenter) *services {
+	repos := newStorage(db)
+
+	detectionsRepo := repos.detections
+	groupsRepo := repos.groups
+	lapsRepo := repos.laps
+	towersRepo := repos.towers
+	imageMetaRepo := repos.imageMeta
+	lapConfigRepo := repos.lapConfig
+	jobsRepo := repos.jobs
+	polygonsRepo := repos.polygons
+	evaluationsRepo := repos.evaluations
+	tasksRepo := repos.tasks
+	historyRepo := repos.history
+	unitOfWork := repos.unitOfWork
+
+	bytesCache := cache.NewBytes[string](int64(cfg.Cache.MaxSizeMb)<<20, time.Duration(cfg.Cache.TTLSec)*time.Second)
+
+	detectorService := detector.NewService(yoloModel, detectionsRepo, unitOfWork)
+	jobsService := jobs.NewService(jobsRepo, unitOfWork, detectorService, imagesRepo, cfg.Jobs.Workers, cfg.Jobs.QueueSize)
+	lapsService := laps.NewService(lapsRepo, groupsRepo)
+	groupsService := groups.NewService(groupsRepo, lapsService, imagesRepo, bytesCache)
+	towersService := towers.NewService(towersRepo, lapsService, groupsRepo)
+	lapConfigService := lapconfig.NewService(lapConfigRepo, lapsService, cfg.DefaultLapConfig)
+	tasksService := tasks.NewService(tasksRepo, cfg.Tasks.Dir, time.Duration(cfg.Tasks.ResultTTLHours)*time.Hour, cfg.Tasks.Workers, cfg.Tasks.QueueSize)
+	batchService := batch.NewService(groupsService, imagesRepo, jobsService, towersService, imageMetaRepo, tasksService, upload.NewArchives(int64(cfg.Http.MaxFileMb)<<20))
+	metricsService := metrics.NewService(groupsRepo, lapsRepo, towersRepo, imageMetaRepo, detectionsRepo, lapConfigService)
+	geoExportService := geoexport.NewService(groupsRepo, detectionsRepo, imageMetaRepo, lapConfigService)
+	maskService := mask.NewService(detectionsRepo, yoloModelSeg, polygonsRepo, imagesRepo, bytesCache)
+	reviewService := review.NewService(detectionsRepo, historyRepo, unitOfWork, imagesRepo, yoloModel, maskService)
+	annotationsService := annotations.NewService(detectionsRepo, historyRepo, unitOfWork, imagesRepo, yoloModel, maskService)
+	datasetExportService := datasetexport.NewService(groupsRepo, detectionsRepo, imagesRepo, yoloModel, tasksService)
+	datasetImportService := datasetimport.NewService(groupsService, imagesRepo, detectionsRepo, unitOfWork, yoloModel, tasksService)
+	modelEvalService := modeleval.NewService(evaluationsRepo, detectionsRepo, imagesRepo, yoloModel, modelName(cfg.YoloModel), tasksService)
+
+	tasksService.Handle(entity.TaskBatchUpload, batchService)
+	tasksService.Handle(entity.TaskDatasetExport, datasetExportService)
+	tasksService.Handle(entity.TaskDatasetImport, datasetImportService)
+	tasksService.Handle(entity.TaskEvaluation, modelEvalService)
+
+	return &services{
+		jobs:       jobsService,
+		tasks:      tasksService,
+		httpServer: newHttpServer(l, jobsService, tasksService, batchService, lapsService, towersService, imageMetaRepo, geoExportService, reviewService, annotationsService, datasetExportService, datasetImportService, modelEvalService, groupsService, metricsService, lapConfigService, maskService, imagesRepo, bytesCache, yoloModel, yoloModelSeg, cfg.Http),
+	}
+}
+
+// start runs the detection jobs and the background tasks until ctx is done,
+// the returned channel is closed when they have stopped.
+func (s *services) start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.jobs.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		s.tasks.Run(ctx)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	return done
+}
+
 func newHttpServer(
 	l *slog.Logger,
-	detector *detector.Service,
+	jobs *jobs.Service,
+	tasks *tasks.Service,
+	batch *batch.Service,
+	laps *laps.Service,
+	towers *towers.Service,
+	imageMeta server.ImageMetaRepo,
+	geoExport *geoexport.Service,
+	review *review.Service,
+	annotations *annotations.Service,
+	datasetExport *datasetexport.Service,
+	datasetImport *datasetimport.Service,
+	modelEval *modeleval.Service,
 	groups *groups.Service,
 	metrics *metrics.Service,
 	lapConfig *lapconfig.Service,
 	mask *mask.Service,
 	images *images.Images,
+	bytesCache *cache.LRU[string, []byte],
+	model inference.Detector,
+	modelSeg inference.Segmenter,
 	cfg *config.HttpConfig,
 ) *http.Server {
-	analyzerServer := server.NewDetectorServer(detector)
+	maxUploadSize := int64(cfg.MaxUploadMb) << 20
+
+	analyzerServer := server.NewDetectorServer(jobs, metrics, towers, imageMeta)
+	jobsServer := server.NewJobsServer(jobs)
+	tasksServer := server.NewTasksServer(tasks)
+	batchServer := server.NewBatchServer(batch, maxUploadSize)
+	modelsServer := server.NewModelsServer(model, modelSeg)
+	cacheServer := server.NewCacheServer(bytesCache)
+	lapsServer := server.NewLapsServer(laps)
+	towersServer := server.NewTowersServer(towers)
+	exportServer := server.NewExportServer(geoExport)
+	reviewServer := server.NewReviewServer(review)
+	annotationsServer := server.NewAnnotationsServer(annotations)
+	datasetServer := server.NewDatasetServer(datasetExport, datasetImport, maxUploadSize)
+	evaluationServer := server.NewEvaluationServer(modelEval)
 	groupsServer := server.NewGroupsServer(groups)
 	metricsServer := server.NewMetricServer(metrics)
-	imagesServer := server.NewImagesServer(images)
+	imagesServer := server.NewImagesServer(images, bytesCache)
 	lapConfigServer := server.NewLapConfigServer(lapConfig)
 	maskServer := server.NewMaskService(mask)
 
@@ -120,12 +236,25 @@ func newHttpServer(
 		lapConfigServer,
 		maskServer,
 		imagesServer,
+		jobsServer,
+		batchServer,
+		modelsServer,
+		cacheServer,
+		lapsServer,
+		towersServer,
+		exportServer,
+		reviewServer,
+		annotationsServer,
+		datasetServer,
+		evaluationServer,
+		tasksServer,
 	)
 
 	rtr := mux.NewRouter()
 	s.InitRoutes(rtr)
 
 	rtr.Use(
+		middlewarex.Streaming(server.IsStreaming),
 		middlewarex.TraceId,
 		middlewarex.Logger,
 		middlewarex.RequestLogging(logx.NewSensitiveDataMasker(), 1000),
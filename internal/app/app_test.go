@@ -0,0 +1,267 @@
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/infrastructure/persistence/images"
+	"FairLAP/internal/infrastructure/persistence/sqlite"
+	"FairLAP/internal/server"
+	"FairLAP/migrations"
+	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/inference/fake"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testApp is the detector on SQLite, in-memory images and the fake model
+// behind a test http server.
+type testApp struct {
+	t   *testing.T
+	url string
+}
+
+func newTestApp(t *testing.T) *testApp {
+	rq := require.New(t)
+
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := &config.Config{
+		Storage:          migrations.SQLite,
+		Http:             &config.HttpConfig{MaxUploadMb: 16, MaxFileMb: 16},
+		YoloModel:        &config.YoloModelConfig{Backend: backendFake, Version: "fake"},
+		Jobs:             &config.JobsConfig{Workers: 1, QueueSize: 10},
+		Tasks:            &config.TasksConfig{Dir: t.TempDir(), Workers: 1, QueueSize: 10, ResultTTLHours: 1},
+		Cache:            &config.CacheConfig{MaxSizeMb: 16, TTLSec: 60},
+		DefaultLapConfig: map[string]int{},
+	}
+
+	db, err := sqlite.Connect(&config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	rq.NoError(err)
+	t.Cleanup(func() { db.Close() })
+	rq.NoError(migrateSchema(db, cfg.Storage, true, l))
+
+	model := fake.NewModel(&fake.Fixtures{
+		ClassList: []string{"insulator", "nest"},
+		Detections: map[string][]fake.FixtureDetection{
+			"default": {{Class: "nest", Confidence: 0.9, BBox: [4]float64{0.1, 0.1, 0.5, 0.5}}},
+		},
+	})
+
+	s := newServices(cfg, l, db, images.New(images.NewMemory(), time.Minute, nil), model, model)
+
+	ctx, cancel := context.WithCancel(contextx.WithLogger(context.Background(), l))
+	done := s.start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	srv := httptest.NewServer(s.httpServer.Handler)
+	t.Cleanup(srv.Close)
+
+	return &testApp{t: t, url: srv.URL}
+}
+
+// do sends the request and decodes the JSON answer to out, the answer has to
+// have the status.
+func (a *testApp) do(method, path, contentType string, body []byte, status int, out any) {
+	a.t.Helper()
+	rq := require.New(a.t)
+
+	req, err := http.NewRequest(method, a.url+path, bytes.NewReader(body))
+	rq.NoError(err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	rq.NoError(err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	rq.NoError(err)
+	rq.Equal(status, resp.StatusCode, string(data))
+
+	if out != nil {
+		rq.NoError(json.Unmarshal(data, out), string(data))
+	}
+}
+
+// awaitTask polls the task until it is finished and decodes its result.
+func (a *testApp) awaitTask(id int, result any) server.TaskResponse {
+	a.t.Helper()
+
+	var task server.TaskResponse
+	require.Eventually(a.t, func() bool {
+		a.do(http.MethodGet, fmt.Sprintf("/tasks/get?id=%d", id), "", nil, http.StatusOK, &task)
+		return task.Status == entity.JobDone || task.Status == entity.JobFailed
+	}, 10*time.Second, 20*time.Millisecond)
+
+	require.Equal(a.t, entity.JobDone, task.Status, task.Error)
+	require.NoError(a.t, json.Unmarshal(task.Result, result))
+
+	return task
+}
+
+func encodePng(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func TestDetectReviewExport(t *testing.T) {
+	rq := require.New(t)
+	a := newTestApp(t)
+
+	a.do(http.MethodPost, "/laps/create", "application/json", []byte(`{"id": "12", "name": "VL-110-12"}`), http.StatusOK, nil)
+
+	var group server.IdResponse
+	a.do(http.MethodPost, "/groups/create?lap_id=12", "", nil, http.StatusOK, &group)
+
+	// a single photo is detected while the request waits
+	var detected server.DetectResponse
+	a.do(http.MethodPost, fmt.Sprintf("/detect?group_id=%d&wait=true", group.Id), "image/png", encodePng(t, 200, 100), http.StatusOK, &detected)
+	rq.Equal(entity.JobDone, detected.Status)
+	rq.Len(detected.Detections, 1)
+	rq.Equal("nest", detected.Detections[0].Class)
+	rq.Equal(20, detected.Detections[0].BBox.X0)
+	rq.Equal(50, detected.Detections[0].BBox.Y1)
+
+	// a batch is decoded by a task, its images are detected by a job
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"a.png", "b.png", "notes.txt"} {
+		f, err := zw.Create(name)
+		rq.NoError(err)
+		_, err = f.Write(encodePng(t, 100, 100))
+		rq.NoError(err)
+	}
+	rq.NoError(zw.Close())
+
+	var upload entity.Task
+	a.do(http.MethodPost, "/detect/batch?lap_id=12", "application/zip", buf.Bytes(), http.StatusAccepted, &upload)
+
+	var summary struct {
+		GroupId  int `json:"group_id"`
+		JobId    int `json:"job_id"`
+		Accepted int `json:"accepted"`
+		Rejected int `json:"rejected"`
+	}
+	a.awaitTask(upload.Id, &summary)
+	rq.Equal(2, summary.Accepted)
+	rq.Equal(1, summary.Rejected)
+	rq.NotZero(summary.JobId)
+
+	require.Eventually(t, func() bool {
+		var job entity.Job
+		a.do(http.MethodGet, fmt.Sprintf("/jobs/get?id=%d", summary.JobId), "", nil, http.StatusOK, &job)
+		return job.Status == entity.JobDone
+	}, 10*time.Second, 20*time.Millisecond)
+
+	// only the reviewed photo is exported, the batch waits for review
+	var reviewed []struct {
+		Review entity.ReviewStatus `json:"review_status"`
+	}
+	review := fmt.Sprintf(`{"group_id": %d, "image_uid": %q, "status": "confirmed", "reviewer": "ivanov"}`, group.Id, detected.ImageUid)
+	a.do(http.MethodPost, "/detections/review_image", "application/json", []byte(review), http.StatusOK, &reviewed)
+	rq.Len(reviewed, 1)
+	rq.Equal(entity.ReviewConfirmed, reviewed[0].Review)
+
+	var export entity.Task
+	a.do(http.MethodPost, "/export/dataset?lap_id=12&format=yolo&val_ratio=0", "", nil, http.StatusAccepted, &export)
+
+	var result struct {
+		Images int `json:"images"`
+		Boxes  int `json:"boxes"`
+	}
+	exported := a.awaitTask(export.Id, &result)
+	rq.Equal(1, result.Images)
+	rq.Equal(1, result.Boxes)
+	rq.Equal(fmt.Sprintf("/tasks/download?id=%d", export.Id), exported.DownloadUrl)
+
+	resp, err := http.Get(a.url + exported.DownloadUrl)
+	rq.NoError(err)
+	defer resp.Body.Close()
+	rq.Equal(http.StatusOK, resp.StatusCode)
+	archive, err := io.ReadAll(resp.Body)
+	rq.NoError(err)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	rq.NoError(err)
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	name := fmt.Sprintf("%d_%s", group.Id, detected.ImageUid)
+	rq.True(slices.ContainsFunc(names, func(n string) bool {
+		return strings.HasPrefix(n, "images/") && strings.Contains(n, name)
+	}), names)
+	rq.True(slices.ContainsFunc(names, func(n string) bool {
+		return strings.HasPrefix(n, "labels/") && strings.Contains(n, name)
+	}), names)
+}
+
+func TestImportDataset(t *testing.T) {
+	rq := require.New(t)
+	a := newTestApp(t)
+
+	a.do(http.MethodPost, "/laps/create", "application/json", []byte(`{"id": "12", "name": "VL-110-12"}`), http.StatusOK, nil)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string][]byte{
+		"classes.txt":      []byte("bird_nest\n"),
+		"images/a.png":     encodePng(t, 100, 100),
+		"labels/a.txt":     []byte("0 0.5 0.5 0.2 0.2\n"),
+		"images/clean.png": encodePng(t, 100, 100),
+	}
+	for name, data := range files {
+		f, err := zw.Create(name)
+		rq.NoError(err)
+		_, err = f.Write(data)
+		rq.NoError(err)
+	}
+	rq.NoError(zw.Close())
+
+	// the import is checked by the task, the unknown class fails it
+	var failed entity.Task
+	a.do(http.MethodPost, "/import/dataset?lap_id=12", "application/zip", buf.Bytes(), http.StatusAccepted, &failed)
+
+	var task server.TaskResponse
+	require.Eventually(t, func() bool {
+		a.do(http.MethodGet, fmt.Sprintf("/tasks/get?id=%d", failed.Id), "", nil, http.StatusOK, &task)
+		return task.Status == entity.JobFailed
+	}, 10*time.Second, 20*time.Millisecond)
+	rq.Contains(task.Error, "unknown classes bird_nest")
+
+	var imported entity.Task
+	a.do(http.MethodPost, "/import/dataset?lap_id=12&class_map=bird_nest:nest", "application/zip", buf.Bytes(), http.StatusAccepted, &imported)
+	rq.Equal(entity.TaskDatasetImport, imported.Kind)
+
+	var summary struct {
+		GroupId    int `json:"group_id"`
+		Accepted   int `json:"accepted"`
+		Detections int `json:"detections"`
+	}
+	a.awaitTask(imported.Id, &summary)
+	rq.NotZero(summary.GroupId)
+	rq.Equal(2, summary.Accepted)
+	rq.Equal(1, summary.Detections)
+}
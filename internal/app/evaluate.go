@@ -0,0 +1,49 @@
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/service/modeleval"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Evaluate runs the model of cfg.YoloModel over the reviewed images without
+// starting the service, so a new build can be scored before it is deployed.
+// The saved run is printed as JSON.
+func Evaluate(cfg *config.Config, req modeleval.Request, out io.Writer) error {
+	db, err := connect(cfg)
+	if err != nil {
+		return fmt.Errorf("connect to storage fail: %w", err)
+	}
+	defer db.Close()
+
+	if err := migrateSchema(db, cfg.Storage, cfg.AutoMigrate, slog.Default()); err != nil {
+		return fmt.Errorf("schema migration fail: %w", err)
+	}
+
+	repos := newStorage(db)
+
+	imagesRepo, err := newImages(cfg)
+	if err != nil {
+		return fmt.Errorf("init images storage fail: %w", err)
+	}
+
+	yoloModel, yoloModelSeg := initModels(cfg.YoloModel)
+	defer yoloModel.Close()
+	defer yoloModelSeg.Close()
+
+	// the run is awaited here, no tasks are queued
+	evalService := modeleval.NewService(repos.evaluations, repos.detections, imagesRepo, yoloModel, modelName(cfg.YoloModel), nil)
+
+	e, err := evalService.Evaluate(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/service/datasetimport"
+	"FairLAP/internal/domain/service/groups"
+	"FairLAP/internal/domain/service/laps"
+	"FairLAP/pkg/cache"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Import saves labeled datasets without starting the service. Every path is
+// a directory or a ZIP archive imported to its own group, the summaries are
+// printed as JSON lines.
+func Import(cfg *config.Config, opts datasetimport.Options, paths []string, out io.Writer) error {
+	db, err := connect(cfg)
+	if err != nil {
+		return fmt.Errorf("connect to storage fail: %w", err)
+	}
+	defer db.Close()
+
+	if err := migrateSchema(db, cfg.Storage, cfg.AutoMigrate, slog.Default()); err != nil {
+		return fmt.Errorf("schema migration fail: %w", err)
+	}
+
+	repos := newStorage(db)
+
+	classes, err := modelClasses(cfg.YoloModel)
+	if err != nil {
+		return fmt.Errorf("read model classes fail: %w", err)
+	}
+
+	imagesRepo, err := newImages(cfg)
+	if err != nil {
+		return fmt.Errorf("init images storage fail: %w", err)
+	}
+
+	lapsService := laps.NewService(repos.laps, repos.groups)
+	// a failed import deletes its new group, nothing of it is cached
+	groupsService := groups.NewService(repos.groups, lapsService, imagesRepo, cache.NewBytes[string](0, 0))
+	// the importer reads the datasets in place, it queues no tasks
+	importService := datasetimport.NewService(groupsService, imagesRepo, repos.detections, repos.unitOfWork, classes, nil)
+
+	enc := json.NewEncoder(out)
+
+	for _, path := range paths {
+		summary, err := importPath(importService, path, opts)
+		if err != nil {
+			return fmt.Errorf("import %s: %w", path, err)
+		}
+		if err := enc.Encode(summary); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importPath(s *datasetimport.Service, path string, opts datasetimport.Options) (*datasetimport.Summary, error) {
+	var fsys fs.FS
+
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		fsys = zr
+	} else {
+		fsys = os.DirFS(path)
+	}
+
+	return s.Import(context.Background(), fsys, opts)
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/migrations"
+	"FairLAP/pkg/migrate"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const migrateTimeout = 5 * time.Minute
+
+// migrateSchema applies pending migrations if auto is set, then refuses to
+// continue if the schema doesn't match the embedded migrations.
+func migrateSchema(db *sqlx.DB, dialect string, auto bool, l *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	migrator, err := newMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	if auto {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			l.Info("schema migrated", slog.Int("applied", applied))
+		}
+	}
+
+	if err := migrator.Check(ctx); err != nil {
+		return fmt.Errorf("%w, run with -migrate up or set auto_migrate", err)
+	}
+
+	return nil
+}
+
+// Migrate runs a migration command without starting the service: "down"
+// rolls back the last migration, "status" prints the migrations as JSON and
+// "baseline" marks the initial schema as applied for databases created from
+// the unversioned up.sql.
+func Migrate(cfg *config.Config, command string, out io.Writer) error {
+	db, err := connect(cfg)
+	if err != nil {
+		return fmt.Errorf("connect to storage fail: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	migrator, err := newMigrator(db, cfg.Storage)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "down":
+		n, err := migrator.Down(ctx, 1)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "rolled back %d migration(s)\n", n)
+	case "baseline":
+		n, err := migrator.Baseline(ctx, 1)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "marked %d migration(s) as applied\n", n)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+
+	return nil
+}
+
+func newMigrator(db *sqlx.DB, dialect string) (*migrate.Migrator, error) {
+	fsys, err := migrations.FS(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(db, fsys)
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/pkg/inference"
+	"FairLAP/pkg/inference/fake"
+	"log"
+	"path/filepath"
+)
+
+const (
+	backendOnnx = "onnx"
+	backendFake = "fake"
+)
+
+func initModels(cfg *config.YoloModelConfig) (inference.Detector, inference.Segmenter) {
+	switch cfg.Backend {
+	case "", backendOnnx:
+		return initOnnxModels(cfg)
+	case backendFake:
+		fixtures, err := fake.ReadFixtures(cfg.Fixtures)
+		if err != nil {
+			log.Fatal("read fake model fixtures error: ", err)
+		}
+
+		model := fake.NewModel(fixtures)
+		return model, model
+	default:
+		log.Fatal("unknown model backend: ", cfg.Backend)
+		return nil, nil
+	}
+}
+
+// modelName names the detection model in evaluation runs.
+func modelName(cfg *config.YoloModelConfig) string {
+	switch {
+	case cfg.Version != "":
+		return cfg.Version
+	case cfg.Backend == backendFake:
+		return backendFake + ":" + filepath.Base(cfg.Fixtures)
+	default:
+		return filepath.Base(cfg.Model)
+	}
+}
+
+// classList is the class list of a model that isn't loaded.
+type classList []string
+
+func (c classList) Classes() []string {
+	return c
+}
+
+// modelClasses reads the classes of the detection model from its config
+// without loading the model.
+func modelClasses(cfg *config.YoloModelConfig) (classList, error) {
+	if cfg.Backend == backendFake {
+		fixtures, err := fake.ReadFixtures(cfg.Fixtures)
+		if err != nil {
+			return nil, err
+		}
+		return fixtures.ClassList, nil
+	}
+
+	return onnxClasses(cfg)
+}
@@ -0,0 +1,20 @@
+//go:build nogocv
+
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/pkg/inference"
+	"errors"
+	"log"
+)
+
+// initOnnxModels is a stub for builds without OpenCV, only the fake backend is available there.
+func initOnnxModels(*config.YoloModelConfig) (inference.Detector, inference.Segmenter) {
+	log.Fatal("onnx backend is not available: binary is built with the nogocv tag")
+	return nil, nil
+}
+
+func onnxClasses(*config.YoloModelConfig) (classList, error) {
+	return nil, errors.New("onnx backend is not available: binary is built with the nogocv tag")
+}
@@ -0,0 +1,33 @@
+//go:build !nogocv
+
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/pkg/inference"
+	"FairLAP/pkg/yolo_model"
+	"log"
+)
+
+func initOnnxModels(cfg *config.YoloModelConfig) (inference.Detector, inference.Segmenter) {
+	yoloConfig, err := yolo_model.ReadConfig(cfg.ModelConfig)
+	if err != nil {
+		log.Fatal("read yolo model config error: ", err)
+	}
+
+	yoloCegConfig, err := yolo_model.ReadSegConfig(cfg.ModelSegConfig)
+	if err != nil {
+		log.Fatal("read yolo model seg config error: ", err)
+	}
+
+	return yolo_model.NewModel(cfg.Model, yoloConfig), yolo_model.NewModelSeg(cfg.ModelSeg, yoloCegConfig)
+}
+
+func onnxClasses(cfg *config.YoloModelConfig) (classList, error) {
+	yoloConfig, err := yolo_model.ReadConfig(cfg.ModelConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return yoloConfig.ClassList, nil
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/service/annotations"
+	"FairLAP/internal/domain/service/batch"
+	"FairLAP/internal/domain/service/datasetexport"
+	"FairLAP/internal/domain/service/datasetimport"
+	"FairLAP/internal/domain/service/detector"
+	"FairLAP/internal/domain/service/geoexport"
+	"FairLAP/internal/domain/service/groups"
+	"FairLAP/internal/domain/service/jobs"
+	"FairLAP/internal/domain/service/lapconfig"
+	"FairLAP/internal/domain/service/laps"
+	"FairLAP/internal/domain/service/mask"
+	"FairLAP/internal/domain/service/metrics"
+	"FairLAP/internal/domain/service/modeleval"
+	"FairLAP/internal/domain/service/review"
+	"FairLAP/internal/domain/service/tasks"
+	"FairLAP/internal/domain/service/towers"
+	"FairLAP/internal/infrastructure/persistence/images"
+	"FairLAP/internal/infrastructure/persistence/mysql"
+	"FairLAP/internal/infrastructure/persistence/postgres"
+	"FairLAP/internal/infrastructure/persistence/sqlite"
+	"FairLAP/internal/infrastructure/persistence/sqlrepo"
+	"FairLAP/internal/server"
+	"FairLAP/migrations"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type detectionsRepo interface {
+	detector.Repo
+	metrics.DetectionsRepo
+	geoexport.DetectionsRepo
+	mask.RectRepo
+	review.Repo
+	annotations.Repo
+	datasetexport.DetectionsRepo
+	datasetimport.Repo
+	modeleval.DetectionsRepo
+}
+
+type groupsRepo interface {
+	groups.Repo
+	laps.GroupsRepo
+	towers.GroupsRepo
+	metrics.GroupsRepo
+	geoexport.GroupsRepo
+	datasetexport.GroupsRepo
+}
+
+type lapsRepo interface {
+	laps.Repo
+	metrics.LapsRepo
+}
+
+type towersRepo interface {
+	towers.Repo
+	metrics.TowersRepo
+}
+
+type imageMetaRepo interface {
+	batch.MetaRepo
+	metrics.ImageMetaRepo
+	geoexport.MetaRepo
+	server.ImageMetaRepo
+}
+
+type unitOfWork interface {
+	detector.UnitOfWork
+	jobs.UnitOfWork
+	annotations.UnitOfWork
+	datasetimport.UnitOfWork
+}
+
+// storage holds the repositories of the configured database backend.
+type storage struct {
+	detections  detectionsRepo
+	groups      groupsRepo
+	laps        lapsRepo
+	towers      towersRepo
+	imageMeta   imageMetaRepo
+	lapConfig   lapconfig.Repo
+	jobs        jobs.Repo
+	polygons    mask.PolygonsRepo
+	evaluations modeleval.Repo
+	tasks       tasks.Repo
+	history     annotations.HistoryRepo
+	unitOfWork  unitOfWork
+}
+
+// connect opens the database of cfg.Storage, its value is also the dialect
+// of the migrations.
+func connect(cfg *config.Config) (*sqlx.DB, error) {
+	switch cfg.Storage {
+	case migrations.MySQL:
+		return mysql.Connect(cfg.MySQL)
+	case migrations.SQLite:
+		return sqlite.Connect(cfg.SQLite)
+	case migrations.Postgres:
+		return postgres.Connect(cfg.Postgres)
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+// newImages creates the images storage of cfg.ImagesStorage, "local" or
+// "s3".
+func newImages(cfg *config.Config) (*images.Images, error) {
+	urlTTL := time.Duration(cfg.S3.URLTTLSec) * time.Second
+
+	switch cfg.ImagesStorage {
+	case "local":
+		return images.New(images.NewLocal(cfg.ImagesPath), urlTTL, cfg.ThumbnailSizes), nil
+	case "s3":
+		storage, err := images.NewS3(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return images.New(storage, urlTTL, cfg.ThumbnailSizes), nil
+	default:
+		return nil, fmt.Errorf("unknown images storage %q", cfg.ImagesStorage)
+	}
+}
+
+func newStorage(db *sqlx.DB) *storage {
+	return &storage{
+		detections:  sqlrepo.NewDetectionsRepo(db),
+		groups:      sqlrepo.NewGroupsRepo(db),
+		laps:        sqlrepo.NewLapsRepo(db),
+		towers:      sqlrepo.NewTowersRepo(db),
+		imageMeta:   sqlrepo.NewImageMetaRepo(db),
+		lapConfig:   sqlrepo.NewLapConfigRepo(db),
+		jobs:        sqlrepo.NewJobsRepo(db),
+		polygons:    sqlrepo.NewPolygonsRepo(db),
+		evaluations: sqlrepo.NewEvaluationsRepo(db),
+		tasks:       sqlrepo.NewTasksRepo(db),
+		history:     sqlrepo.NewAnnotationHistoryRepo(db),
+		unitOfWork:  sqlrepo.NewUnitOfWork(db),
+	}
+}
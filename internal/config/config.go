@@ -10,9 +10,19 @@ import (
 type Config struct {
 	Debug            bool             `json:"debug" yaml:"debug" env:"DEBUG" envDefault:"false"`
 	Http             *HttpConfig      `json:"http" yaml:"http"`
+	Storage          string           `json:"storage" yaml:"storage" env:"STORAGE" envDefault:"mysql"`
 	MySQL            *MySQLConfig     `json:"mysql" yaml:"mysql"`
+	SQLite           *SQLiteConfig    `json:"sqlite" yaml:"sqlite"`
+	Postgres         *PostgresConfig  `json:"postgres" yaml:"postgres"`
 	YoloModel        *YoloModelConfig `json:"yolo_model" yaml:"yolo_model"`
+	Jobs             *JobsConfig      `json:"jobs" yaml:"jobs"`
+	Tasks            *TasksConfig     `json:"tasks" yaml:"tasks"`
+	Cache            *CacheConfig     `json:"cache" yaml:"cache"`
+	AutoMigrate      bool             `json:"auto_migrate" yaml:"auto_migrate" env:"AUTO_MIGRATE" envDefault:"false"`
+	ImagesStorage    string           `json:"images_storage" yaml:"images_storage" env:"IMAGES_STORAGE" envDefault:"local"`
 	ImagesPath       string           `json:"images_path" yaml:"images_path"`
+	S3               *S3Config        `json:"s3" yaml:"s3"`
+	ThumbnailSizes   []int            `json:"thumbnail_sizes" yaml:"thumbnail_sizes" env:"THUMBNAIL_SIZES" envDefault:"256,1024"`
 	DefaultLapConfig map[string]int   `json:"default_lap_config" yaml:"default_lap_config"`
 }
 
@@ -21,6 +31,8 @@ type HttpConfig struct {
 	ReadTimeoutSec   int    `json:"read_timeout_sec" yaml:"read_timeout_sec" env:"HTTP_READ_TIMEOUT_SEC" envDefault:"10"`
 	HandleTimeoutSec int    `json:"handle_timeout_sec" yaml:"handle_timeout_sec" env:"HTTP_HANDE_TIMEOUT_SEC" envDefault:"20"`
 	WriteTimeoutSec  int    `json:"write_timeout_sec" yaml:"write_timeout_sec" env:"HTTP_WRITE_TIMEOUT_SEC" envDefault:"10"`
+	MaxUploadMb      int    `json:"max_upload_mb" yaml:"max_upload_mb" env:"HTTP_MAX_UPLOAD_MB" envDefault:"2048"`
+	MaxFileMb        int    `json:"max_file_mb" yaml:"max_file_mb" env:"HTTP_MAX_FILE_MB" envDefault:"100"`
 	SSLKeyPath       string `json:"ssl_key_path" yaml:"ssl_key_path" env:"HTTP_SSL_KEY_PATH"`
 	SSLCertPath      string `json:"ssl_cert_path" yaml:"ssl_cert_path" env:"HTTP_SSL_CERT_PATH"`
 }
@@ -34,11 +46,58 @@ type MySQLConfig struct {
 	ConnectTimeoutSec int    `json:"connect_timeout_sec" yaml:"connect_timeout_sec" env:"MYSQL_CONNECT_TIMEOUT_SEC" envDefault:"10"`
 }
 
+type SQLiteConfig struct {
+	Path string `json:"path" yaml:"path" env:"SQLITE_PATH" envDefault:"fairlap.db"`
+}
+
+type PostgresConfig struct {
+	Host     string `json:"host" yaml:"host" env:"POSTGRES_HOST" envDefault:"localhost"`
+	Port     int    `json:"port" yaml:"port" env:"POSTGRES_PORT" envDefault:"5432"`
+	User     string `json:"user" yaml:"user" env:"POSTGRES_USER" envDefault:"postgres"`
+	Password string `json:"password" yaml:"password" env:"POSTGRES_PASSWORD" envDefault:"pass"`
+	Database string `json:"database" yaml:"database" env:"POSTGRES_DATABASE" envDefault:"app"`
+	SSLMode  string `json:"ssl_mode" yaml:"ssl_mode" env:"POSTGRES_SSL_MODE" envDefault:"disable"`
+}
+
 type YoloModelConfig struct {
+	Backend        string `json:"backend" yaml:"backend" env:"YOLO_BACKEND"`
+	Fixtures       string `json:"fixtures" yaml:"fixtures" env:"YOLO_FIXTURES"`
 	Model          string `json:"model" yaml:"model" env:"YOLO_MODEL"`
 	ModelConfig    string `json:"model_config" yaml:"model_config" env:"YOLO_MODEL_CONFIG"`
 	ModelSeg       string `json:"model_seg" yaml:"model_seg" env:"YOLO_MODEL_SEG"`
 	ModelSegConfig string `json:"model_seg_config" yaml:"model_seg_config" env:"YOLO_MODEL_SEG_CONFIG"`
+	// Version names the model in evaluation runs, the model file name if empty.
+	Version string `json:"version" yaml:"version" env:"YOLO_MODEL_VERSION"`
+}
+
+type JobsConfig struct {
+	Workers   int `json:"workers" yaml:"workers" env:"JOBS_WORKERS" envDefault:"1"`
+	QueueSize int `json:"queue_size" yaml:"queue_size" env:"JOBS_QUEUE_SIZE" envDefault:"1000"`
+}
+
+// TasksConfig configures the background tasks, Dir holds the spooled uploads
+// and the task outputs, which are removed ResultTTLHours after the task ends.
+type TasksConfig struct {
+	Dir            string `json:"dir" yaml:"dir" env:"TASKS_DIR" envDefault:"tasks"`
+	Workers        int    `json:"workers" yaml:"workers" env:"TASKS_WORKERS" envDefault:"1"`
+	QueueSize      int    `json:"queue_size" yaml:"queue_size" env:"TASKS_QUEUE_SIZE" envDefault:"100"`
+	ResultTTLHours int    `json:"result_ttl_hours" yaml:"result_ttl_hours" env:"TASKS_RESULT_TTL_HOURS" envDefault:"24"`
+}
+
+type CacheConfig struct {
+	MaxSizeMb int `json:"max_size_mb" yaml:"max_size_mb" env:"CACHE_MAX_SIZE_MB" envDefault:"256"`
+	TTLSec    int `json:"ttl_sec" yaml:"ttl_sec" env:"CACHE_TTL_SEC" envDefault:"600"`
+}
+
+type S3Config struct {
+	Endpoint  string `json:"endpoint" yaml:"endpoint" env:"S3_ENDPOINT" envDefault:"localhost:9000"`
+	Region    string `json:"region" yaml:"region" env:"S3_REGION" envDefault:"us-east-1"`
+	Bucket    string `json:"bucket" yaml:"bucket" env:"S3_BUCKET" envDefault:"images"`
+	AccessKey string `json:"access_key" yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `json:"secret_key" yaml:"secret_key" env:"S3_SECRET_KEY"`
+	UseSSL    bool   `json:"use_ssl" yaml:"use_ssl" env:"S3_USE_SSL" envDefault:"false"`
+	// URLTTLSec is the lifetime of presigned image links.
+	URLTTLSec int `json:"url_ttl_sec" yaml:"url_ttl_sec" env:"S3_URL_TTL_SEC" envDefault:"900"`
 }
 
 func ReadConfig(path string, dotenv ...string) (*Config, error) {
@@ -50,6 +109,38 @@ func ReadConfig(path string, dotenv ...string) (*Config, error) {
 
 	cfg := new(Config)
 	cfg.DefaultLapConfig = make(map[string]int)
+	cfg.ThumbnailSizes = []int{256, 1024}
+	cfg.SQLite = &SQLiteConfig{
+		Path: "fairlap.db",
+	}
+	cfg.Postgres = &PostgresConfig{
+		Host:     "localhost",
+		Port:     5432,
+		User:     "postgres",
+		Password: "pass",
+		Database: "app",
+		SSLMode:  "disable",
+	}
+	cfg.S3 = &S3Config{
+		Endpoint:  "localhost:9000",
+		Region:    "us-east-1",
+		Bucket:    "images",
+		URLTTLSec: 900,
+	}
+	cfg.Jobs = &JobsConfig{
+		Workers:   1,
+		QueueSize: 1000,
+	}
+	cfg.Tasks = &TasksConfig{
+		Dir:            "tasks",
+		Workers:        1,
+		QueueSize:      100,
+		ResultTTLHours: 24,
+	}
+	cfg.Cache = &CacheConfig{
+		MaxSizeMb: 256,
+		TTLSec:    600,
+	}
 
 	if err := cleanenv.ReadConfig(path, cfg); err != nil {
 		return nil, err
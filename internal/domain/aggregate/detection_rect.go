@@ -0,0 +1,43 @@
+package aggregate
+
+import (
+	"FairLAP/internal/domain/entity"
+	"github.com/google/uuid"
+	"time"
+)
+
+type DetectionRect struct {
+	Id         int                    `json:"id" db:"id"`
+	GroupId    int                    `json:"group_id" db:"group_id"`
+	ImageUid   uuid.UUID              `json:"image_uid" db:"image_uid"`
+	Class      string                 `json:"class" db:"class"`
+	Confidence float32                `json:"confidence" db:"confidence"`
+	Threshold  float32                `json:"conf_threshold" db:"conf_threshold"`
+	Review     entity.ReviewStatus    `json:"review_status" db:"review_status"`
+	Source     entity.DetectionSource `json:"source" db:"source"`
+	Width      int                    `json:"width" db:"width"`
+	Height     int                    `json:"height" db:"height"`
+	X0         int                    `json:"x0" db:"x0"`
+	Y0         int                    `json:"y0" db:"y0"`
+	X1         int                    `json:"x1" db:"x1"`
+	Y1         int                    `json:"y1" db:"y1"`
+}
+
+// Change records the label of the detection before an edit.
+func (r *DetectionRect) Change(action entity.AnnotationAction, editor string, at time.Time) *entity.AnnotationChange {
+	return &entity.AnnotationChange{
+		DetectionId:  r.Id,
+		GroupId:      r.GroupId,
+		ImageUid:     r.ImageUid,
+		Action:       action,
+		Editor:       editor,
+		Class:        r.Class,
+		Source:       r.Source,
+		ReviewStatus: r.Review,
+		X0:           r.X0,
+		Y0:           r.Y0,
+		X1:           r.X1,
+		Y1:           r.Y1,
+		CreateAt:     at,
+	}
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type AnnotationAction string
+
+const (
+	AnnotationUpdated AnnotationAction = "updated"
+	AnnotationDeleted AnnotationAction = "deleted"
+)
+
+// AnnotationChange keeps the label of a detection as it was before an
+// engineer edited or deleted it, so the model labels stay comparable with
+// the corrections.
+type AnnotationChange struct {
+	Id           int              `json:"id" db:"id"`
+	DetectionId  int              `json:"detection_id" db:"detection_id"`
+	GroupId      int              `json:"group_id" db:"group_id"`
+	ImageUid     uuid.UUID        `json:"image_uid" db:"image_uid"`
+	Action       AnnotationAction `json:"action" db:"action"`
+	Editor       string           `json:"editor" db:"editor"`
+	Class        string           `json:"class" db:"class"`
+	Source       DetectionSource  `json:"source" db:"source"`
+	ReviewStatus ReviewStatus     `json:"review_status" db:"review_status"`
+	X0           int              `json:"x0" db:"x0"`
+	Y0           int              `json:"y0" db:"y0"`
+	X1           int              `json:"x1" db:"x1"`
+	Y1           int              `json:"y1" db:"y1"`
+	CreateAt     time.Time        `json:"create_at" db:"create_at"`
+}
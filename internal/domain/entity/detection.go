@@ -1,10 +1,95 @@
 package entity
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// DetectionSource tells who made the current label of a detection.
+type DetectionSource string
+
+const (
+	SourceModel DetectionSource = "model"
+	// SourceHuman detections are drawn or edited by an engineer.
+	SourceHuman DetectionSource = "human"
+)
+
+type ReviewStatus string
+
+const (
+	// ReviewPending detections are not checked by an engineer yet.
+	ReviewPending   ReviewStatus = "pending"
+	ReviewConfirmed ReviewStatus = "confirmed"
+	ReviewRejected  ReviewStatus = "rejected"
+	// ReviewCorrected detections are real defects of another class, the
+	// class is replaced by the reviewer.
+	ReviewCorrected ReviewStatus = "corrected"
+)
+
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewPending, ReviewConfirmed, ReviewRejected, ReviewCorrected:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProblemCount selects the detections counted as line problems by their
+// review status. Rejected detections are never counted.
+type ProblemCount string
+
+const (
+	// CountConfirmed counts confirmed and corrected detections only.
+	CountConfirmed ProblemCount = "confirmed"
+	// CountConfirmedPending also counts detections not reviewed yet.
+	CountConfirmedPending ProblemCount = "confirmed_pending"
+)
+
+func (c ProblemCount) IsValid() bool {
+	return c == CountConfirmed || c == CountConfirmedPending
+}
+
+func (c ProblemCount) Counts(status ReviewStatus) bool {
+	switch status {
+	case ReviewConfirmed, ReviewCorrected:
+		return true
+	case ReviewPending:
+		return c == CountConfirmedPending
+	default:
+		return false
+	}
+}
 
 type Detection struct {
 	Id       int       `json:"id" db:"id"`
 	GroupId  int       `json:"group_id" db:"group_id"`
 	ImageUid uuid.UUID `json:"image_uid" db:"image_uid"`
 	Class    string    `json:"class" db:"class"`
+	// ConfThreshold is the confidence threshold the model applied to the detection.
+	ConfThreshold float32         `json:"conf_threshold" db:"conf_threshold"`
+	Source        DetectionSource `json:"source" db:"source"`
+	ReviewStatus  ReviewStatus    `json:"review_status" db:"review_status"`
+	ReviewedBy    string          `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt    *time.Time      `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewComment string          `json:"review_comment,omitempty" db:"review_comment"`
+}
+
+// Review is a decision of an engineer on a detection.
+type Review struct {
+	Status   ReviewStatus `json:"status"`
+	Reviewer string       `json:"reviewer"`
+	Comment  string       `json:"comment"`
+	// Class is the right class of a corrected detection.
+	Class string    `json:"class,omitempty"`
+	At    time.Time `json:"-"`
+}
+
+// ImageReview marks an image checked by an engineer as a whole, so an image
+// left without detections is known to be clean rather than not looked at.
+type ImageReview struct {
+	GroupId    int       `json:"group_id" db:"group_id"`
+	ImageUid   uuid.UUID `json:"image_uid" db:"image_uid"`
+	ReviewedBy string    `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt time.Time `json:"reviewed_at" db:"reviewed_at"`
 }
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"FairLAP/pkg/evaluation"
+	"time"
+)
+
+// Evaluation is a run of a model over the reviewed images of groups, the
+// summary metrics are kept in columns to compare runs without their details.
+type Evaluation struct {
+	Id    int    `json:"id" db:"id"`
+	Model string `json:"model" db:"model"`
+	// Comment tells what was evaluated, e.g. the build of the weights.
+	Comment       string    `json:"comment" db:"comment"`
+	GroupIds      []int     `json:"group_ids" db:"-"`
+	ConfThreshold float32   `json:"conf_threshold" db:"conf_threshold"`
+	Images        int       `json:"images" db:"images"`
+	Precision     float64   `json:"precision" db:"precision_value"`
+	Recall        float64   `json:"recall" db:"recall_value"`
+	MAP50         float64   `json:"map50" db:"map50"`
+	MAP50_95      float64   `json:"map50_95" db:"map50_95"`
+	CreateAt      time.Time `json:"create_at" db:"create_at"`
+	// Result holds the per class metrics and the confusion matrix, it is
+	// loaded only for a single run.
+	Result *evaluation.Result `json:"result,omitempty" db:"-"`
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "image"
+
+// ImageFile is an uploaded image, decoded with the EXIF orientation applied
+// and as the original file.
+type ImageFile struct {
+	Image    image.Image
+	Original []byte
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ImageMeta is the metadata of an uploaded photo read from its EXIF and XMP.
+// Fields the photo doesn't carry are nil or empty.
+type ImageMeta struct {
+	GroupId   int       `json:"group_id" db:"group_id"`
+	ImageUid  uuid.UUID `json:"image_uid" db:"image_uid"`
+	Latitude  *float64  `json:"latitude" db:"latitude"`
+	Longitude *float64  `json:"longitude" db:"longitude"`
+	// Altitude is meters above sea level.
+	Altitude *float64 `json:"altitude" db:"altitude"`
+	// RelativeAltitude is meters above the take-off point.
+	RelativeAltitude *float64   `json:"relative_altitude" db:"relative_altitude"`
+	GimbalPitch      *float64   `json:"gimbal_pitch" db:"gimbal_pitch"`
+	GimbalYaw        *float64   `json:"gimbal_yaw" db:"gimbal_yaw"`
+	GimbalRoll       *float64   `json:"gimbal_roll" db:"gimbal_roll"`
+	CaptureAt        *time.Time `json:"capture_at" db:"capture_at"`
+	CameraMake       string     `json:"camera_make" db:"camera_make"`
+	CameraModel      string     `json:"camera_model" db:"camera_model"`
+}
+
+func (m *ImageMeta) HasLocation() bool {
+	return m != nil && m.Latitude != nil && m.Longitude != nil
+}
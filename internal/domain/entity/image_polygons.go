@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"image"
+)
+
+// ImagePolygons are segmentation contours of an image, computed once and stored.
+type ImagePolygons struct {
+	Id       int             `json:"id" db:"id"`
+	GroupId  int             `json:"group_id" db:"group_id"`
+	ImageUid uuid.UUID       `json:"image_uid" db:"image_uid"`
+	Width    int             `json:"width" db:"width"`
+	Height   int             `json:"height" db:"height"`
+	Polygons [][]image.Point `json:"polygons" db:"-"`
+}
+
+func (p *ImagePolygons) ImgBounds() image.Rectangle {
+	return image.Rect(0, 0, p.Width, p.Height)
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"FairLAP/pkg/inference"
+	"github.com/google/uuid"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+type Job struct {
+	Id      int       `json:"id" db:"id"`
+	GroupId int       `json:"group_id" db:"group_id"`
+	Status  JobStatus `json:"status" db:"status"`
+	// Thresholds is a request level override of the model thresholds.
+	Thresholds *inference.Thresholds `json:"thresholds,omitempty" db:"thresholds"`
+	Error      string                `json:"error,omitempty" db:"error"`
+	CreateAt   time.Time             `json:"create_at" db:"create_at"`
+	UpdateAt   time.Time             `json:"update_at" db:"update_at"`
+	Images     []JobImage            `json:"images,omitempty" db:"-"`
+}
+
+type JobImage struct {
+	Id              int       `json:"id" db:"id"`
+	JobId           int       `json:"job_id" db:"job_id"`
+	ImageUid        uuid.UUID `json:"image_uid" db:"image_uid"`
+	Status          JobStatus `json:"status" db:"status"`
+	Error           string    `json:"error,omitempty" db:"error"`
+	DetectionsCount int       `json:"detections_count" db:"detections_count"`
+}
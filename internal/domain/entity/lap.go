@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+type Lap struct {
+	Id           string `json:"id" db:"id"`
+	Name         string `json:"name" db:"name"`
+	VoltageClass string `json:"voltage_class" db:"voltage_class"`
+	Operator     string `json:"operator" db:"operator"`
+	Region       string `json:"region" db:"region"`
+	// RouteLength is the length of the line route in kilometers.
+	RouteLength float64   `json:"route_length" db:"route_length"`
+	CreateAt    time.Time `json:"create_at" db:"create_at"`
+	UpdateAt    time.Time `json:"update_at" db:"update_at"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type TaskKind string
+
+const (
+	TaskBatchUpload   TaskKind = "batch_upload"
+	TaskDatasetExport TaskKind = "dataset_export"
+	TaskDatasetImport TaskKind = "dataset_import"
+	TaskEvaluation    TaskKind = "evaluation"
+)
+
+// Task is a request run in the background, e.g. an uploaded archive that is
+// decoded after the upload is answered. Params and Result are the JSON of the
+// kind. Input is the spooled request body, Output names the file the task
+// produced for download, both are empty if the kind has none.
+type Task struct {
+	Id       int             `json:"id" db:"id"`
+	Kind     TaskKind        `json:"kind" db:"kind"`
+	Status   JobStatus       `json:"status" db:"status"`
+	Params   json.RawMessage `json:"params" db:"-"`
+	Result   json.RawMessage `json:"result,omitempty" db:"-"`
+	Input    string          `json:"-" db:"input"`
+	Output   string          `json:"output,omitempty" db:"output"`
+	Error    string          `json:"error,omitempty" db:"error"`
+	CreateAt time.Time       `json:"create_at" db:"create_at"`
+	UpdateAt time.Time       `json:"update_at" db:"update_at"`
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Tower is a support structure (опора) of a lap. Number is the order of the
+// tower along the line route.
+type Tower struct {
+	Id        int       `json:"id" db:"id"`
+	LapId     string    `json:"lap_id" db:"lap_id"`
+	Number    int       `json:"number" db:"number"`
+	Name      string    `json:"name" db:"name"`
+	Latitude  *float64  `json:"latitude" db:"latitude"`
+	Longitude *float64  `json:"longitude" db:"longitude"`
+	CreateAt  time.Time `json:"create_at" db:"create_at"`
+}
+
+// Span is a section of a lap between two adjacent towers.
+type Span struct {
+	Id          int    `json:"id" db:"id"`
+	LapId       string `json:"lap_id" db:"lap_id"`
+	FromTowerId int    `json:"from_tower_id" db:"from_tower_id"`
+	ToTowerId   int    `json:"to_tower_id" db:"to_tower_id"`
+	// Length is the span length in meters.
+	Length float64 `json:"length" db:"length"`
+}
+
+type TowerLinkSource string
+
+const (
+	// TowerLinkExplicit links are set by the client on upload.
+	TowerLinkExplicit TowerLinkSource = "explicit"
+	// TowerLinkInferred links are derived from the uploaded file name or the
+	// photo GPS position.
+	TowerLinkInferred TowerLinkSource = "inferred"
+)
+
+type ImageTower struct {
+	GroupId  int             `json:"group_id" db:"group_id"`
+	ImageUid uuid.UUID       `json:"image_uid" db:"image_uid"`
+	TowerId  int             `json:"tower_id" db:"tower_id"`
+	Source   TowerLinkSource `json:"source" db:"source"`
+}
@@ -0,0 +1,333 @@
+package annotations
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"image"
+	"image/jpeg"
+	"io"
+	"slices"
+	"time"
+)
+
+type Repo interface {
+	Save(ctx context.Context, detection *entity.Detection) error
+	SaveRects(ctx context.Context, rects []entity.RectDetection) error
+	Get(ctx context.Context, id int) (*entity.Detection, error)
+	GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]aggregate.DetectionRect, error)
+	UpdateRect(ctx context.Context, rect entity.RectDetection) error
+	SetSource(ctx context.Context, id int, source entity.DetectionSource) error
+	SetReview(ctx context.Context, id int, review entity.Review) error
+	SetImageReviewed(ctx context.Context, review entity.ImageReview) error
+	Delete(ctx context.Context, id int) error
+}
+
+type HistoryRepo interface {
+	Save(ctx context.Context, change *entity.AnnotationChange) error
+	GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]entity.AnnotationChange, error)
+}
+
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Images interface {
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+}
+
+type Classes interface {
+	Classes() []string
+}
+
+type Masks interface {
+	InvalidateRect(detectionId int)
+}
+
+// Box is a rectangle in pixels of the image.
+type Box struct {
+	X0 int `json:"x0"`
+	Y0 int `json:"y0"`
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+}
+
+func (b Box) Rect() image.Rectangle {
+	return image.Rect(b.X0, b.Y0, b.X1, b.Y1)
+}
+
+// Edit changes a detection, empty Class and nil Box keep the current ones.
+type Edit struct {
+	Class  string `json:"class,omitempty"`
+	Box    *Box   `json:"box,omitempty"`
+	Editor string `json:"editor"`
+}
+
+type Service struct {
+	repo    Repo
+	history HistoryRepo
+	uow     UnitOfWork
+	images  Images
+	classes Classes
+	masks   Masks
+}
+
+func NewService(repo Repo, history HistoryRepo, uow UnitOfWork, images Images, classes Classes, masks Masks) *Service {
+	return &Service{
+		repo:    repo,
+		history: history,
+		uow:     uow,
+		images:  images,
+		classes: classes,
+		masks:   masks,
+	}
+}
+
+// Classes returns the classes a box can be labeled with.
+func (s *Service) Classes() []string {
+	return s.classes.Classes()
+}
+
+// Create draws a box missed by the model. Human boxes are confirmed by
+// their editor.
+func (s *Service) Create(ctx context.Context, groupId int, imageUid uuid.UUID, class string, box Box, editor string) (*aggregate.DetectionRect, error) {
+	const op = "annotations_service.Create"
+
+	if err := s.validate(class, editor); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bounds, err := s.imageBounds(ctx, groupId, imageUid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := validateBox(box, bounds); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().In(time.UTC)
+	detection := &entity.Detection{
+		GroupId:      groupId,
+		ImageUid:     imageUid,
+		Class:        class,
+		Source:       entity.SourceHuman,
+		ReviewStatus: entity.ReviewConfirmed,
+		ReviewedBy:   editor,
+		ReviewedAt:   &now,
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Save(ctx, detection); err != nil {
+			return err
+		}
+
+		return s.repo.SaveRects(ctx, []entity.RectDetection{{
+			DetectionId: detection.Id,
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+			X0:          box.X0,
+			Y0:          box.Y0,
+			X1:          box.X1,
+			Y1:          box.Y1,
+			Confidence:  1,
+		}})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rect, err := s.get(ctx, detection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rect, nil
+}
+
+// Update moves the box and changes the class of a detection. The detection
+// becomes human made, a changed class marks it corrected. The previous label
+// is kept in the history.
+func (s *Service) Update(ctx context.Context, detectionId int, edit Edit) (*aggregate.DetectionRect, error) {
+	const op = "annotations_service.Update"
+
+	if edit.Class == "" && edit.Box == nil {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("class or box is required"))
+	}
+
+	detection, err := s.repo.Get(ctx, detectionId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	class := detection.Class
+	if edit.Class != "" {
+		class = edit.Class
+	}
+	if err := s.validate(class, edit.Editor); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.get(ctx, detection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if edit.Box != nil {
+		if err := validateBox(*edit.Box, image.Rect(0, 0, current.Width, current.Height)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	review := entity.Review{
+		Status:   entity.ReviewConfirmed,
+		Reviewer: edit.Editor,
+		Comment:  detection.ReviewComment,
+		Class:    class,
+		At:       time.Now().In(time.UTC),
+	}
+	if class != detection.Class || detection.ReviewStatus == entity.ReviewCorrected {
+		review.Status = entity.ReviewCorrected
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.history.Save(ctx, current.Change(entity.AnnotationUpdated, edit.Editor, review.At)); err != nil {
+			return err
+		}
+
+		if edit.Box != nil {
+			if err := s.repo.UpdateRect(ctx, entity.RectDetection{
+				DetectionId: detectionId,
+				X0:          edit.Box.X0,
+				Y0:          edit.Box.Y0,
+				X1:          edit.Box.X1,
+				Y1:          edit.Box.Y1,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.SetReview(ctx, detectionId, review); err != nil {
+			return err
+		}
+
+		return s.repo.SetSource(ctx, detectionId, entity.SourceHuman)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.masks.InvalidateRect(detectionId)
+
+	rect, err := s.get(ctx, detection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rect, nil
+}
+
+// Delete removes a detection with its box, the deleted label is kept in the
+// history. The image is marked reviewed by the editor, so an image left
+// without boxes is a clean one.
+func (s *Service) Delete(ctx context.Context, detectionId int, editor string) error {
+	const op = "annotations_service.Delete"
+
+	if editor == "" {
+		return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("editor is required"))
+	}
+
+	detection, err := s.repo.Get(ctx, detectionId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.get(ctx, detection)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().In(time.UTC)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.history.Save(ctx, current.Change(entity.AnnotationDeleted, editor, now)); err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, detectionId); err != nil {
+			return err
+		}
+
+		// the image stays reviewed if its last box is deleted
+		return s.repo.SetImageReviewed(ctx, entity.ImageReview{GroupId: current.GroupId, ImageUid: current.ImageUid, ReviewedBy: editor, ReviewedAt: now})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.masks.InvalidateRect(detectionId)
+
+	return nil
+}
+
+// History returns the changes of the image labels, the oldest first.
+func (s *Service) History(ctx context.Context, groupId int, imageUid uuid.UUID) ([]entity.AnnotationChange, error) {
+	const op = "annotations_service.History"
+
+	changes, err := s.history.GetByImage(ctx, groupId, imageUid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return changes, nil
+}
+
+// get returns the detection with its box.
+func (s *Service) get(ctx context.Context, detection *entity.Detection) (*aggregate.DetectionRect, error) {
+	rects, err := s.repo.GetByImage(ctx, detection.GroupId, detection.ImageUid)
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(rects, func(rect aggregate.DetectionRect) bool { return rect.Id == detection.Id })
+	if i == -1 {
+		return nil, failure.NewNotFoundError("detection has no box")
+	}
+
+	return &rects[i], nil
+}
+
+func (s *Service) validate(class, editor string) error {
+	if editor == "" {
+		return failure.NewInvalidRequestError("editor is required")
+	}
+	if !slices.Contains(s.classes.Classes(), class) {
+		return failure.NewInvalidRequestError(fmt.Sprintf("unknown class %q", class))
+	}
+	return nil
+}
+
+func (s *Service) imageBounds(ctx context.Context, groupId int, imageUid uuid.UUID) (image.Rectangle, error) {
+	f, err := s.images.Open(ctx, groupId, imageUid)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	defer f.Close()
+
+	cfg, err := jpeg.DecodeConfig(f)
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("decode image failed: %w", err)
+	}
+
+	return image.Rect(0, 0, cfg.Width, cfg.Height), nil
+}
+
+func validateBox(box Box, bounds image.Rectangle) error {
+	if box.X0 >= box.X1 || box.Y0 >= box.Y1 {
+		return failure.NewInvalidRequestError("box is empty, x0 < x1 and y0 < y1 are expected")
+	}
+	if !box.Rect().In(bounds) {
+		return failure.NewInvalidRequestError(fmt.Sprintf("box is outside of the %dx%d image", bounds.Dx(), bounds.Dy()))
+	}
+	return nil
+}
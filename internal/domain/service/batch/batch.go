@@ -0,0 +1,333 @@
+package batch
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/inference"
+	"FairLAP/pkg/logx"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"iter"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Groups interface {
+	CreateGroup(ctx context.Context, lapId string) (int, error)
+	DeleteGroup(ctx context.Context, id int) error
+}
+
+type Images interface {
+	Save(ctx context.Context, groupId int, file entity.ImageFile) (uuid.UUID, error)
+}
+
+type Jobs interface {
+	EnqueueSaved(ctx context.Context, groupId int, imageUids []uuid.UUID, thresholds *inference.Thresholds) (*entity.Job, error)
+}
+
+type Towers interface {
+	CheckLapTower(ctx context.Context, lapId string, towerId int) error
+	FindByNumber(ctx context.Context, lapId string, number int) (*entity.Tower, error)
+	FindNearest(ctx context.Context, lapId string, lat, lon float64) (*entity.Tower, error)
+	LinkImage(ctx context.Context, groupId int, imageUid uuid.UUID, towerId int, source entity.TowerLinkSource) error
+}
+
+type MetaRepo interface {
+	Save(ctx context.Context, meta *entity.ImageMeta) error
+}
+
+type Tasks interface {
+	Spool(body io.Reader) (string, error)
+	Enqueue(ctx context.Context, kind entity.TaskKind, params any, input string) (*entity.Task, error)
+}
+
+// Archives reads the files of a spooled upload.
+type Archives interface {
+	Open(path, contentType string) (iter.Seq[File], io.Closer, error)
+}
+
+type Service struct {
+	groups   Groups
+	images   Images
+	jobs     Jobs
+	towers   Towers
+	meta     MetaRepo
+	tasks    Tasks
+	archives Archives
+}
+
+func NewService(groups Groups, images Images, jobs Jobs, towers Towers, meta MetaRepo, tasks Tasks, archives Archives) *Service {
+	return &Service{
+		groups:   groups,
+		images:   images,
+		jobs:     jobs,
+		towers:   towers,
+		meta:     meta,
+		tasks:    tasks,
+		archives: archives,
+	}
+}
+
+// Request is an upload of a ZIP archive or a multipart form of photos.
+// ContentType is the Content-Type of the upload, the multipart boundary
+// included.
+type Request struct {
+	LapId       string                `json:"lap_id"`
+	TowerId     int                   `json:"tower_id,omitempty"`
+	Thresholds  *inference.Thresholds `json:"thresholds,omitempty"`
+	ContentType string                `json:"content_type"`
+}
+
+// params are the params of the batch upload task.
+type params struct {
+	GroupId int `json:"group_id"`
+	Request
+}
+
+// File is a single entry of an uploaded archive. Decode is called lazily so
+// that only one image of the batch is held in memory at a time. Decode
+// returns nil meta for photos without EXIF and XMP.
+type File struct {
+	Name   string
+	Decode func() (entity.ImageFile, *entity.ImageMeta, error)
+}
+
+// FileResult reports one file of the batch. MetaError is set for an accepted
+// image whose metadata could not be saved.
+type FileResult struct {
+	File      string     `json:"file"`
+	ImageUid  *uuid.UUID `json:"image_uid,omitempty"`
+	TowerId   int        `json:"tower_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	MetaError string     `json:"meta_error,omitempty"`
+}
+
+type Summary struct {
+	GroupId  int          `json:"group_id"`
+	JobId    int          `json:"job_id,omitempty"`
+	Total    int          `json:"total"`
+	Accepted int          `json:"accepted"`
+	Rejected int          `json:"rejected"`
+	Files    []FileResult `json:"files"`
+}
+
+// Upload spools the body and queues a task that saves its files to a new
+// group of the lap and enqueues detection, the task result is the Summary.
+// Images are linked to TowerId if it is set, otherwise the tower is inferred
+// from the file path or the photo GPS position, see inferTower.
+func (s *Service) Upload(ctx context.Context, req Request, body io.Reader) (*entity.Task, error) {
+	const op = "batch_service.Upload"
+
+	if req.TowerId != 0 {
+		if err := s.towers.CheckLapTower(ctx, req.LapId, req.TowerId); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	groupId, err := s.groups.CreateGroup(ctx, req.LapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task, err := s.enqueue(ctx, params{GroupId: groupId, Request: req}, body)
+	if err != nil {
+		s.deleteGroup(ctx, groupId)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Service) enqueue(ctx context.Context, p params, body io.Reader) (*entity.Task, error) {
+	input, err := s.tasks.Spool(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tasks.Enqueue(ctx, entity.TaskBatchUpload, p, input)
+}
+
+// Run is the task of an upload, it saves the spooled files to the group.
+func (s *Service) Run(ctx context.Context, task *entity.Task, _ string) (any, error) {
+	const op = "batch_service.Run"
+
+	var p params
+	if err := json.Unmarshal(task.Params, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary, err := s.run(ctx, p, task.Input)
+	if err != nil {
+		s.deleteGroup(ctx, p.GroupId)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return summary, nil
+}
+
+// Abort removes the group of an upload interrupted by a restart.
+func (s *Service) Abort(ctx context.Context, task *entity.Task) {
+	var p params
+	if err := json.Unmarshal(task.Params, &p); err != nil {
+		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "decode batch params", logx.Error(err))
+		return
+	}
+
+	s.deleteGroup(ctx, p.GroupId)
+}
+
+func (s *Service) run(ctx context.Context, p params, input string) (*Summary, error) {
+	files, closer, err := s.archives.Open(input, p.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	return s.upload(ctx, p.LapId, p.GroupId, p.TowerId, files, p.Thresholds)
+}
+
+// deleteGroup removes the group of a failed upload, the group is new, so it
+// drops the saved files with their meta and tower links. ctx may be already
+// cancelled here.
+func (s *Service) deleteGroup(ctx context.Context, groupId int) {
+	if err := s.groups.DeleteGroup(context.WithoutCancel(ctx), groupId); err != nil {
+		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "delete failed batch group", logx.Error(err))
+	}
+}
+
+func (s *Service) upload(ctx context.Context, lapId string, groupId, towerId int, files iter.Seq[File], thresholds *inference.Thresholds) (*Summary, error) {
+	summary := &Summary{
+		GroupId: groupId,
+	}
+
+	var uids []uuid.UUID
+	towers := make(map[int]*entity.Tower)
+
+	for file := range files {
+		result := FileResult{File: file.Name}
+
+		uid, meta, err := s.save(ctx, groupId, file)
+		if err != nil {
+			result.Error = err.Error()
+			summary.Rejected++
+			summary.Files = append(summary.Files, result)
+			continue
+		}
+
+		result.ImageUid = &uid
+		uids = append(uids, uid)
+		summary.Accepted++
+
+		if meta != nil {
+			meta.GroupId = groupId
+			meta.ImageUid = uid
+			if err := s.meta.Save(ctx, meta); err != nil {
+				contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "save image meta", slog.String("file", file.Name), logx.Error(err))
+				result.MetaError = err.Error()
+			}
+		}
+
+		result.TowerId, err = s.linkTower(ctx, lapId, groupId, uid, towerId, file.Name, meta, towers)
+		if err != nil {
+			return nil, err
+		}
+
+		summary.Files = append(summary.Files, result)
+	}
+
+	summary.Total = len(summary.Files)
+
+	if len(uids) == 0 {
+		return summary, nil
+	}
+
+	job, err := s.jobs.EnqueueSaved(ctx, groupId, uids, thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.JobId = job.Id
+
+	return summary, nil
+}
+
+func (s *Service) linkTower(ctx context.Context, lapId string, groupId int, uid uuid.UUID, towerId int, name string, meta *entity.ImageMeta, towers map[int]*entity.Tower) (int, error) {
+	source := entity.TowerLinkExplicit
+
+	if towerId == 0 {
+		tower, err := s.inferTower(ctx, lapId, name, meta, towers)
+		if err != nil || tower == nil {
+			return 0, err
+		}
+
+		towerId = tower.Id
+		source = entity.TowerLinkInferred
+	}
+
+	if err := s.towers.LinkImage(ctx, groupId, uid, towerId, source); err != nil {
+		return 0, err
+	}
+
+	return towerId, nil
+}
+
+// inferTower finds the tower by the number in the file name, falling back to
+// the tower nearest to the photo GPS position.
+func (s *Service) inferTower(ctx context.Context, lapId string, name string, meta *entity.ImageMeta, towers map[int]*entity.Tower) (*entity.Tower, error) {
+	if number, ok := towerNumber(name); ok {
+		tower, found := towers[number]
+		if !found {
+			var err error
+			if tower, err = s.towers.FindByNumber(ctx, lapId, number); err != nil {
+				return nil, err
+			}
+			towers[number] = tower
+		}
+		if tower != nil {
+			return tower, nil
+		}
+	}
+
+	if meta.HasLocation() {
+		return s.towers.FindNearest(ctx, lapId, *meta.Latitude, *meta.Longitude)
+	}
+
+	return nil, nil
+}
+
+var towerPattern = regexp.MustCompile(`(?i)^(?:tower|опора|op)?[ _-]*(\d+)(?:$|[ _.-])`)
+
+// towerNumber extracts the tower number from an archive path. Directories are
+// checked first, so both "12/IMG_0001.jpg" and "tower_12_1.jpg" give 12.
+func towerNumber(name string) (int, bool) {
+	for _, p := range strings.Split(name, "/") {
+		m := towerPattern.FindStringSubmatch(p)
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			return n, true
+		}
+	}
+
+	return 0, false
+}
+
+func (s *Service) save(ctx context.Context, groupId int, file File) (uuid.UUID, *entity.ImageMeta, error) {
+	decoded, meta, err := file.Decode()
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	uid, err := s.images.Save(ctx, groupId, decoded)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	return uid, meta, nil
+}
@@ -0,0 +1,352 @@
+package datasetexport
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/dataset"
+	"FairLAP/pkg/failure"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"hash/fnv"
+	"image/jpeg"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+type GroupsRepo interface {
+	GetLaps(ctx context.Context) ([]aggregate.LapLastDetect, error)
+	GetByLap(ctx context.Context, lapId string) ([]entity.Group, error)
+}
+
+type DetectionsRepo interface {
+	GetRectsByGroup(ctx context.Context, groupId int) ([]aggregate.DetectionRect, error)
+	GetReviewedImages(ctx context.Context, groupId int) ([]uuid.UUID, error)
+}
+
+type Images interface {
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+}
+
+type Classes interface {
+	Classes() []string
+}
+
+type Tasks interface {
+	Enqueue(ctx context.Context, kind entity.TaskKind, params any, input string) (*entity.Task, error)
+}
+
+type Service struct {
+	groups     GroupsRepo
+	detections DetectionsRepo
+	images     Images
+	classes    Classes
+	tasks      Tasks
+}
+
+func NewService(groups GroupsRepo, detections DetectionsRepo, images Images, classes Classes, tasks Tasks) *Service {
+	return &Service{
+		groups:     groups,
+		detections: detections,
+		images:     images,
+		classes:    classes,
+		tasks:      tasks,
+	}
+}
+
+// Filter selects the exported detections. An empty LapId selects every lap,
+// zero From and To don't limit the group creation time, To is exclusive.
+type Filter struct {
+	LapId  string                `json:"lap_id,omitempty"`
+	From   time.Time             `json:"from"`
+	To     time.Time             `json:"to"`
+	Review []entity.ReviewStatus `json:"review,omitempty"`
+	// ValRatio is the share of images in the validation split.
+	ValRatio float64 `json:"val_ratio"`
+}
+
+// Request is an export of the filtered dataset in Format, the params of the
+// export task.
+type Request struct {
+	Filter
+	Format dataset.Format `json:"format"`
+}
+
+// Result is the result of the export task.
+type Result struct {
+	Images int `json:"images"`
+	Boxes  int `json:"boxes"`
+}
+
+// DefaultReview are the statuses of detections checked by an engineer.
+var DefaultReview = []entity.ReviewStatus{entity.ReviewConfirmed, entity.ReviewCorrected}
+
+// Export queues a task writing the dataset to a ZIP archive, the archive is
+// downloaded from the finished task.
+func (s *Service) Export(ctx context.Context, req Request) (*entity.Task, error) {
+	const op = "datasetexport_service.Export"
+
+	if !req.Format.IsValid() {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("invalid format"))
+	}
+	if err := validate(&req.Filter); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task, err := s.tasks.Enqueue(ctx, entity.TaskDatasetExport, req, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// Run is the export task, it writes the archive to output.
+func (s *Service) Run(ctx context.Context, task *entity.Task, output string) (any, error) {
+	const op = "datasetexport_service.Run"
+
+	var req Request
+	if err := json.Unmarshal(task.Params, &req); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := s.Dataset(ctx, req.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := writeZip(output, req.Format, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task.Output = fmt.Sprintf("dataset_%s.zip", req.Format)
+
+	result := Result{Images: len(data.Images)}
+	for _, img := range data.Images {
+		result.Boxes += len(img.Boxes)
+	}
+
+	return result, nil
+}
+
+// Abort does nothing, the output of an interrupted export is removed by the
+// tasks service.
+func (s *Service) Abort(context.Context, *entity.Task) {}
+
+func writeZip(path string, format dataset.Format, data *dataset.Dataset) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := dataset.WriteZip(f, format, data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func validate(filter *Filter) error {
+	if filter.ValRatio < 0 || filter.ValRatio > 1 {
+		return failure.NewInvalidRequestError("val ratio must be in [0, 1]")
+	}
+	if len(filter.Review) == 0 {
+		filter.Review = DefaultReview
+	}
+	for _, status := range filter.Review {
+		if !status.IsValid() {
+			return failure.NewInvalidRequestError(fmt.Sprintf("unknown review status %q", status))
+		}
+	}
+	return nil
+}
+
+// Dataset collects the reviewed images of the filtered groups. An image is
+// exported once none of its detections waits for review: the boxes with a
+// status of filter.Review become labels, rejected ones are false positives
+// and are dropped, so an image with only rejected boxes is a background
+// sample, as is an image reviewed as a whole without detections. Classes
+// are the model classes. Images are split by their uid, so
+// an image stays in the same split across exports with the same ratio.
+func (s *Service) Dataset(ctx context.Context, filter Filter) (*dataset.Dataset, error) {
+	const op = "datasetexport_service.Dataset"
+
+	if err := validate(&filter); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groups, err := s.filterGroups(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := &dataset.Dataset{
+		Classes: s.classes.Classes(),
+	}
+
+	for _, group := range groups {
+		rects, err := s.detections.GetRectsByGroup(ctx, group.Id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		reviewedImages, err := s.detections.GetReviewedImages(ctx, group.Id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		detected := make(map[uuid.UUID]bool)
+
+		for _, imageRects := range byImage(rects) {
+			first := imageRects[0]
+			detected[first.ImageUid] = true
+
+			if !reviewed(imageRects, filter.Review) {
+				continue
+			}
+
+			img := s.image(ctx, first.GroupId, first.ImageUid, first.Width, first.Height, filter.ValRatio)
+
+			for _, rect := range imageRects {
+				if !slices.Contains(filter.Review, rect.Review) {
+					continue
+				}
+
+				img.Boxes = append(img.Boxes, dataset.Box{
+					Class: rect.Class,
+					X0:    rect.X0,
+					Y0:    rect.Y0,
+					X1:    rect.X1,
+					Y1:    rect.Y1,
+				})
+			}
+
+			result.Images = append(result.Images, img)
+		}
+
+		// images reviewed as a whole without detections are exported
+		// without labels
+		for _, imageUid := range reviewedImages {
+			if detected[imageUid] {
+				continue
+			}
+
+			width, height, err := s.imageSize(ctx, group.Id, imageUid)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			result.Images = append(result.Images, s.image(ctx, group.Id, imageUid, width, height, filter.ValRatio))
+		}
+	}
+
+	return result, nil
+}
+
+// byImage splits the detections by image in the order of the first
+// detection of every image.
+func byImage(rects []aggregate.DetectionRect) [][]aggregate.DetectionRect {
+	var images [][]aggregate.DetectionRect
+	index := make(map[uuid.UUID]int)
+
+	for _, rect := range rects {
+		i, ok := index[rect.ImageUid]
+		if !ok {
+			i = len(images)
+			index[rect.ImageUid] = i
+			images = append(images, nil)
+		}
+		images[i] = append(images[i], rect)
+	}
+
+	return images
+}
+
+// reviewed reports whether every detection of the image is either exported
+// or rejected.
+func reviewed(rects []aggregate.DetectionRect, review []entity.ReviewStatus) bool {
+	for _, rect := range rects {
+		if rect.Review != entity.ReviewRejected && !slices.Contains(review, rect.Review) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) filterGroups(ctx context.Context, filter Filter) ([]entity.Group, error) {
+	lapIds := []string{filter.LapId}
+	if filter.LapId == "" {
+		laps, err := s.groups.GetLaps(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		lapIds = lapIds[:0]
+		for _, lap := range laps {
+			lapIds = append(lapIds, lap.LapId)
+		}
+	}
+
+	var groups []entity.Group
+
+	for _, lapId := range lapIds {
+		lapGroups, err := s.groups.GetByLap(ctx, lapId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range lapGroups {
+			if !filter.From.IsZero() && group.CreateAt.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && !group.CreateAt.Before(filter.To) {
+				continue
+			}
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+func (s *Service) image(ctx context.Context, groupId int, imageUid uuid.UUID, width, height int, valRatio float64) dataset.Image {
+	return dataset.Image{
+		Name:   fmt.Sprintf("%d_%s", groupId, imageUid),
+		Width:  width,
+		Height: height,
+		Split:  split(imageUid, valRatio),
+		Open: func() (io.ReadCloser, error) {
+			return s.images.Open(ctx, groupId, imageUid)
+		},
+	}
+}
+
+// imageSize reads the size of an image without detections from its JPEG.
+func (s *Service) imageSize(ctx context.Context, groupId int, imageUid uuid.UUID) (int, int, error) {
+	f, err := s.images.Open(ctx, groupId, imageUid)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	cfg, err := jpeg.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("decode image failed: %w", err)
+	}
+
+	return cfg.Width, cfg.Height, nil
+}
+
+func split(imageUid uuid.UUID, valRatio float64) dataset.Split {
+	h := fnv.New32a()
+	h.Write(imageUid[:])
+
+	if float64(h.Sum32()%10000) < valRatio*10000 {
+		return dataset.SplitVal
+	}
+	return dataset.SplitTrain
+}
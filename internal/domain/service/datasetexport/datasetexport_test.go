@@ -0,0 +1,111 @@
+package datasetexport
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepos struct {
+	rects    []aggregate.DetectionRect
+	reviewed []uuid.UUID
+}
+
+func (f fakeRepos) GetLaps(context.Context) ([]aggregate.LapLastDetect, error) {
+	return []aggregate.LapLastDetect{{LapId: "12"}}, nil
+}
+
+func (f fakeRepos) GetByLap(context.Context, string) ([]entity.Group, error) {
+	return []entity.Group{{Id: 1, LapId: "12", CreateAt: time.Now()}}, nil
+}
+
+func (f fakeRepos) GetRectsByGroup(context.Context, int) ([]aggregate.DetectionRect, error) {
+	return f.rects, nil
+}
+
+func (f fakeRepos) GetReviewedImages(context.Context, int) ([]uuid.UUID, error) {
+	return f.reviewed, nil
+}
+
+func (f fakeRepos) Open(context.Context, int, uuid.UUID) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 48)), nil); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func (f fakeRepos) Classes() []string {
+	return []string{"nest", "traverse"}
+}
+
+func TestDatasetReviewFilter(t *testing.T) {
+	rq := require.New(t)
+
+	confirmed, background, pending := uuid.New(), uuid.New(), uuid.New()
+	rect := func(uid uuid.UUID, class string, review entity.ReviewStatus) aggregate.DetectionRect {
+		return aggregate.DetectionRect{GroupId: 1, ImageUid: uid, Class: class, Review: review, Width: 100, Height: 100, X1: 10, Y1: 10}
+	}
+
+	repos := fakeRepos{rects: []aggregate.DetectionRect{
+		rect(confirmed, "nest", entity.ReviewConfirmed),
+		rect(background, "nest", entity.ReviewRejected),
+		rect(pending, "nest", entity.ReviewConfirmed),
+		rect(confirmed, "traverse", entity.ReviewRejected),
+		rect(pending, "traverse", entity.ReviewPending),
+	}}
+	s := NewService(repos, repos, nil, repos, nil)
+
+	data, err := s.Dataset(context.Background(), Filter{})
+	rq.NoError(err)
+	rq.Len(data.Images, 2)
+
+	rq.Equal("1_"+confirmed.String(), data.Images[0].Name)
+	rq.Len(data.Images[0].Boxes, 1)
+	rq.Equal("nest", data.Images[0].Boxes[0].Class)
+
+	rq.Equal("1_"+background.String(), data.Images[1].Name)
+	rq.Empty(data.Images[1].Boxes)
+
+	data, err = s.Dataset(context.Background(), Filter{Review: []entity.ReviewStatus{entity.ReviewConfirmed, entity.ReviewPending}})
+	rq.NoError(err)
+	rq.Len(data.Images, 3)
+	rq.Len(data.Images[2].Boxes, 2)
+}
+
+func TestDatasetCleanImages(t *testing.T) {
+	rq := require.New(t)
+
+	confirmed, clean, pending := uuid.New(), uuid.New(), uuid.New()
+
+	repos := fakeRepos{
+		rects: []aggregate.DetectionRect{
+			{GroupId: 1, ImageUid: confirmed, Class: "nest", Review: entity.ReviewConfirmed, Width: 100, Height: 100, X1: 10, Y1: 10},
+			{GroupId: 1, ImageUid: pending, Class: "nest", Review: entity.ReviewPending, Width: 100, Height: 100, X1: 10, Y1: 10},
+		},
+		// a detection added after the review puts the image back to review
+		reviewed: []uuid.UUID{clean, confirmed, pending},
+	}
+	s := NewService(repos, repos, repos, repos, nil)
+
+	data, err := s.Dataset(context.Background(), Filter{})
+	rq.NoError(err)
+	rq.Len(data.Images, 2)
+
+	rq.Equal("1_"+confirmed.String(), data.Images[0].Name)
+	rq.Len(data.Images[0].Boxes, 1)
+
+	rq.Equal("1_"+clean.String(), data.Images[1].Name)
+	rq.Empty(data.Images[1].Boxes)
+	rq.Equal(64, data.Images[1].Width)
+	rq.Equal(48, data.Images[1].Height)
+}
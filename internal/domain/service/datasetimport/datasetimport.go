@@ -0,0 +1,404 @@
+package datasetimport
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/dataset"
+	"FairLAP/pkg/failure"
+	"FairLAP/pkg/logx"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"image"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Groups interface {
+	CreateGroupAt(ctx context.Context, lapId string, createAt time.Time) (int, error)
+	DeleteGroup(ctx context.Context, id int) error
+}
+
+type Images interface {
+	Save(ctx context.Context, groupId int, file entity.ImageFile) (uuid.UUID, error)
+}
+
+type Repo interface {
+	SaveBatch(ctx context.Context, detections []entity.Detection) error
+	SaveRects(ctx context.Context, rects []entity.RectDetection) error
+	SetImageReviewed(ctx context.Context, review entity.ImageReview) error
+}
+
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Classes interface {
+	Classes() []string
+}
+
+type Tasks interface {
+	Spool(body io.Reader) (string, error)
+	Enqueue(ctx context.Context, kind entity.TaskKind, params any, input string) (*entity.Task, error)
+}
+
+// DefaultReviewer is the reviewer of imported labels if none is given.
+const DefaultReviewer = "import"
+
+// Options of an import. A zero CreateAt dates the group by the import time.
+// ClassMap renames dataset classes to the classes of the model.
+type Options struct {
+	LapId    string            `json:"lap_id"`
+	Format   dataset.Format    `json:"format"`
+	Reviewer string            `json:"reviewer"`
+	CreateAt time.Time         `json:"create_at"`
+	ClassMap map[string]string `json:"class_map,omitempty"`
+}
+
+// params are the params of the import task.
+type params struct {
+	GroupId int `json:"group_id"`
+	Options
+}
+
+type FileResult struct {
+	File       string     `json:"file"`
+	ImageUid   *uuid.UUID `json:"image_uid,omitempty"`
+	Detections int        `json:"detections"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type Summary struct {
+	GroupId    int          `json:"group_id"`
+	Total      int          `json:"total"`
+	Accepted   int          `json:"accepted"`
+	Rejected   int          `json:"rejected"`
+	Detections int          `json:"detections"`
+	Files      []FileResult `json:"files"`
+}
+
+type Service struct {
+	groups  Groups
+	images  Images
+	repo    Repo
+	uow     UnitOfWork
+	classes Classes
+	tasks   Tasks
+}
+
+func NewService(groups Groups, images Images, repo Repo, uow UnitOfWork, classes Classes, tasks Tasks) *Service {
+	return &Service{
+		groups:  groups,
+		images:  images,
+		repo:    repo,
+		uow:     uow,
+		classes: classes,
+		tasks:   tasks,
+	}
+}
+
+// Import saves a labeled dataset to a new group of the lap. Labels become
+// human detections confirmed by the reviewer, so they count in the metrics
+// and serve as ground truth. Every labeled class has to be a class of the
+// model after ClassMap is applied, otherwise nothing is imported. Images that
+// can't be decoded or saved are reported in the summary and skipped.
+func (s *Service) Import(ctx context.Context, fsys fs.FS, opts Options) (*Summary, error) {
+	const op = "datasetimport_service.Import"
+
+	if err := prepare(&opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := s.read(fsys, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groupId, err := s.groups.CreateGroupAt(ctx, opts.LapId, opts.CreateAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary, err := s.save(ctx, groupId, data, opts.Reviewer)
+	if err != nil {
+		s.deleteGroup(ctx, groupId)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return summary, nil
+}
+
+// Upload queues the import of a dataset sent as a ZIP archive, the group is
+// created at once and the summary is the result of the task.
+func (s *Service) Upload(ctx context.Context, opts Options, body io.Reader) (*entity.Task, error) {
+	const op = "datasetimport_service.Upload"
+
+	if err := prepare(&opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groupId, err := s.groups.CreateGroupAt(ctx, opts.LapId, opts.CreateAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task, err := s.enqueue(ctx, params{GroupId: groupId, Options: opts}, body)
+	if err != nil {
+		s.deleteGroup(ctx, groupId)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Service) enqueue(ctx context.Context, p params, body io.Reader) (*entity.Task, error) {
+	input, err := s.tasks.Spool(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tasks.Enqueue(ctx, entity.TaskDatasetImport, p, input)
+}
+
+// Run is the task of an uploaded dataset, it imports the spooled archive to
+// the group.
+func (s *Service) Run(ctx context.Context, task *entity.Task, _ string) (any, error) {
+	const op = "datasetimport_service.Run"
+
+	var p params
+	if err := json.Unmarshal(task.Params, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary, err := s.run(ctx, p, task.Input)
+	if err != nil {
+		s.deleteGroup(ctx, p.GroupId)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return summary, nil
+}
+
+// Abort removes the group of an import interrupted by a restart.
+func (s *Service) Abort(ctx context.Context, task *entity.Task) {
+	var p params
+	if err := json.Unmarshal(task.Params, &p); err != nil {
+		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "decode import params", logx.Error(err))
+		return
+	}
+
+	s.deleteGroup(ctx, p.GroupId)
+}
+
+func (s *Service) run(ctx context.Context, p params, input string) (*Summary, error) {
+	zr, err := zip.OpenReader(input)
+	if err != nil {
+		return nil, failure.NewInvalidRequestError(fmt.Sprintf("invalid zip archive: %s", err))
+	}
+	defer zr.Close()
+
+	data, err := s.read(&zr.Reader, p.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, p.GroupId, data, p.Reviewer)
+}
+
+// prepare checks the format and fills in the default reviewer and date.
+func prepare(opts *Options) error {
+	if opts.Format != dataset.FormatYOLO && opts.Format != dataset.FormatCOCO {
+		return failure.NewInvalidRequestError(fmt.Sprintf("unknown import format %q, use yolo or coco", opts.Format))
+	}
+	if opts.Reviewer == "" {
+		opts.Reviewer = DefaultReviewer
+	}
+	if opts.CreateAt.IsZero() {
+		opts.CreateAt = time.Now()
+	}
+	return nil
+}
+
+// read reads the dataset and maps its classes to the model classes.
+func (s *Service) read(fsys fs.FS, opts Options) (*dataset.Dataset, error) {
+	data, err := dataset.Read(fsys, opts.Format)
+	if err != nil {
+		return nil, failure.NewInvalidRequestError(err.Error())
+	}
+	if len(data.Images) == 0 {
+		return nil, failure.NewInvalidRequestError("dataset has no images")
+	}
+	if err := s.mapClasses(data, opts.ClassMap); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// deleteGroup removes the group of a failed import with everything saved to
+// it, as with uploads. ctx may be already cancelled here.
+func (s *Service) deleteGroup(ctx context.Context, groupId int) {
+	if err := s.groups.DeleteGroup(context.WithoutCancel(ctx), groupId); err != nil {
+		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "delete failed import group", logx.Error(err))
+	}
+}
+
+func (s *Service) save(ctx context.Context, groupId int, data *dataset.Dataset, reviewer string) (*Summary, error) {
+	summary := &Summary{
+		GroupId: groupId,
+		Total:   len(data.Images),
+	}
+
+	for _, img := range data.Images {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := FileResult{File: img.Name}
+
+		uid, err := uuid.Nil, img.Err
+		if err == nil {
+			uid, err = s.saveImage(ctx, groupId, img)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			summary.Rejected++
+			summary.Files = append(summary.Files, result)
+			continue
+		}
+
+		if err := s.saveLabels(ctx, groupId, uid, img, reviewer); err != nil {
+			return nil, err
+		}
+
+		result.ImageUid = &uid
+		result.Detections = len(img.Boxes)
+		summary.Accepted++
+		summary.Detections += len(img.Boxes)
+		summary.Files = append(summary.Files, result)
+	}
+
+	return summary, nil
+}
+
+// mapClasses renames the classes of the boxes by classMap and checks they are
+// classes of the model, a label the model doesn't know can't be reviewed or
+// used as ground truth.
+func (s *Service) mapClasses(data *dataset.Dataset, classMap map[string]string) error {
+	known := s.classes.Classes()
+
+	var unknown []string
+	for i := range data.Images {
+		for j := range data.Images[i].Boxes {
+			box := &data.Images[i].Boxes[j]
+			if class, ok := classMap[box.Class]; ok {
+				box.Class = class
+			}
+			if !slices.Contains(known, box.Class) && !slices.Contains(unknown, box.Class) {
+				unknown = append(unknown, box.Class)
+			}
+		}
+	}
+
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return failure.NewInvalidRequestError(fmt.Sprintf("unknown classes %s, map them to the model classes with class_map", strings.Join(unknown, ", ")))
+	}
+
+	return nil
+}
+
+// ParseClassMap parses "dataset_class:model_class" pairs separated by commas.
+func ParseClassMap(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	classMap := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(pair, ":")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid class mapping %q, expected dataset_class:model_class", pair)
+		}
+		classMap[from] = to
+	}
+
+	return classMap, nil
+}
+
+// saveImage keeps the file as the original. The image is decoded without
+// applying EXIF orientation, labels are drawn on the pixels as stored.
+func (s *Service) saveImage(ctx context.Context, groupId int, img dataset.Image) (uuid.UUID, error) {
+	f, err := img.Open()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer f.Close()
+
+	original, err := io.ReadAll(f)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	return s.images.Save(ctx, groupId, entity.ImageFile{Image: decoded, Original: original})
+}
+
+// saveLabels saves the boxes of the image and marks it reviewed, an image
+// without boxes is a clean one.
+func (s *Service) saveLabels(ctx context.Context, groupId int, uid uuid.UUID, img dataset.Image, reviewer string) error {
+	now := time.Now().In(time.UTC)
+
+	detections := make([]entity.Detection, len(img.Boxes))
+	for i, box := range img.Boxes {
+		detections[i] = entity.Detection{
+			GroupId:      groupId,
+			ImageUid:     uid,
+			Class:        box.Class,
+			Source:       entity.SourceHuman,
+			ReviewStatus: entity.ReviewConfirmed,
+			ReviewedBy:   reviewer,
+			ReviewedAt:   &now,
+		}
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		review := entity.ImageReview{GroupId: groupId, ImageUid: uid, ReviewedBy: reviewer, ReviewedAt: now}
+		if err := s.repo.SetImageReviewed(ctx, review); err != nil {
+			return err
+		}
+		if len(detections) == 0 {
+			return nil
+		}
+
+		if err := s.repo.SaveBatch(ctx, detections); err != nil {
+			return err
+		}
+
+		rects := make([]entity.RectDetection, len(img.Boxes))
+		for i, box := range img.Boxes {
+			rects[i] = entity.RectDetection{
+				DetectionId: detections[i].Id,
+				Width:       img.Width,
+				Height:      img.Height,
+				X0:          box.X0,
+				Y0:          box.Y0,
+				X1:          box.X1,
+				Y1:          box.Y1,
+				Confidence:  1,
+			}
+		}
+
+		return s.repo.SaveRects(ctx, rects)
+	})
+}
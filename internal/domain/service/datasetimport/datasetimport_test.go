@@ -0,0 +1,104 @@
+package datasetimport
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/dataset"
+	"FairLAP/pkg/failure"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepos struct {
+	rects    []entity.RectDetection
+	reviewed []uuid.UUID
+}
+
+func (f *fakeRepos) CreateGroupAt(context.Context, string, time.Time) (int, error) {
+	return 1, nil
+}
+
+func (f *fakeRepos) DeleteGroup(context.Context, int) error {
+	return nil
+}
+
+func (f *fakeRepos) Save(context.Context, int, entity.ImageFile) (uuid.UUID, error) {
+	return uuid.New(), nil
+}
+
+func (f *fakeRepos) SaveBatch(context.Context, []entity.Detection) error {
+	return nil
+}
+
+func (f *fakeRepos) SaveRects(_ context.Context, rects []entity.RectDetection) error {
+	f.rects = append(f.rects, rects...)
+	return nil
+}
+
+func (f *fakeRepos) SetImageReviewed(_ context.Context, review entity.ImageReview) error {
+	f.reviewed = append(f.reviewed, review.ImageUid)
+	return nil
+}
+
+func (f *fakeRepos) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *fakeRepos) Classes() []string {
+	return []string{"insulator", "nest"}
+}
+
+func TestImport(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+	rq.NoError(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10))))
+
+	fsys := fstest.MapFS{
+		"classes.txt": {Data: []byte("bird_nest\n")},
+		"a.png":       {Data: buf.Bytes()},
+		"a.txt":       {Data: []byte("0 0.5 0.5 0.2 0.2\n")},
+		"b.png":       {Data: []byte("not an image")},
+		"c.png":       {Data: buf.Bytes()},
+	}
+
+	repos := new(fakeRepos)
+	s := NewService(repos, repos, repos, repos, repos, nil)
+
+	_, err := s.Import(context.Background(), fsys, Options{LapId: "12", Format: dataset.FormatYOLO})
+	rq.True(failure.IsInvalidRequestError(err))
+	rq.ErrorContains(err, "unknown classes bird_nest")
+
+	summary, err := s.Import(context.Background(), fsys, Options{
+		LapId:    "12",
+		Format:   dataset.FormatYOLO,
+		ClassMap: map[string]string{"bird_nest": "nest"},
+	})
+	rq.NoError(err)
+	rq.Equal(3, summary.Total)
+	rq.Equal(2, summary.Accepted)
+	rq.Equal(1, summary.Rejected)
+	rq.Equal(1, summary.Detections)
+	rq.Contains(summary.Files[1].Error, "decode image b.png")
+	rq.Len(repos.rects, 1)
+	// an image without labels is imported as a clean reviewed one
+	rq.Equal([]uuid.UUID{*summary.Files[0].ImageUid, *summary.Files[2].ImageUid}, repos.reviewed)
+}
+
+func TestParseClassMap(t *testing.T) {
+	rq := require.New(t)
+
+	classMap, err := ParseClassMap("bird_nest:nest, glass : insulator")
+	rq.NoError(err)
+	rq.Equal(map[string]string{"bird_nest": "nest", "glass": "insulator"}, classMap)
+
+	_, err = ParseClassMap("bird_nest")
+	rq.Error(err)
+}
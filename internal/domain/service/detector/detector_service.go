@@ -2,7 +2,7 @@ package detector
 
 import (
 	"FairLAP/internal/domain/entity"
-	"FairLAP/pkg/yolo_model"
+	"FairLAP/pkg/inference"
 	"context"
 	"fmt"
 	"github.com/google/uuid"
@@ -10,71 +10,86 @@ import (
 )
 
 type Repo interface {
-	Save(ctx context.Context, detections *entity.Detection) error
+	DeleteByImage(ctx context.Context, groupId int, imageUid uuid.UUID) error
+	SaveBatch(ctx context.Context, detections []entity.Detection) error
 	SaveRects(ctx context.Context, rects []entity.RectDetection) error
 }
 
-type ImageRepo interface {
-	Save(groupId int, img image.Image) (uuid.UUID, error)
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Model interface {
+	Detect(ctx context.Context, img image.Image, thresholds *inference.Thresholds) ([]inference.Detection, error)
 }
 
 type Service struct {
-	model  *yolo_model.Model
-	repo   Repo
-	images ImageRepo
+	model Model
+	repo  Repo
+	uow   UnitOfWork
 }
 
-func NewService(model *yolo_model.Model, repo Repo, images ImageRepo) *Service {
+func NewService(model Model, repo Repo, uow UnitOfWork) *Service {
 	return &Service{
-		model:  model,
-		repo:   repo,
-		images: images,
+		model: model,
+		repo:  repo,
+		uow:   uow,
 	}
 }
 
-func (s *Service) Detect(ctx context.Context, groupId int, img image.Image) error {
+// Detect runs the model and replaces the model detections of the image with
+// the result in one transaction, so a retried job doesn't duplicate them.
+// Human annotations of the image are kept.
+func (s *Service) Detect(ctx context.Context, groupId int, imgUid uuid.UUID, img image.Image, thresholds *inference.Thresholds) ([]entity.RectDetection, error) {
 	const op = "detector_service.Detect"
 
-	modelsDetections, err := s.model.Detect(img)
+	modelsDetections, err := s.model.Detect(ctx, img, thresholds)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	imgUid, err := s.images.Save(groupId, img)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
+	detections := make([]entity.Detection, len(modelsDetections))
+	for i, detection := range modelsDetections {
+		detections[i] = entity.Detection{
+			GroupId:  groupId,
+			ImageUid: imgUid,
+			Class:    detection.ClassName,
 
-	if len(modelsDetections) == 0 {
-		return nil
+			ConfThreshold: detection.Threshold,
+			Source:        entity.SourceModel,
+			ReviewStatus:  entity.ReviewPending,
+		}
 	}
 
 	rects := make([]entity.RectDetection, len(modelsDetections))
 
-	for i, detection := range modelsDetections {
-		d := &entity.Detection{
-			GroupId:  groupId,
-			ImageUid: imgUid,
-			Class:    detection.ClassName,
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteByImage(ctx, groupId, imgUid); err != nil {
+			return err
 		}
-		if err := s.repo.Save(ctx, d); err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+
+		if err := s.repo.SaveBatch(ctx, detections); err != nil {
+			return err
 		}
-		rects[i] = entity.RectDetection{
-			DetectionId: d.Id,
-			Width:       img.Bounds().Dx(),
-			Height:      img.Bounds().Dy(),
-			X0:          detection.BBox.Min.X,
-			Y0:          detection.BBox.Min.Y,
-			X1:          detection.BBox.Max.X,
-			Y1:          detection.BBox.Max.Y,
-			Confidence:  detection.Confidence,
+
+		for i, detection := range modelsDetections {
+			rects[i] = entity.RectDetection{
+				DetectionId: detections[i].Id,
+				Width:       img.Bounds().Dx(),
+				Height:      img.Bounds().Dy(),
+				X0:          detection.BBox.Min.X,
+				Y0:          detection.BBox.Min.Y,
+				X1:          detection.BBox.Max.X,
+				Y1:          detection.BBox.Max.Y,
+				Confidence:  detection.Confidence,
+			}
 		}
-	}
 
-	if err := s.repo.SaveRects(ctx, rects); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return s.repo.SaveRects(ctx, rects)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return rects, nil
 }
@@ -0,0 +1,169 @@
+package geoexport
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+type GroupsRepo interface {
+	GetLapId(ctx context.Context, groupId int) (string, error)
+	GetByLap(ctx context.Context, lapId string) ([]entity.Group, error)
+}
+
+type DetectionsRepo interface {
+	GetByGroup(ctx context.Context, group int) ([]entity.Detection, error)
+}
+
+type MetaRepo interface {
+	GetByGroup(ctx context.Context, groupId int) ([]entity.ImageMeta, error)
+}
+
+type ConfigService interface {
+	GetConfig(ctx context.Context, lapId string) (map[string]int, error)
+}
+
+type Service struct {
+	groups     GroupsRepo
+	detections DetectionsRepo
+	meta       MetaRepo
+	lapConfig  ConfigService
+}
+
+func NewService(groups GroupsRepo, detections DetectionsRepo, meta MetaRepo, lapConfig ConfigService) *Service {
+	return &Service{
+		groups:     groups,
+		detections: detections,
+		meta:       meta,
+		lapConfig:  lapConfig,
+	}
+}
+
+// ImageFeature is a photo location with the defects detected on the photo.
+type ImageFeature struct {
+	GroupId        int
+	ImageUid       uuid.UUID
+	Latitude       float64
+	Longitude      float64
+	Altitude       *float64
+	CaptureAt      *time.Time
+	Classes        map[string]int
+	MaxDamageLevel int
+	DetectionIds   []int
+}
+
+// GroupFeatures returns features of the group photos that have a location and
+// at least one detection selected by count.
+func (s *Service) GroupFeatures(ctx context.Context, groupId int, count entity.ProblemCount) ([]ImageFeature, error) {
+	const op = "geoexport_service.GroupFeatures"
+
+	if err := checkCount(count); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lapId, err := s.groups.GetLapId(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	config, err := s.lapConfig.GetConfig(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	features, err := s.groupFeatures(ctx, groupId, config, count)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return features, nil
+}
+
+// LapFeatures returns features of all groups of the lap.
+func (s *Service) LapFeatures(ctx context.Context, lapId string, count entity.ProblemCount) ([]ImageFeature, error) {
+	const op = "geoexport_service.LapFeatures"
+
+	if err := checkCount(count); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groups, err := s.groups.GetByLap(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	config, err := s.lapConfig.GetConfig(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var features []ImageFeature
+
+	for _, group := range groups {
+		groupFeatures, err := s.groupFeatures(ctx, group.Id, config, count)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		features = append(features, groupFeatures...)
+	}
+
+	return features, nil
+}
+
+func checkCount(count entity.ProblemCount) error {
+	if !count.IsValid() {
+		return failure.NewInvalidRequestError(fmt.Sprintf("unknown problem count %q", count))
+	}
+	return nil
+}
+
+func (s *Service) groupFeatures(ctx context.Context, groupId int, config map[string]int, count entity.ProblemCount) ([]ImageFeature, error) {
+	meta, err := s.meta.GetByGroup(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	detections, err := s.detections.GetByGroup(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	imageDetections := make(map[uuid.UUID][]entity.Detection)
+	for _, detection := range detections {
+		if !count.Counts(detection.ReviewStatus) {
+			continue
+		}
+		imageDetections[detection.ImageUid] = append(imageDetections[detection.ImageUid], detection)
+	}
+
+	features := make([]ImageFeature, 0, len(meta))
+
+	for _, m := range meta {
+		if !m.HasLocation() || len(imageDetections[m.ImageUid]) == 0 {
+			continue
+		}
+
+		feature := ImageFeature{
+			GroupId:   groupId,
+			ImageUid:  m.ImageUid,
+			Latitude:  *m.Latitude,
+			Longitude: *m.Longitude,
+			Altitude:  m.Altitude,
+			CaptureAt: m.CaptureAt,
+			Classes:   make(map[string]int),
+		}
+
+		for _, detection := range imageDetections[m.ImageUid] {
+			feature.Classes[detection.Class]++
+			feature.DetectionIds = append(feature.DetectionIds, detection.Id)
+			feature.MaxDamageLevel = max(feature.MaxDamageLevel, config[detection.Class])
+		}
+
+		features = append(features, feature)
+	}
+
+	return features, nil
+}
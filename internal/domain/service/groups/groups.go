@@ -6,6 +6,8 @@ import (
 	"FairLAP/pkg/logx"
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,28 +17,50 @@ type Repo interface {
 	Delete(ctx context.Context, id int) error
 }
 
+type LapValidator interface {
+	Validate(ctx context.Context, lapId string) error
+}
+
 type ImagesDeleter interface {
-	DeleteGroup(groupId int) error
+	DeleteGroup(ctx context.Context, groupId int) error
+}
+
+type Cache interface {
+	DeleteFunc(match func(key string) bool) int
 }
 
 type Service struct {
 	repo   Repo
+	laps   LapValidator
 	images ImagesDeleter
+	cache  Cache
 }
 
-func NewService(repo Repo, images ImagesDeleter) *Service {
+func NewService(repo Repo, laps LapValidator, images ImagesDeleter, cache Cache) *Service {
 	return &Service{
 		repo:   repo,
+		laps:   laps,
 		images: images,
+		cache:  cache,
 	}
 }
 
 func (s *Service) CreateGroup(ctx context.Context, lapId string) (int, error) {
-	const op = "groups_service.CreateGroup"
+	return s.CreateGroupAt(ctx, lapId, time.Now())
+}
+
+// CreateGroupAt creates a group dated createAt, it is used for photos taken
+// before they were uploaded.
+func (s *Service) CreateGroupAt(ctx context.Context, lapId string, createAt time.Time) (int, error) {
+	const op = "groups_service.CreateGroupAt"
+
+	if err := s.laps.Validate(ctx, lapId); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
 	group := &entity.Group{
 		LapId:    lapId,
-		CreateAt: time.Now().In(time.UTC),
+		CreateAt: createAt.In(time.UTC),
 	}
 
 	if err := s.repo.Save(ctx, group); err != nil {
@@ -60,9 +84,21 @@ func (s *Service) DeleteGroup(ctx context.Context, id int) error {
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	if err := s.images.DeleteGroup(id); err != nil {
+	if err := s.images.DeleteGroup(ctx, id); err != nil {
 		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "delete group image", logx.Error(err))
 	}
 
+	groupId := strconv.Itoa(id)
+	s.cache.DeleteFunc(func(key string) bool {
+		return isGroupKey(key, groupId)
+	})
+
 	return nil
 }
+
+// isGroupKey reports whether the cache key belongs to the group, images and
+// masks of a group are cached as "<kind>:<group_id>:<image_uid>...".
+func isGroupKey(key, groupId string) bool {
+	parts := strings.SplitN(key, ":", 3)
+	return len(parts) == 3 && parts[1] == groupId
+}
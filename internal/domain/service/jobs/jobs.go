@@ -0,0 +1,360 @@
+package jobs
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/failure"
+	"FairLAP/pkg/inference"
+	"FairLAP/pkg/logx"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"image"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const queueFullMsg = "detection queue is full"
+
+type Repo interface {
+	Save(ctx context.Context, job *entity.Job) error
+	SaveImages(ctx context.Context, images []entity.JobImage) error
+	UpdateStatus(ctx context.Context, id int, status entity.JobStatus, errMsg string) error
+	UpdateImage(ctx context.Context, img *entity.JobImage) error
+	Get(ctx context.Context, id int) (*entity.Job, error)
+	GetImages(ctx context.Context, jobId int) ([]entity.JobImage, error)
+	GetByGroup(ctx context.Context, groupId int) ([]entity.Job, error)
+	GetUnfinished(ctx context.Context) ([]entity.Job, error)
+}
+
+type Detector interface {
+	Detect(ctx context.Context, groupId int, imgUid uuid.UUID, img image.Image, thresholds *inference.Thresholds) ([]entity.RectDetection, error)
+}
+
+type Images interface {
+	Save(ctx context.Context, groupId int, file entity.ImageFile) (uuid.UUID, error)
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+	Delete(ctx context.Context, groupId int, uid uuid.UUID) error
+}
+
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Service struct {
+	repo     Repo
+	uow      UnitOfWork
+	detector Detector
+	images   Images
+
+	workers int
+	queue   chan int
+
+	mu      sync.Mutex
+	waiters map[int]chan struct{}
+}
+
+func NewService(repo Repo, uow UnitOfWork, detector Detector, images Images, workers, queueSize int) *Service {
+	return &Service{
+		repo:     repo,
+		uow:      uow,
+		detector: detector,
+		images:   images,
+		workers:  max(workers, 1),
+		queue:    make(chan int, max(queueSize, 1)),
+		waiters:  make(map[int]chan struct{}),
+	}
+}
+
+// Run requeues jobs left unfinished by a previous process and drains the queue
+// with the worker pool until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	l := contextx.GetLoggerOrDefault(ctx)
+
+	unfinished, err := s.repo.GetUnfinished(ctx)
+	if err != nil {
+		l.ErrorContext(ctx, "get unfinished jobs", logx.Error(err))
+	}
+
+	wg := new(sync.WaitGroup)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, job := range unfinished {
+			select {
+			case s.queue <- job.Id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case id := <-s.queue:
+					s.process(ctx, id)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Service) Enqueue(ctx context.Context, groupId int, images []entity.ImageFile, thresholds *inference.Thresholds) (*entity.Job, error) {
+	const op = "jobs_service.Enqueue"
+
+	if len(s.queue) == cap(s.queue) {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewUnavailableError(queueFullMsg))
+	}
+
+	uids := make([]uuid.UUID, 0, len(images))
+
+	for _, file := range images {
+		uid, err := s.images.Save(ctx, groupId, file)
+		if err != nil {
+			s.deleteImages(ctx, groupId, uids)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		uids = append(uids, uid)
+	}
+
+	job, err := s.EnqueueSaved(ctx, groupId, uids, thresholds)
+	if err != nil {
+		s.deleteImages(ctx, groupId, uids)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// deleteImages removes image files of a failed enqueue, so no files without
+// a job are left.
+func (s *Service) deleteImages(ctx context.Context, groupId int, uids []uuid.UUID) {
+	for _, uid := range uids {
+		if err := s.images.Delete(ctx, groupId, uid); err != nil {
+			contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "delete image", logx.Error(err))
+		}
+	}
+}
+
+// EnqueueSaved creates a job for images already put into the images storage.
+func (s *Service) EnqueueSaved(ctx context.Context, groupId int, imageUids []uuid.UUID, thresholds *inference.Thresholds) (*entity.Job, error) {
+	const op = "jobs_service.EnqueueSaved"
+
+	if len(s.queue) == cap(s.queue) {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewUnavailableError(queueFullMsg))
+	}
+
+	now := time.Now().In(time.UTC)
+
+	job := &entity.Job{
+		GroupId:    groupId,
+		Status:     entity.JobQueued,
+		Thresholds: thresholds,
+		CreateAt:   now,
+		UpdateAt:   now,
+		Images:     make([]entity.JobImage, len(imageUids)),
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Save(ctx, job); err != nil {
+			return err
+		}
+
+		for i, uid := range imageUids {
+			job.Images[i] = entity.JobImage{
+				JobId:    job.Id,
+				ImageUid: uid,
+				Status:   entity.JobQueued,
+			}
+		}
+
+		return s.repo.SaveImages(ctx, job.Images)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	select {
+	case s.queue <- job.Id:
+	default:
+		if err := s.repo.UpdateStatus(ctx, job.Id, entity.JobFailed, queueFullMsg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, failure.NewUnavailableError(queueFullMsg))
+	}
+
+	return job, nil
+}
+
+func (s *Service) Get(ctx context.Context, id int) (*entity.Job, error) {
+	const op = "jobs_service.Get"
+
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Images, err = s.repo.GetImages(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// Wait blocks until the job is finished or ctx is done and returns the last known job state.
+func (s *Service) Wait(ctx context.Context, id int) (*entity.Job, error) {
+	const op = "jobs_service.Wait"
+
+	for {
+		finished := s.subscribe(id)
+
+		job, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if job.Status == entity.JobDone || job.Status == entity.JobFailed {
+			return job, nil
+		}
+
+		select {
+		case <-finished:
+		case <-ctx.Done():
+			return job, nil
+		}
+	}
+}
+
+func (s *Service) subscribe(id int) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.waiters[id]
+	if !ok {
+		ch = make(chan struct{})
+		s.waiters[id] = ch
+	}
+
+	return ch
+}
+
+func (s *Service) notify(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ch, ok := s.waiters[id]; ok {
+		close(ch)
+		delete(s.waiters, id)
+	}
+}
+
+func (s *Service) GetByGroup(ctx context.Context, groupId int) ([]entity.Job, error) {
+	const op = "jobs_service.GetByGroup"
+
+	jobs, err := s.repo.GetByGroup(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+func (s *Service) process(ctx context.Context, id int) {
+	l := contextx.GetLoggerOrDefault(ctx).With(slog.Int("job_id", id))
+	defer s.notify(id)
+
+	if err := s.run(ctx, id); err != nil {
+		l.ErrorContext(ctx, "detection job failed", logx.Error(err))
+		if err := s.repo.UpdateStatus(ctx, id, entity.JobFailed, err.Error()); err != nil {
+			l.ErrorContext(ctx, "update job status", logx.Error(err))
+		}
+	}
+}
+
+func (s *Service) run(ctx context.Context, id int) error {
+	const op = "jobs_service.run"
+
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.UpdateStatus(ctx, id, entity.JobRunning, ""); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	images, err := s.repo.GetImages(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	failed := 0
+
+	for i := range images {
+		jobImage := &images[i]
+		if jobImage.Status == entity.JobDone {
+			continue
+		}
+
+		if err := s.detect(ctx, job, jobImage); err != nil {
+			jobImage.Status = entity.JobFailed
+			jobImage.Error = err.Error()
+			failed++
+		} else {
+			jobImage.Status = entity.JobDone
+			jobImage.Error = ""
+		}
+
+		if err := s.repo.UpdateImage(ctx, jobImage); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	status, errMsg := entity.JobDone, ""
+	if failed > 0 && failed == len(images) {
+		status, errMsg = entity.JobFailed, "detection failed for all images"
+	}
+
+	if err := s.repo.UpdateStatus(ctx, id, status, errMsg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) detect(ctx context.Context, job *entity.Job, jobImage *entity.JobImage) error {
+	const op = "jobs_service.detect"
+
+	f, err := s.images.Open(ctx, job.GroupId, jobImage.ImageUid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rects, err := s.detector.Detect(ctx, job.GroupId, jobImage.ImageUid, img, job.Thresholds)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobImage.DetectionsCount = len(rects)
+
+	return nil
+}
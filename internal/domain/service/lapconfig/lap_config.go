@@ -13,14 +13,20 @@ type Repo interface {
 	DeleteParameters(ctx context.Context, lapId string, classes []string) error
 }
 
+type LapValidator interface {
+	Validate(ctx context.Context, lapId string) error
+}
+
 type Service struct {
 	repo          Repo
+	laps          LapValidator
 	defaultConfig map[string]int
 }
 
-func NewService(repo Repo, defaultConfig map[string]int) *Service {
+func NewService(repo Repo, laps LapValidator, defaultConfig map[string]int) *Service {
 	return &Service{
 		repo:          repo,
+		laps:          laps,
 		defaultConfig: defaultConfig,
 	}
 }
@@ -45,6 +51,10 @@ func (s *Service) SaveDefaultConfig(ctx context.Context, lapId string) error {
 func (s *Service) SaveLapConfig(ctx context.Context, lapId string, params map[string]int) error {
 	const op = "lap_config.SaveLapConfig"
 
+	if err := s.laps.Validate(ctx, lapId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	currentParams, isDefault, err := s.getConfig(ctx, lapId)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -0,0 +1,160 @@
+package laps
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxIdLength is the length of the laps.id column in characters.
+const maxIdLength = 45
+
+type Repo interface {
+	Save(ctx context.Context, lap *entity.Lap) error
+	Update(ctx context.Context, lap *entity.Lap) error
+	Get(ctx context.Context, id string) (*entity.Lap, error)
+	GetAll(ctx context.Context) ([]entity.Lap, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type GroupsRepo interface {
+	GetByLap(ctx context.Context, lapId string) ([]entity.Group, error)
+}
+
+type Service struct {
+	repo   Repo
+	groups GroupsRepo
+}
+
+func NewService(repo Repo, groups GroupsRepo) *Service {
+	return &Service{
+		repo:   repo,
+		groups: groups,
+	}
+}
+
+// ValidateId checks that id may be used as a lap identifier. Ids are free
+// text, e.g. the dispatch name of the line.
+func ValidateId(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return failure.NewInvalidRequestError("lap_id is required")
+	}
+	if !utf8.ValidString(id) || utf8.RuneCountInString(id) > maxIdLength {
+		return failure.NewInvalidRequestError(fmt.Sprintf("invalid lap_id: expected at most %d characters", maxIdLength))
+	}
+	return nil
+}
+
+// Validate checks that lapId is well-formed and refers to an existing lap.
+func (s *Service) Validate(ctx context.Context, lapId string) error {
+	const op = "laps_service.Validate"
+
+	if err := ValidateId(lapId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := s.repo.Get(ctx, lapId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Create(ctx context.Context, lap *entity.Lap) error {
+	const op = "laps_service.Create"
+
+	if err := validateLap(lap); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.repo.Get(ctx, lap.Id); err == nil {
+		return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("lap already exists"))
+	} else if !failure.IsNotFoundError(err) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lap.CreateAt = time.Now().In(time.UTC)
+	lap.UpdateAt = lap.CreateAt
+
+	if err := s.repo.Save(ctx, lap); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Update(ctx context.Context, lap *entity.Lap) error {
+	const op = "laps_service.Update"
+
+	if err := validateLap(lap); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.repo.Get(ctx, lap.Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lap.CreateAt = current.CreateAt
+	lap.UpdateAt = time.Now().In(time.UTC)
+
+	if err := s.repo.Update(ctx, lap); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*entity.Lap, error) {
+	const op = "laps_service.Get"
+	lap, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return lap, nil
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]entity.Lap, error) {
+	const op = "laps_service.GetAll"
+	laps, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return laps, nil
+}
+
+// Delete removes the lap with its config. Laps that still have groups can't
+// be deleted, the groups must be removed first.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	const op = "laps_service.Delete"
+
+	groups, err := s.groups.GetByLap(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(groups) > 0 {
+		return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("lap has groups"))
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func validateLap(lap *entity.Lap) error {
+	if err := ValidateId(lap.Id); err != nil {
+		return err
+	}
+	if lap.Name == "" {
+		return failure.NewInvalidRequestError("lap name is required")
+	}
+	if lap.RouteLength < 0 {
+		return failure.NewInvalidRequestError("route_length must not be negative")
+	}
+	return nil
+}
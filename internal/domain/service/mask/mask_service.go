@@ -2,13 +2,15 @@ package mask
 
 import (
 	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/google/uuid"
 	"image"
 	"image/jpeg"
-	"os"
-	"time"
+	"image/png"
+	"io"
 )
 
 type RectRepo interface {
@@ -16,54 +18,64 @@ type RectRepo interface {
 }
 
 type Polygons interface {
-	DetectPolygons(img image.Image) ([][]image.Point, error)
+	DetectPolygons(ctx context.Context, img image.Image) ([][]image.Point, error)
 }
 
-type Images interface {
-	Open(groupId int, uid uuid.UUID) (*os.File, error)
+type PolygonsRepo interface {
+	Save(ctx context.Context, polygons *entity.ImagePolygons) error
+	Get(ctx context.Context, groupId int, imageUid uuid.UUID) (*entity.ImagePolygons, error)
 }
 
-type Service struct {
-	rectRepo RectRepo
-	polygons Polygons
-	images   Images
-
-	polygonCache map[uuid.UUID]cachedImage
+type Images interface {
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
 }
 
-type cachedImage struct {
-	img image.Image
-	ts  time.Time
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
 }
 
-func NewService(rectRepo RectRepo, polygons Polygons, images Images) *Service {
-	s := &Service{
-		rectRepo: rectRepo,
-		polygons: polygons,
-		images:   images,
+type Service struct {
+	rectRepo     RectRepo
+	polygons     Polygons
+	polygonsRepo PolygonsRepo
+	images       Images
+	cache        Cache
+}
 
-		polygonCache: make(map[uuid.UUID]cachedImage),
+func NewService(rectRepo RectRepo, polygons Polygons, polygonsRepo PolygonsRepo, images Images, cache Cache) *Service {
+	return &Service{
+		rectRepo:     rectRepo,
+		polygons:     polygons,
+		polygonsRepo: polygonsRepo,
+		images:       images,
+		cache:        cache,
 	}
+}
 
-	go func() {
+func rectCacheKey(detectionId int) string {
+	return fmt.Sprintf("mask:rect:%d", detectionId)
+}
 
-		for {
-			time.Sleep(30 * time.Second)
-			now := time.Now()
-			for k, img := range s.polygonCache {
-				if now.Sub(img.ts) > time.Minute*1 {
-					delete(s.polygonCache, k)
-				}
-			}
-		}
-	}()
+func polygonCacheKey(groupId int, imageUid uuid.UUID) string {
+	return fmt.Sprintf("mask_polygon:%d:%s", groupId, imageUid)
+}
 
-	return s
+// InvalidateRect drops the cached mask of a changed detection.
+func (s *Service) InvalidateRect(detectionId int) {
+	s.cache.Delete(rectCacheKey(detectionId))
 }
 
-func (s *Service) GetRectMask(ctx context.Context, detectionId int) (image.Image, error) {
+// GetRectMask returns the detection box rendered as PNG.
+func (s *Service) GetRectMask(ctx context.Context, detectionId int) ([]byte, error) {
 	const op = "service.GetRectMask"
 
+	key := rectCacheKey(detectionId)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached, nil
+	}
+
 	rectDetection, class, err := s.rectRepo.GetRect(ctx, detectionId)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -72,35 +84,89 @@ func (s *Service) GetRectMask(ctx context.Context, detectionId int) (image.Image
 	mask := image.NewRGBA(rectDetection.ImgBounds())
 	drawRectMask(mask, class, rectDetection)
 
-	return mask, nil
+	data, err := encodePNG(mask)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.cache.Set(key, data)
+	return data, nil
 }
 
-func (s *Service) GetPolygonMask(_ context.Context, groupId int, imageUid uuid.UUID) (image.Image, error) {
+// GetPolygonMask returns segmentation polygons of the image rendered as PNG.
+func (s *Service) GetPolygonMask(ctx context.Context, groupId int, imageUid uuid.UUID) ([]byte, error) {
 	const op = "service.GetPolygonMask"
 
-	if cached, ok := s.polygonCache[imageUid]; ok {
-		cached.ts = time.Now()
-		return cached.img, nil
+	key := polygonCacheKey(groupId, imageUid)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached, nil
+	}
+
+	polygons, err := s.GetPolygons(ctx, groupId, imageUid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mask := image.NewRGBA(polygons.ImgBounds())
+	drawPolygon(mask, polygons.Polygons)
+
+	data, err := encodePNG(mask)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	f, err := s.images.Open(groupId, imageUid)
+	s.cache.Set(key, data)
+	return data, nil
+}
+
+// GetPolygons returns stored segmentation polygons of the image, running the
+// segmentation model on the first request.
+func (s *Service) GetPolygons(ctx context.Context, groupId int, imageUid uuid.UUID) (*entity.ImagePolygons, error) {
+	const op = "service.GetPolygons"
+
+	polygons, err := s.polygonsRepo.Get(ctx, groupId, imageUid)
+	if err == nil {
+		return polygons, nil
+	}
+	if !failure.IsNotFoundError(err) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := s.images.Open(ctx, groupId, imageUid)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer f.Close()
 
 	img, err := jpeg.Decode(f)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	p, err := s.polygons.DetectPolygons(img)
+	p, err := s.polygons.DetectPolygons(ctx, img)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	mask := image.NewRGBA(img.Bounds())
-	drawPolygon(mask, p)
+	polygons = &entity.ImagePolygons{
+		GroupId:  groupId,
+		ImageUid: imageUid,
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+		Polygons: p,
+	}
 
-	s.polygonCache[imageUid] = cachedImage{img: img, ts: time.Now()}
-	return mask, nil
+	if err := s.polygonsRepo.Save(ctx, polygons); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return polygons, nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
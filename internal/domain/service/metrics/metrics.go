@@ -3,6 +3,7 @@ package metrics
 import (
 	"FairLAP/internal/domain/aggregate"
 	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
 	"context"
 	"fmt"
 	"github.com/google/uuid"
@@ -11,6 +12,7 @@ import (
 
 type DetectionsRepo interface {
 	GetByGroup(ctx context.Context, group int) ([]entity.Detection, error)
+	GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]aggregate.DetectionRect, error)
 }
 
 type GroupsRepo interface {
@@ -18,42 +20,79 @@ type GroupsRepo interface {
 	GetLapId(ctx context.Context, groupId int) (string, error)
 }
 
+type TowersRepo interface {
+	GetTowersByLap(ctx context.Context, lapId string) ([]entity.Tower, error)
+	GetImageLinks(ctx context.Context, groupId int) ([]entity.ImageTower, error)
+}
+
+type ImageMetaRepo interface {
+	Get(ctx context.Context, groupId int, imageUid uuid.UUID) (*entity.ImageMeta, error)
+	GetByGroup(ctx context.Context, groupId int) ([]entity.ImageMeta, error)
+}
+
+type LapsRepo interface {
+	GetAll(ctx context.Context) ([]entity.Lap, error)
+}
+
 type ConfigService interface {
 	GetConfig(ctx context.Context, lapId string) (map[string]int, error)
 }
 
 type Service struct {
 	groups     GroupsRepo
+	laps       LapsRepo
+	towers     TowersRepo
+	meta       ImageMetaRepo
 	detections DetectionsRepo
 	lapConfig  ConfigService
 }
 
-func NewService(groups GroupsRepo, detections DetectionsRepo, lapConfig ConfigService) *Service {
+func NewService(groups GroupsRepo, laps LapsRepo, towers TowersRepo, meta ImageMetaRepo, detections DetectionsRepo, lapConfig ConfigService) *Service {
 	return &Service{
 		groups:     groups,
+		laps:       laps,
+		towers:     towers,
+		meta:       meta,
 		detections: detections,
 		lapConfig:  lapConfig,
 	}
 }
 
 type LapItem struct {
-	HaveProblems bool      `json:"have_problems"`
-	LastGroup    int       `json:"last_group"`
-	LastDetect   time.Time `json:"last_detect"`
+	// Lap is nil for laps that are only known by groups created before
+	// laps got their own table.
+	Lap          *entity.Lap `json:"lap"`
+	HaveProblems bool        `json:"have_problems"`
+	LastGroup    int         `json:"last_group"`
+	LastDetect   time.Time   `json:"last_detect"`
 }
 
-func (s *Service) GetLaps(ctx context.Context) (map[string]LapItem, error) {
+func (s *Service) GetLaps(ctx context.Context, count entity.ProblemCount) (map[string]LapItem, error) {
 	const op = "metrics_service.GetLaps"
 
+	if err := checkCount(count); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	laps, err := s.groups.GetLaps(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	meta, err := s.laps.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	lapMap := make(map[string]LapItem)
 
+	for i := range meta {
+		lapMap[meta[i].Id] = LapItem{Lap: &meta[i]}
+	}
+
 	for _, lap := range laps {
 		lapItem := LapItem{
+			Lap:        lapMap[lap.LapId].Lap,
 			LastGroup:  lap.LastGroup,
 			LastDetect: lap.LastDetect,
 		}
@@ -63,21 +102,12 @@ func (s *Service) GetLaps(ctx context.Context) (map[string]LapItem, error) {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
-		detections, err := s.detections.GetByGroup(ctx, lap.LastGroup)
+		detections, err := s.counted(ctx, lap.LastGroup, count)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
-		maxSum := config["sum"]
-		deletionsLevelSum := 0
-
-		for _, detection := range detections {
-			deletionsLevelSum += config[detection.Class]
-			if deletionsLevelSum >= maxSum {
-				lapItem.HaveProblems = true
-				break
-			}
-		}
+		lapItem.HaveProblems = haveProblems(config, detections)
 
 		lapMap[lap.LapId] = lapItem
 	}
@@ -85,6 +115,177 @@ func (s *Service) GetLaps(ctx context.Context) (map[string]LapItem, error) {
 	return lapMap, nil
 }
 
+func checkCount(count entity.ProblemCount) error {
+	if !count.IsValid() {
+		return failure.NewInvalidRequestError(fmt.Sprintf("unknown problem count %q", count))
+	}
+	return nil
+}
+
+// counted returns the detections of the group counted as problems.
+func (s *Service) counted(ctx context.Context, groupId int, count entity.ProblemCount) ([]entity.Detection, error) {
+	detections, err := s.detections.GetByGroup(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	counted := detections[:0]
+	for _, detection := range detections {
+		if count.Counts(detection.ReviewStatus) {
+			counted = append(counted, detection)
+		}
+	}
+
+	return counted, nil
+}
+
+// haveProblems reports whether the summed damage level of the detections
+// reaches the "sum" limit of the lap config.
+func haveProblems(config map[string]int, detections []entity.Detection) bool {
+	maxSum := config["sum"]
+	deletionsLevelSum := 0
+
+	for _, detection := range detections {
+		deletionsLevelSum += config[detection.Class]
+		if deletionsLevelSum >= maxSum {
+			return true
+		}
+	}
+
+	return false
+}
+
+type TowersMetric struct {
+	GroupId int           `json:"group_id"`
+	LapId   string        `json:"lap_id"`
+	Towers  []TowerMetric `json:"towers"`
+	// Unlinked counts images of the group that are not linked to any tower.
+	Unlinked TowerMetric `json:"unlinked"`
+}
+
+type TowerMetric struct {
+	Tower           *entity.Tower  `json:"tower,omitempty"`
+	ImageCount      int            `json:"image_count"`
+	DetectionsCount int            `json:"detections_count"`
+	Classes         map[string]int `json:"classes"`
+	DamageLevel     int            `json:"damage_level"`
+	HaveProblems    bool           `json:"have_problems"`
+}
+
+// GetLapTowersMetric is GetTowersMetric for the last group of the lap.
+func (s *Service) GetLapTowersMetric(ctx context.Context, lapId string, count entity.ProblemCount) (*TowersMetric, error) {
+	const op = "metrics_service.GetLapTowersMetric"
+
+	laps, err := s.groups.GetLaps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, lap := range laps {
+		if lap.LapId != lapId {
+			continue
+		}
+
+		metric, err := s.GetTowersMetric(ctx, lap.LastGroup, count)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return metric, nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("lap has no groups"))
+}
+
+// GetTowersMetric groups detections of the group by the towers their images
+// are linked to. Every tower of the lap is reported, even without images.
+// Only the detections selected by count are reported.
+func (s *Service) GetTowersMetric(ctx context.Context, groupId int, count entity.ProblemCount) (*TowersMetric, error) {
+	const op = "metrics_service.GetTowersMetric"
+
+	if err := checkCount(count); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lapId, err := s.groups.GetLapId(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	config, err := s.lapConfig.GetConfig(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	towers, err := s.towers.GetTowersByLap(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	links, err := s.towers.GetImageLinks(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	detections, err := s.counted(ctx, groupId, count)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	imageTower := make(map[uuid.UUID]int, len(links))
+	for _, link := range links {
+		imageTower[link.ImageUid] = link.TowerId
+	}
+
+	towerImages := make(map[int]map[uuid.UUID]struct{})
+	towerDetections := make(map[int][]entity.Detection)
+
+	for _, link := range links {
+		addImage(towerImages, link.TowerId, link.ImageUid)
+	}
+	for _, detection := range detections {
+		towerId := imageTower[detection.ImageUid]
+		addImage(towerImages, towerId, detection.ImageUid)
+		towerDetections[towerId] = append(towerDetections[towerId], detection)
+	}
+
+	metric := &TowersMetric{
+		GroupId:  groupId,
+		LapId:    lapId,
+		Towers:   make([]TowerMetric, len(towers)),
+		Unlinked: towerMetric(config, len(towerImages[0]), towerDetections[0]),
+	}
+
+	for i := range towers {
+		metric.Towers[i] = towerMetric(config, len(towerImages[towers[i].Id]), towerDetections[towers[i].Id])
+		metric.Towers[i].Tower = &towers[i]
+	}
+
+	return metric, nil
+}
+
+func addImage(images map[int]map[uuid.UUID]struct{}, towerId int, imageUid uuid.UUID) {
+	if images[towerId] == nil {
+		images[towerId] = make(map[uuid.UUID]struct{})
+	}
+	images[towerId][imageUid] = struct{}{}
+}
+
+func towerMetric(config map[string]int, imageCount int, detections []entity.Detection) TowerMetric {
+	metric := TowerMetric{
+		ImageCount:      imageCount,
+		DetectionsCount: len(detections),
+		Classes:         make(map[string]int),
+		HaveProblems:    haveProblems(config, detections),
+	}
+
+	for _, detection := range detections {
+		metric.Classes[detection.Class]++
+		metric.DamageLevel += config[detection.Class]
+	}
+
+	return metric
+}
+
 type GroupMetric struct {
 	ImageCount      int                           `json:"image_count"`
 	DetectionsCount int                           `json:"detections_count"`
@@ -134,9 +335,14 @@ type ImageDetection struct {
 	DamageLevel int    `json:"damage_level" db:"damage_level"`
 }
 
-func (s *Service) GetGroupMetricV2(ctx context.Context, groupId int) (*GroupMetricV2, error) {
+// GetGroupMetricV2 reports the detections selected by count per image.
+func (s *Service) GetGroupMetricV2(ctx context.Context, groupId int, count entity.ProblemCount) (*GroupMetricV2, error) {
 	const op = "metrics_service.GetGroupMetric"
 
+	if err := checkCount(count); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	lapId, err := s.groups.GetLapId(ctx, groupId)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -147,7 +353,7 @@ func (s *Service) GetGroupMetricV2(ctx context.Context, groupId int) (*GroupMetr
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	detections, err := s.detections.GetByGroup(ctx, groupId)
+	detections, err := s.counted(ctx, groupId, count)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -170,3 +376,84 @@ func (s *Service) GetGroupMetricV2(ctx context.Context, groupId int) (*GroupMetr
 
 	return metric, nil
 }
+
+type ImageMetric struct {
+	GroupId    int                 `json:"group_id"`
+	ImageUid   uuid.UUID           `json:"image_uid"`
+	Meta       *entity.ImageMeta   `json:"meta,omitempty"`
+	Detections []ImageDetectionBox `json:"detections"`
+}
+
+type ImageDetectionBox struct {
+	Id          int     `json:"id"`
+	Class       string  `json:"class"`
+	Confidence  float32 `json:"confidence"`
+	Threshold   float32 `json:"conf_threshold"`
+	BBox        BBox    `json:"bbox"`
+	DamageLevel int     `json:"damage_level"`
+}
+
+type BBox struct {
+	X0 int `json:"x0"`
+	Y0 int `json:"y0"`
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+}
+
+func (s *Service) GetImageMetric(ctx context.Context, groupId int, imageUid uuid.UUID) (*ImageMetric, error) {
+	const op = "metrics_service.GetImageMetric"
+
+	lapId, err := s.groups.GetLapId(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	config, err := s.lapConfig.GetConfig(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	detections, err := s.detections.GetByImage(ctx, groupId, imageUid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	meta, err := s.meta.Get(ctx, groupId, imageUid)
+	if err != nil && !failure.IsNotFoundError(err) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	metric := &ImageMetric{
+		GroupId:    groupId,
+		ImageUid:   imageUid,
+		Meta:       meta,
+		Detections: make([]ImageDetectionBox, len(detections)),
+	}
+
+	for i, detection := range detections {
+		metric.Detections[i] = ImageDetectionBox{
+			Id:         detection.Id,
+			Class:      detection.Class,
+			Confidence: detection.Confidence,
+			Threshold:  detection.Threshold,
+			BBox: BBox{
+				X0: detection.X0,
+				Y0: detection.Y0,
+				X1: detection.X1,
+				Y1: detection.Y1,
+			},
+			DamageLevel: config[detection.Class],
+		}
+	}
+
+	return metric, nil
+}
+
+func (s *Service) GetGroupMeta(ctx context.Context, groupId int) ([]entity.ImageMeta, error) {
+	const op = "metrics_service.GetGroupMeta"
+	meta, err := s.meta.GetByGroup(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return meta, nil
+}
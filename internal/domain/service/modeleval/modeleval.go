@@ -0,0 +1,370 @@
+package modeleval
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/evaluation"
+	"FairLAP/pkg/failure"
+	"FairLAP/pkg/inference"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"image"
+	"image/jpeg"
+	"io"
+	"slices"
+	"time"
+)
+
+// minConfidence is the threshold the model runs with, precision-recall
+// curves need the low confidence predictions too.
+const minConfidence = 0.001
+
+// DefaultConfThreshold is the confidence precision, recall and the confusion
+// matrix are reported at.
+const DefaultConfThreshold = 0.25
+
+type Repo interface {
+	Save(ctx context.Context, e *entity.Evaluation) error
+	Get(ctx context.Context, id int) (*entity.Evaluation, error)
+	List(ctx context.Context, model string) ([]entity.Evaluation, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type DetectionsRepo interface {
+	GetRectsByGroup(ctx context.Context, groupId int) ([]aggregate.DetectionRect, error)
+	GetReviewedImages(ctx context.Context, groupId int) ([]uuid.UUID, error)
+}
+
+type Images interface {
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+}
+
+type Tasks interface {
+	Enqueue(ctx context.Context, kind entity.TaskKind, params any, input string) (*entity.Task, error)
+}
+
+type Service struct {
+	repo       Repo
+	detections DetectionsRepo
+	images     Images
+	model      inference.Detector
+	modelName  string
+	tasks      Tasks
+}
+
+// NewService evaluates model, its runs are saved under modelName. tasks runs
+// the evaluations started with Start.
+func NewService(repo Repo, detections DetectionsRepo, images Images, model inference.Detector, modelName string, tasks Tasks) *Service {
+	return &Service{
+		repo:       repo,
+		detections: detections,
+		images:     images,
+		model:      model,
+		modelName:  modelName,
+		tasks:      tasks,
+	}
+}
+
+type Request struct {
+	GroupIds []int `json:"group_ids"`
+	// ConfThreshold is DefaultConfThreshold if zero.
+	ConfThreshold float32 `json:"conf_threshold"`
+	Comment       string  `json:"comment"`
+}
+
+// Start queues the evaluation as a task, the model takes a run per image, so
+// the request is answered before it is done. The task result is the saved run
+// without the details, they are at /evaluations/get.
+func (s *Service) Start(ctx context.Context, req Request) (*entity.Task, error) {
+	const op = "modeleval_service.Start"
+
+	if err := validate(&req); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task, err := s.tasks.Enqueue(ctx, entity.TaskEvaluation, req, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// Run evaluates the request of a task.
+func (s *Service) Run(ctx context.Context, task *entity.Task, _ string) (any, error) {
+	const op = "modeleval_service.Run"
+
+	var req Request
+	if err := json.Unmarshal(task.Params, &req); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	e, err := s.Evaluate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	e.Result = nil
+
+	return e, nil
+}
+
+// Abort does nothing, an interrupted evaluation has saved nothing.
+func (s *Service) Abort(context.Context, *entity.Task) {}
+
+// Evaluate runs the model over the reviewed images of the groups and saves
+// the run. An image is reviewed if it has no pending detections and it has
+// reviewed detections or was reviewed as a whole, e.g. imported or found
+// clean. Its confirmed and corrected detections are the ground truth, a clean
+// image has none. Every model class is enabled whatever the model
+// configuration.
+func (s *Service) Evaluate(ctx context.Context, req Request) (*entity.Evaluation, error) {
+	const op = "modeleval_service.Evaluate"
+
+	if err := validate(&req); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	classes := s.model.Classes()
+	conf := float32(minConfidence)
+	thresholds := &inference.Thresholds{
+		Default: &conf,
+		Classes: make(map[string]float32, len(classes)),
+		Enabled: classes,
+	}
+	for _, class := range classes {
+		thresholds.Classes[class] = minConfidence
+	}
+
+	groupIds := slices.Compact(slices.Sorted(slices.Values(req.GroupIds)))
+
+	var samples []evaluation.Sample
+
+	for _, groupId := range groupIds {
+		images, err := s.groundTruth(ctx, groupId)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, img := range images {
+			sample, err := s.predict(ctx, groupId, img, thresholds)
+			if err != nil {
+				return nil, fmt.Errorf("%s: image %s: %w", op, img.uid, err)
+			}
+			samples = append(samples, sample)
+		}
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("groups have no reviewed images"))
+	}
+
+	result := evaluation.Evaluate(classes, samples, req.ConfThreshold)
+
+	e := &entity.Evaluation{
+		Model:         s.modelName,
+		Comment:       req.Comment,
+		GroupIds:      groupIds,
+		ConfThreshold: req.ConfThreshold,
+		Images:        result.Images,
+		Precision:     result.Precision,
+		Recall:        result.Recall,
+		MAP50:         result.MAP50,
+		MAP50_95:      result.MAP50_95,
+		CreateAt:      time.Now().In(time.UTC),
+		Result:        result,
+	}
+
+	if err := s.repo.Save(ctx, e); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return e, nil
+}
+
+func validate(req *Request) error {
+	if len(req.GroupIds) == 0 {
+		return failure.NewInvalidRequestError("group_ids are required")
+	}
+	if req.ConfThreshold == 0 {
+		req.ConfThreshold = DefaultConfThreshold
+	}
+	if req.ConfThreshold < 0 || req.ConfThreshold > 1 {
+		return failure.NewInvalidRequestError("conf_threshold must be in [0, 1]")
+	}
+	return nil
+}
+
+type truthImage struct {
+	uid   uuid.UUID
+	truth []evaluation.Box
+}
+
+func (s *Service) groundTruth(ctx context.Context, groupId int) ([]truthImage, error) {
+	rects, err := s.detections.GetRectsByGroup(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+	reviewed, err := s.detections.GetReviewedImages(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []truthImage
+	index := make(map[uuid.UUID]int)
+	pending := make(map[uuid.UUID]bool)
+
+	// images reviewed as a whole are there even without detections
+	for _, uid := range reviewed {
+		index[uid] = len(images)
+		images = append(images, truthImage{uid: uid})
+	}
+
+	for _, rect := range rects {
+		if rect.Review == entity.ReviewPending {
+			pending[rect.ImageUid] = true
+			continue
+		}
+
+		i, ok := index[rect.ImageUid]
+		if !ok {
+			i = len(images)
+			index[rect.ImageUid] = i
+			images = append(images, truthImage{uid: rect.ImageUid})
+		}
+
+		// rejected detections leave the image reviewed without an object
+		if rect.Review == entity.ReviewConfirmed || rect.Review == entity.ReviewCorrected {
+			images[i].truth = append(images[i].truth, evaluation.Box{
+				Class: rect.Class,
+				Rect:  image.Rect(rect.X0, rect.Y0, rect.X1, rect.Y1),
+			})
+		}
+	}
+
+	return slices.DeleteFunc(images, func(img truthImage) bool {
+		return pending[img.uid]
+	}), nil
+}
+
+func (s *Service) predict(ctx context.Context, groupId int, img truthImage, thresholds *inference.Thresholds) (evaluation.Sample, error) {
+	f, err := s.images.Open(ctx, groupId, img.uid)
+	if err != nil {
+		return evaluation.Sample{}, err
+	}
+	defer f.Close()
+
+	decoded, err := jpeg.Decode(f)
+	if err != nil {
+		return evaluation.Sample{}, fmt.Errorf("decode image failed: %w", err)
+	}
+
+	detections, err := s.model.Detect(ctx, decoded, thresholds)
+	if err != nil {
+		return evaluation.Sample{}, err
+	}
+
+	sample := evaluation.Sample{Truth: img.truth}
+	for _, d := range detections {
+		sample.Predictions = append(sample.Predictions, evaluation.Box{
+			Class:      d.ClassName,
+			Rect:       d.BBox,
+			Confidence: d.Confidence,
+		})
+	}
+
+	return sample, nil
+}
+
+func (s *Service) Get(ctx context.Context, id int) (*entity.Evaluation, error) {
+	const op = "modeleval_service.Get"
+
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return e, nil
+}
+
+// List returns the runs of a model, newest first. An empty model lists every
+// run.
+func (s *Service) List(ctx context.Context, model string) ([]entity.Evaluation, error) {
+	const op = "modeleval_service.List"
+
+	evaluations, err := s.repo.List(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return evaluations, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int) error {
+	const op = "modeleval_service.Delete"
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClassComparison holds the metrics of a class in every compared run, nil
+// if the run has no objects of the class.
+type ClassComparison struct {
+	Class string                     `json:"class"`
+	Runs  []*evaluation.ClassMetrics `json:"runs"`
+}
+
+type Comparison struct {
+	Runs    []entity.Evaluation `json:"runs"`
+	Classes []ClassComparison   `json:"classes"`
+	// SameImages is false if the runs evaluated different groups, their
+	// metrics are then not directly comparable.
+	SameImages bool `json:"same_images"`
+}
+
+// Compare puts the metrics of the runs side by side in the given order.
+func (s *Service) Compare(ctx context.Context, ids []int) (*Comparison, error) {
+	const op = "modeleval_service.Compare"
+
+	if len(ids) < 2 {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("at least two runs are required"))
+	}
+
+	comparison := &Comparison{SameImages: true}
+	classIndex := make(map[string]int)
+
+	for i, id := range ids {
+		e, err := s.repo.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, metrics := range e.Result.Classes {
+			j, ok := classIndex[metrics.Class]
+			if !ok {
+				j = len(comparison.Classes)
+				classIndex[metrics.Class] = j
+				comparison.Classes = append(comparison.Classes, ClassComparison{
+					Class: metrics.Class,
+					Runs:  make([]*evaluation.ClassMetrics, len(ids)),
+				})
+			}
+			comparison.Classes[j].Runs[i] = &metrics
+		}
+
+		if i > 0 && (e.Images != comparison.Runs[0].Images || !slices.Equal(e.GroupIds, comparison.Runs[0].GroupIds)) {
+			comparison.SameImages = false
+		}
+
+		// the details are in Classes
+		e.Result = nil
+		comparison.Runs = append(comparison.Runs, *e)
+	}
+
+	return comparison, nil
+}
@@ -0,0 +1,67 @@
+package modeleval
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDetections struct {
+	rects    []aggregate.DetectionRect
+	reviewed []uuid.UUID
+}
+
+func (f fakeDetections) GetRectsByGroup(context.Context, int) ([]aggregate.DetectionRect, error) {
+	return f.rects, nil
+}
+
+func (f fakeDetections) GetReviewedImages(context.Context, int) ([]uuid.UUID, error) {
+	return f.reviewed, nil
+}
+
+func TestGroundTruth(t *testing.T) {
+	rq := require.New(t)
+
+	confirmed, rejected, pending, clean := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	rect := func(uid uuid.UUID, review entity.ReviewStatus) aggregate.DetectionRect {
+		return aggregate.DetectionRect{GroupId: 1, ImageUid: uid, Class: "nest", Review: review, X1: 10, Y1: 10}
+	}
+
+	s := NewService(nil, fakeDetections{
+		rects: []aggregate.DetectionRect{
+			rect(confirmed, entity.ReviewConfirmed),
+			rect(rejected, entity.ReviewRejected),
+			rect(pending, entity.ReviewConfirmed),
+			rect(pending, entity.ReviewPending),
+		},
+		// a clean image has no detections, a pending image stays out even if reviewed once
+		reviewed: []uuid.UUID{clean, pending, confirmed},
+	}, nil, nil, "", nil)
+
+	images, err := s.groundTruth(context.Background(), 1)
+	rq.NoError(err)
+	rq.Len(images, 3)
+
+	rq.Equal(clean, images[0].uid)
+	rq.Empty(images[0].truth)
+	rq.Equal(confirmed, images[1].uid)
+	rq.Len(images[1].truth, 1)
+	rq.Equal(rejected, images[2].uid)
+	rq.Empty(images[2].truth)
+}
+
+func TestStartValidates(t *testing.T) {
+	rq := require.New(t)
+
+	s := NewService(nil, fakeDetections{}, nil, nil, "", nil)
+
+	_, err := s.Start(context.Background(), Request{})
+	rq.ErrorContains(err, "group_ids are required")
+
+	_, err = s.Start(context.Background(), Request{GroupIds: []int{1}, ConfThreshold: 2})
+	rq.ErrorContains(err, "conf_threshold")
+}
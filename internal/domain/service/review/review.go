@@ -0,0 +1,196 @@
+package review
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"slices"
+	"time"
+)
+
+type Repo interface {
+	Get(ctx context.Context, id int) (*entity.Detection, error)
+	GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]aggregate.DetectionRect, error)
+	SetReview(ctx context.Context, id int, review entity.Review) error
+	SetImageReview(ctx context.Context, groupId int, imageUid uuid.UUID, review entity.Review) error
+	SetImageReviewed(ctx context.Context, review entity.ImageReview) error
+	SetSource(ctx context.Context, id int, source entity.DetectionSource) error
+}
+
+type HistoryRepo interface {
+	Save(ctx context.Context, change *entity.AnnotationChange) error
+}
+
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Images interface {
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+}
+
+type Classes interface {
+	Classes() []string
+}
+
+type Masks interface {
+	InvalidateRect(detectionId int)
+}
+
+type Service struct {
+	repo    Repo
+	history HistoryRepo
+	uow     UnitOfWork
+	images  Images
+	classes Classes
+	masks   Masks
+}
+
+func NewService(repo Repo, history HistoryRepo, uow UnitOfWork, images Images, classes Classes, masks Masks) *Service {
+	return &Service{
+		repo:    repo,
+		history: history,
+		uow:     uow,
+		images:  images,
+		classes: classes,
+		masks:   masks,
+	}
+}
+
+// Review saves the decision of an engineer on a single detection. A corrected
+// detection gets the class of the review, it has to be a class of the model.
+// A new class is a human label, the model one is kept in the history as with
+// annotation edits.
+func (s *Service) Review(ctx context.Context, detectionId int, review entity.Review) (*entity.Detection, error) {
+	const op = "review_service.Review"
+
+	if err := validate(review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if review.Status == entity.ReviewCorrected && review.Class == "" {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("class is required for a corrected detection"))
+	}
+	if review.Status == entity.ReviewCorrected && !slices.Contains(s.classes.Classes(), review.Class) {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError(fmt.Sprintf("unknown class %q", review.Class)))
+	}
+
+	detection, err := s.repo.Get(ctx, detectionId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if review.Status != entity.ReviewCorrected {
+		review.Class = detection.Class
+	}
+	review.At = time.Now().In(time.UTC)
+
+	if review.Class == detection.Class {
+		err = s.repo.SetReview(ctx, detectionId, review)
+	} else {
+		err = s.relabel(ctx, detection, review)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the rect mask is labeled with the class
+	if review.Class != detection.Class {
+		s.masks.InvalidateRect(detectionId)
+	}
+
+	detection, err = s.repo.Get(ctx, detectionId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return detection, nil
+}
+
+// relabel saves a corrected review with a new class, the previous label goes
+// to the history.
+func (s *Service) relabel(ctx context.Context, detection *entity.Detection, review entity.Review) error {
+	rects, err := s.repo.GetByImage(ctx, detection.GroupId, detection.ImageUid)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(rects, func(rect aggregate.DetectionRect) bool { return rect.Id == detection.Id })
+	if i == -1 {
+		return failure.NewNotFoundError("detection has no box")
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.history.Save(ctx, rects[i].Change(entity.AnnotationUpdated, review.Reviewer, review.At)); err != nil {
+			return err
+		}
+		if err := s.repo.SetReview(ctx, detection.Id, review); err != nil {
+			return err
+		}
+		return s.repo.SetSource(ctx, detection.Id, entity.SourceHuman)
+	})
+}
+
+// ReviewImage saves the same decision for every detection of the image and
+// marks the image reviewed, an image without detections is reviewed as clean.
+// Classes are set per detection, so a whole image can't be corrected.
+func (s *Service) ReviewImage(ctx context.Context, groupId int, imageUid uuid.UUID, review entity.Review) ([]aggregate.DetectionRect, error) {
+	const op = "review_service.ReviewImage"
+
+	if err := validate(review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if review.Status == entity.ReviewCorrected {
+		return nil, fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("detections are corrected one by one"))
+	}
+
+	detections, err := s.repo.GetByImage(ctx, groupId, imageUid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(detections) == 0 {
+		// nothing but the file tells a clean image exists
+		f, err := s.images.Open(ctx, groupId, imageUid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		f.Close()
+	}
+
+	review.At = time.Now().In(time.UTC)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetImageReview(ctx, groupId, imageUid, review); err != nil {
+			return err
+		}
+		return s.repo.SetImageReviewed(ctx, entity.ImageReview{
+			GroupId:    groupId,
+			ImageUid:   imageUid,
+			ReviewedBy: review.Reviewer,
+			ReviewedAt: review.At,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	detections, err = s.repo.GetByImage(ctx, groupId, imageUid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return detections, nil
+}
+
+func validate(review entity.Review) error {
+	if !review.Status.IsValid() {
+		return failure.NewInvalidRequestError(fmt.Sprintf("unknown review status %q", review.Status))
+	}
+	if review.Reviewer == "" {
+		return failure.NewInvalidRequestError("reviewer is required")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package review
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepos struct {
+	detection entity.Detection
+	history   []entity.AnnotationChange
+}
+
+func (f *fakeRepos) Get(context.Context, int) (*entity.Detection, error) {
+	detection := f.detection
+	return &detection, nil
+}
+
+func (f *fakeRepos) GetByImage(context.Context, int, uuid.UUID) ([]aggregate.DetectionRect, error) {
+	d := f.detection
+	return []aggregate.DetectionRect{{Id: d.Id, Class: d.Class, Source: d.Source, Review: d.ReviewStatus, X1: 10, Y1: 10}}, nil
+}
+
+func (f *fakeRepos) SetReview(_ context.Context, _ int, review entity.Review) error {
+	f.detection.Class = review.Class
+	f.detection.ReviewStatus = review.Status
+	return nil
+}
+
+func (f *fakeRepos) SetImageReview(context.Context, int, uuid.UUID, entity.Review) error {
+	return nil
+}
+
+func (f *fakeRepos) SetImageReviewed(context.Context, entity.ImageReview) error {
+	return nil
+}
+
+func (f *fakeRepos) SetSource(_ context.Context, _ int, source entity.DetectionSource) error {
+	f.detection.Source = source
+	return nil
+}
+
+func (f *fakeRepos) Save(_ context.Context, change *entity.AnnotationChange) error {
+	f.history = append(f.history, *change)
+	return nil
+}
+
+func (f *fakeRepos) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *fakeRepos) Classes() []string {
+	return []string{"insulator", "nest"}
+}
+
+func (f *fakeRepos) InvalidateRect(int) {}
+
+func TestReviewCorrectedClass(t *testing.T) {
+	rq := require.New(t)
+
+	repos := &fakeRepos{detection: entity.Detection{Id: 1, Class: "insulator", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending}}
+	s := NewService(repos, repos, repos, nil, repos, repos)
+
+	_, err := s.Review(context.Background(), 1, entity.Review{Status: entity.ReviewCorrected, Reviewer: "ivanov", Class: "nset"})
+	rq.True(failure.IsInvalidRequestError(err), err)
+	rq.ErrorContains(err, `unknown class "nset"`)
+	rq.Equal(entity.ReviewPending, repos.detection.ReviewStatus)
+
+	detection, err := s.Review(context.Background(), 1, entity.Review{Status: entity.ReviewCorrected, Reviewer: "ivanov", Class: "nest"})
+	rq.NoError(err)
+	rq.Equal("nest", detection.Class)
+	rq.Equal(entity.ReviewCorrected, detection.ReviewStatus)
+}
+
+func TestReviewRelabelHistory(t *testing.T) {
+	rq := require.New(t)
+
+	repos := &fakeRepos{detection: entity.Detection{Id: 1, Class: "insulator", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending}}
+	s := NewService(repos, repos, repos, nil, repos, repos)
+
+	// a confirmation keeps the model label
+	_, err := s.Review(context.Background(), 1, entity.Review{Status: entity.ReviewConfirmed, Reviewer: "ivanov"})
+	rq.NoError(err)
+	rq.Empty(repos.history)
+	rq.Equal(entity.SourceModel, repos.detection.Source)
+
+	detection, err := s.Review(context.Background(), 1, entity.Review{Status: entity.ReviewCorrected, Reviewer: "petrov", Class: "nest"})
+	rq.NoError(err)
+	rq.Equal(entity.SourceHuman, detection.Source)
+	rq.Len(repos.history, 1)
+	rq.Equal(entity.AnnotationUpdated, repos.history[0].Action)
+	rq.Equal("petrov", repos.history[0].Editor)
+	rq.Equal("insulator", repos.history[0].Class)
+	rq.Equal(entity.SourceModel, repos.history[0].Source)
+}
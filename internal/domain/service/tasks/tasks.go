@@ -0,0 +1,350 @@
+package tasks
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/failure"
+	"FairLAP/pkg/logx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	queueFullMsg   = "task queue is full"
+	interruptedMsg = "interrupted by a restart"
+	cleanupPeriod  = time.Hour
+)
+
+type Repo interface {
+	Save(ctx context.Context, task *entity.Task) error
+	UpdateStatus(ctx context.Context, id int, status entity.JobStatus, errMsg string) error
+	Finish(ctx context.Context, task *entity.Task) error
+	Get(ctx context.Context, id int) (*entity.Task, error)
+	GetUnfinished(ctx context.Context) ([]entity.Task, error)
+	GetFinishedBefore(ctx context.Context, t time.Time) ([]entity.Task, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// Handler runs the tasks of one kind.
+type Handler interface {
+	// Run does the task and returns its result. A task producing a file
+	// writes it to output and sets task.Output to the download name.
+	Run(ctx context.Context, task *entity.Task, output string) (any, error)
+	// Abort cleans up after a task a previous process left running.
+	Abort(ctx context.Context, task *entity.Task)
+}
+
+// Service runs long requests in the background. The request body is spooled
+// to dir, the answer is the task to poll, its output file is kept in dir
+// until resultTTL passes.
+type Service struct {
+	repo      Repo
+	dir       string
+	resultTTL time.Duration
+
+	workers  int
+	queue    chan int
+	handlers map[entity.TaskKind]Handler
+}
+
+func NewService(repo Repo, dir string, resultTTL time.Duration, workers, queueSize int) *Service {
+	return &Service{
+		repo:      repo,
+		dir:       dir,
+		resultTTL: resultTTL,
+		workers:   max(workers, 1),
+		queue:     make(chan int, max(queueSize, 1)),
+		handlers:  make(map[entity.TaskKind]Handler),
+	}
+}
+
+// Handle registers the handler of kind, it has to be called before Run.
+func (s *Service) Handle(kind entity.TaskKind, h Handler) {
+	s.handlers[kind] = h
+}
+
+// Spool saves the body to a file of the tasks directory and returns its path.
+func (s *Service) Spool(body io.Reader) (string, error) {
+	const op = "tasks_service.Spool"
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := os.CreateTemp(s.dir, "input-*")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f.Name(), nil
+}
+
+// Enqueue saves a task of kind and queues it. input is the spooled body, it
+// is removed when the task is finished or could not be queued.
+func (s *Service) Enqueue(ctx context.Context, kind entity.TaskKind, params any, input string) (*entity.Task, error) {
+	const op = "tasks_service.Enqueue"
+
+	task, err := s.enqueue(ctx, kind, params, input)
+	if err != nil {
+		s.removeFile(ctx, input)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Service) enqueue(ctx context.Context, kind entity.TaskKind, params any, input string) (*entity.Task, error) {
+	if len(s.queue) == cap(s.queue) {
+		return nil, failure.NewUnavailableError(queueFullMsg)
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(time.UTC)
+
+	task := &entity.Task{
+		Kind:     kind,
+		Status:   entity.JobQueued,
+		Params:   data,
+		Input:    input,
+		CreateAt: now,
+		UpdateAt: now,
+	}
+
+	if err := s.repo.Save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.queue <- task.Id:
+	default:
+		if err := s.repo.UpdateStatus(ctx, task.Id, entity.JobFailed, queueFullMsg); err != nil {
+			return nil, err
+		}
+		return nil, failure.NewUnavailableError(queueFullMsg)
+	}
+
+	return task, nil
+}
+
+func (s *Service) Get(ctx context.Context, id int) (*entity.Task, error) {
+	const op = "tasks_service.Get"
+
+	task, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// Output opens the file produced by a finished task.
+func (s *Service) Output(ctx context.Context, id int) (*entity.Task, *os.File, error) {
+	const op = "tasks_service.Output"
+
+	task, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if task.Status != entity.JobDone || task.Output == "" {
+		return nil, nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("task has no output"))
+	}
+
+	f, err := os.Open(s.outputPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("task output expired"))
+		}
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, f, nil
+}
+
+// Run aborts the tasks a previous process left running, requeues the queued
+// ones and drains the queue with the worker pool until ctx is done. Expired
+// outputs are removed every hour.
+func (s *Service) Run(ctx context.Context) {
+	l := contextx.GetLoggerOrDefault(ctx)
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		l.ErrorContext(ctx, "create tasks dir", logx.Error(err))
+	}
+
+	unfinished, err := s.repo.GetUnfinished(ctx)
+	if err != nil {
+		l.ErrorContext(ctx, "get unfinished tasks", logx.Error(err))
+	}
+
+	var queued []int
+	for i := range unfinished {
+		task := &unfinished[i]
+		if task.Status == entity.JobQueued {
+			queued = append(queued, task.Id)
+			continue
+		}
+		s.abort(ctx, task)
+	}
+
+	wg := new(sync.WaitGroup)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, id := range queued {
+			select {
+			case s.queue <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(cleanupPeriod)
+		defer ticker.Stop()
+		for {
+			s.cleanup(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case id := <-s.queue:
+					s.process(ctx, id)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Service) abort(ctx context.Context, task *entity.Task) {
+	l := contextx.GetLoggerOrDefault(ctx).With(slog.Int("task_id", task.Id))
+
+	if h, ok := s.handlers[task.Kind]; ok {
+		h.Abort(ctx, task)
+	}
+
+	s.removeFile(ctx, task.Input)
+	s.removeFile(ctx, s.outputPath(task.Id))
+
+	if err := s.repo.UpdateStatus(ctx, task.Id, entity.JobFailed, interruptedMsg); err != nil {
+		l.ErrorContext(ctx, "update task status", logx.Error(err))
+	}
+}
+
+func (s *Service) process(ctx context.Context, id int) {
+	l := contextx.GetLoggerOrDefault(ctx).With(slog.Int("task_id", id))
+
+	task, err := s.repo.Get(ctx, id)
+	if err != nil {
+		l.ErrorContext(ctx, "get task", logx.Error(err))
+		return
+	}
+	defer s.removeFile(ctx, task.Input)
+
+	if err := s.run(ctx, task); err != nil {
+		l.ErrorContext(ctx, "task failed", slog.String("kind", string(task.Kind)), logx.Error(err))
+		task.Status = entity.JobFailed
+		task.Result = nil
+		task.Output = ""
+		task.Error = err.Error()
+		s.removeFile(ctx, s.outputPath(id))
+	}
+
+	task.UpdateAt = time.Now().In(time.UTC)
+	if err := s.repo.Finish(ctx, task); err != nil {
+		l.ErrorContext(ctx, "finish task", logx.Error(err))
+	}
+}
+
+func (s *Service) run(ctx context.Context, task *entity.Task) error {
+	const op = "tasks_service.run"
+
+	h, ok := s.handlers[task.Kind]
+	if !ok {
+		return fmt.Errorf("%s: unknown task kind %q", op, task.Kind)
+	}
+
+	if err := s.repo.UpdateStatus(ctx, task.Id, entity.JobRunning, ""); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := h.Run(ctx, task, s.outputPath(task.Id))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if task.Result, err = json.Marshal(result); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	task.Status = entity.JobDone
+
+	return nil
+}
+
+// cleanup removes the tasks finished longer than resultTTL ago with their
+// outputs.
+func (s *Service) cleanup(ctx context.Context) {
+	l := contextx.GetLoggerOrDefault(ctx)
+
+	expired, err := s.repo.GetFinishedBefore(ctx, time.Now().In(time.UTC).Add(-s.resultTTL))
+	if err != nil {
+		l.ErrorContext(ctx, "get expired tasks", logx.Error(err))
+		return
+	}
+
+	for _, task := range expired {
+		s.removeFile(ctx, s.outputPath(task.Id))
+		if err := s.repo.Delete(ctx, task.Id); err != nil {
+			l.ErrorContext(ctx, "delete expired task", slog.Int("task_id", task.Id), logx.Error(err))
+		}
+	}
+}
+
+func (s *Service) outputPath(id int) string {
+	return filepath.Join(s.dir, "output-"+strconv.Itoa(id))
+}
+
+func (s *Service) removeFile(ctx context.Context, path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "remove task file", slog.String("path", path), logx.Error(err))
+	}
+}
@@ -0,0 +1,303 @@
+package towers
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"time"
+)
+
+type Repo interface {
+	SaveTower(ctx context.Context, tower *entity.Tower) error
+	UpdateTower(ctx context.Context, tower *entity.Tower) error
+	GetTower(ctx context.Context, id int) (*entity.Tower, error)
+	GetTowerByNumber(ctx context.Context, lapId string, number int) (*entity.Tower, error)
+	GetTowersByLap(ctx context.Context, lapId string) ([]entity.Tower, error)
+	DeleteTower(ctx context.Context, id int) error
+	SaveSpan(ctx context.Context, span *entity.Span) error
+	GetSpansByLap(ctx context.Context, lapId string) ([]entity.Span, error)
+	DeleteSpan(ctx context.Context, id int) error
+	LinkImage(ctx context.Context, link entity.ImageTower) error
+}
+
+type LapValidator interface {
+	Validate(ctx context.Context, lapId string) error
+}
+
+type GroupsRepo interface {
+	GetLapId(ctx context.Context, groupId int) (string, error)
+}
+
+type Service struct {
+	repo   Repo
+	laps   LapValidator
+	groups GroupsRepo
+}
+
+func NewService(repo Repo, laps LapValidator, groups GroupsRepo) *Service {
+	return &Service{
+		repo:   repo,
+		laps:   laps,
+		groups: groups,
+	}
+}
+
+func (s *Service) CreateTower(ctx context.Context, tower *entity.Tower) error {
+	const op = "towers_service.CreateTower"
+
+	if err := s.laps.Validate(ctx, tower.LapId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.checkNumber(ctx, tower); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tower.CreateAt = time.Now().In(time.UTC)
+
+	if err := s.repo.SaveTower(ctx, tower); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) UpdateTower(ctx context.Context, tower *entity.Tower) error {
+	const op = "towers_service.UpdateTower"
+
+	current, err := s.repo.GetTower(ctx, tower.Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// towers can't be moved to another lap
+	tower.LapId = current.LapId
+	tower.CreateAt = current.CreateAt
+
+	if err := s.checkNumber(ctx, tower); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.UpdateTower(ctx, tower); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) checkNumber(ctx context.Context, tower *entity.Tower) error {
+	if tower.Number <= 0 {
+		return failure.NewInvalidRequestError("tower number must be positive")
+	}
+
+	existing, err := s.repo.GetTowerByNumber(ctx, tower.LapId, tower.Number)
+	if err != nil {
+		if failure.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+	if existing.Id != tower.Id {
+		return failure.NewInvalidRequestError(fmt.Sprintf("tower %d already exists", tower.Number))
+	}
+
+	return nil
+}
+
+func (s *Service) GetTowers(ctx context.Context, lapId string) ([]entity.Tower, error) {
+	const op = "towers_service.GetTowers"
+	towers, err := s.repo.GetTowersByLap(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return towers, nil
+}
+
+func (s *Service) DeleteTower(ctx context.Context, id int) error {
+	const op = "towers_service.DeleteTower"
+	if err := s.repo.DeleteTower(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Service) CreateSpan(ctx context.Context, span *entity.Span) error {
+	const op = "towers_service.CreateSpan"
+
+	if span.FromTowerId == span.ToTowerId {
+		return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("span must connect two different towers"))
+	}
+	if span.Length < 0 {
+		return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("span length must not be negative"))
+	}
+
+	for _, id := range []int{span.FromTowerId, span.ToTowerId} {
+		tower, err := s.repo.GetTower(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if tower.LapId != span.LapId {
+			return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError(fmt.Sprintf("tower %d belongs to another lap", id)))
+		}
+	}
+
+	if err := s.repo.SaveSpan(ctx, span); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetSpans(ctx context.Context, lapId string) ([]entity.Span, error) {
+	const op = "towers_service.GetSpans"
+	spans, err := s.repo.GetSpansByLap(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return spans, nil
+}
+
+func (s *Service) DeleteSpan(ctx context.Context, id int) error {
+	const op = "towers_service.DeleteSpan"
+	if err := s.repo.DeleteSpan(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CheckGroupTower checks that the tower belongs to the lap of the group, so
+// images of the group can be linked to it.
+func (s *Service) CheckGroupTower(ctx context.Context, groupId, towerId int) error {
+	const op = "towers_service.CheckGroupTower"
+
+	lapId, err := s.groups.GetLapId(ctx, groupId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.CheckLapTower(ctx, lapId, towerId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) CheckLapTower(ctx context.Context, lapId string, towerId int) error {
+	const op = "towers_service.CheckLapTower"
+
+	tower, err := s.repo.GetTower(ctx, towerId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tower.LapId != lapId {
+		return fmt.Errorf("%s: %w", op, failure.NewInvalidRequestError("tower belongs to another lap"))
+	}
+
+	return nil
+}
+
+// FindByNumber returns the tower of the lap with the given number or nil if
+// there is no such tower.
+func (s *Service) FindByNumber(ctx context.Context, lapId string, number int) (*entity.Tower, error) {
+	const op = "towers_service.FindByNumber"
+
+	tower, err := s.repo.GetTowerByNumber(ctx, lapId, number)
+	if err != nil {
+		if failure.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tower, nil
+}
+
+// maxTowerDistance is the max distance in meters from a photo to a tower for
+// the photo to be linked to the tower by its GPS position.
+const maxTowerDistance = 150
+
+// FindNearest returns the tower of the lap closest to the point if it is
+// within maxTowerDistance, nil otherwise.
+func (s *Service) FindNearest(ctx context.Context, lapId string, lat, lon float64) (*entity.Tower, error) {
+	const op = "towers_service.FindNearest"
+
+	towers, err := s.repo.GetTowersByLap(ctx, lapId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var nearest *entity.Tower
+	minDistance := float64(maxTowerDistance)
+
+	for i, tower := range towers {
+		if tower.Latitude == nil || tower.Longitude == nil {
+			continue
+		}
+		if d := distance(lat, lon, *tower.Latitude, *tower.Longitude); d <= minDistance {
+			nearest = &towers[i]
+			minDistance = d
+		}
+	}
+
+	return nearest, nil
+}
+
+// LinkNearest links the image to the tower of the group lap nearest to the
+// point, the image is left unlinked if there is no tower close enough.
+func (s *Service) LinkNearest(ctx context.Context, groupId int, imageUid uuid.UUID, lat, lon float64) error {
+	const op = "towers_service.LinkNearest"
+
+	lapId, err := s.groups.GetLapId(ctx, groupId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tower, err := s.FindNearest(ctx, lapId, lat, lon)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tower == nil {
+		return nil
+	}
+
+	if err := s.LinkImage(ctx, groupId, imageUid, tower.Id, entity.TowerLinkInferred); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// distance returns the great-circle distance between two points in meters.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000
+
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func (s *Service) LinkImage(ctx context.Context, groupId int, imageUid uuid.UUID, towerId int, source entity.TowerLinkSource) error {
+	const op = "towers_service.LinkImage"
+
+	link := entity.ImageTower{
+		GroupId:  groupId,
+		ImageUid: imageUid,
+		TowerId:  towerId,
+		Source:   source,
+	}
+
+	if err := s.repo.LinkImage(ctx, link); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
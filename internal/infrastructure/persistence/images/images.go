@@ -1,115 +1,260 @@
 package images
 
 import (
+	"FairLAP/internal/domain/entity"
 	"FairLAP/pkg/failure"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"golang.org/x/image/draw"
 	"image"
 	"image/jpeg"
 	"io"
-	"log"
-	"os"
-	"path/filepath"
+	"net/http"
+	"slices"
 	"strconv"
+	"time"
 )
 
-type Images struct {
-	path string
+// ErrNoURL is returned by Images.URL if the storage can't give direct links.
+var ErrNoURL = errors.New("storage doesn't support presigned urls")
+
+// Storage keeps files by slash separated keys. Get returns a not found
+// failure for a missing key, Delete and DeleteDir ignore missing keys.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// DeleteDir removes every key starting with dir + "/".
+	DeleteDir(ctx context.Context, dir string) error
 }
 
-func New(path string) *Images {
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		if !os.IsExist(err) {
-			log.Fatal(err)
-		}
-	}
+// URLSigner is implemented by storages able to give temporary download links.
+type URLSigner interface {
+	URL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
 
+// Images stores files of an image under <group_id>/:
+//
+//	<uuid>_original           the uploaded file byte for byte
+//	<uuid>.jpeg               the normalized JPEG used for detection and viewing
+//	<uuid>_thumb_<size>.jpeg  thumbnails with the longest side of size pixels
+//	<uuid>_mask.png           the mask
+type Images struct {
+	storage    Storage
+	urlTTL     time.Duration
+	thumbSizes []int
+}
+
+// New creates images kept in storage. Thumbnails of thumbSizes are made on
+// upload, the first size is the default one.
+func New(storage Storage, urlTTL time.Duration, thumbSizes []int) *Images {
 	return &Images{
-		path: path,
+		storage:    storage,
+		urlTTL:     urlTTL,
+		thumbSizes: thumbSizes,
 	}
 }
 
-func (images *Images) Save(groupId int, img image.Image) (uuid.UUID, error) {
-	path := filepath.Join(images.path, strconv.Itoa(groupId))
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		if !os.IsExist(err) {
-			return uuid.Nil, fmt.Errorf("make dir failed: %w", err)
-		}
+// Save stores the original file, the normalized JPEG and the thumbnails of
+// an upload. Files written before a failure are removed.
+func (images *Images) Save(ctx context.Context, groupId int, file entity.ImageFile) (uuid.UUID, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, file.Image, &jpeg.Options{Quality: 90}); err != nil {
+		return uuid.Nil, err
 	}
 
 	uid := uuid.New()
 
-	path = filepath.Join(path, uid.String()+".jpeg")
-
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
-	if err != nil {
-		return uuid.Nil, fmt.Errorf("open file failed: %w", err)
-	}
-	defer f.Close()
-
-	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 90}); err != nil {
+	if err := images.save(ctx, groupId, uid, file, buf.Bytes()); err != nil {
+		images.Delete(ctx, groupId, uid)
 		return uuid.Nil, err
 	}
 
 	return uid, nil
 }
 
-func (images *Images) SaveMask(groupId int, uid uuid.UUID, mask io.Reader) error {
-	path := filepath.Join(images.path, strconv.Itoa(groupId))
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		if !os.IsExist(err) {
-			return fmt.Errorf("make dir failed: %w", err)
+func (images *Images) save(ctx context.Context, groupId int, uid uuid.UUID, file entity.ImageFile, normalized []byte) error {
+	if len(file.Original) > 0 {
+		if err := images.storage.Put(ctx, originalKey(groupId, uid), file.Original, ContentType(file.Original)); err != nil {
+			return fmt.Errorf("save original failed: %w", err)
 		}
 	}
-	path = filepath.Join(path, fmt.Sprintf("%s_mask.png", uid))
 
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err := images.storage.Put(ctx, imageKey(groupId, uid), normalized, "image/jpeg"); err != nil {
+		return fmt.Errorf("save image failed: %w", err)
+	}
+
+	for _, size := range images.thumbSizes {
+		if _, err := images.saveThumb(ctx, groupId, uid, file.Image, size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (images *Images) SaveMask(ctx context.Context, groupId int, uid uuid.UUID, mask io.Reader) error {
+	data, err := io.ReadAll(mask)
 	if err != nil {
-		return fmt.Errorf("open file failed: %w", err)
+		return fmt.Errorf("read mask failed: %w", err)
 	}
-	defer f.Close()
 
-	if _, err := io.Copy(f, mask); err != nil {
-		return fmt.Errorf("write file failed: %w", err)
+	if err := images.storage.Put(ctx, maskKey(groupId, uid), data, "image/png"); err != nil {
+		return fmt.Errorf("save mask failed: %w", err)
 	}
 
 	return nil
 }
 
-func (images *Images) Open(groupId int, uid uuid.UUID) (*os.File, error) {
-	path := filepath.Join(images.path, strconv.Itoa(groupId), uid.String()+".jpeg")
+func (images *Images) Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error) {
+	return images.storage.Get(ctx, imageKey(groupId, uid))
+}
+
+// OpenOriginal opens the uploaded file. Images saved before originals were
+// kept have none, the normalized JPEG is returned for them.
+func (images *Images) OpenOriginal(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error) {
+	f, err := images.storage.Get(ctx, originalKey(groupId, uid))
+	if failure.IsNotFoundError(err) {
+		return images.Open(ctx, groupId, uid)
+	}
+	return f, err
+}
+
+// OpenThumb opens the thumbnail of size, zero means the default size. A
+// missing thumbnail is made from the normalized JPEG and saved.
+func (images *Images) OpenThumb(ctx context.Context, groupId int, uid uuid.UUID, size int) (io.ReadCloser, error) {
+	if len(images.thumbSizes) == 0 {
+		return nil, failure.NewInvalidRequestError("thumbnails are disabled")
+	}
+	if size == 0 {
+		size = images.thumbSizes[0]
+	}
+	if !slices.Contains(images.thumbSizes, size) {
+		return nil, failure.NewInvalidRequestError(fmt.Sprintf("unknown thumbnail size %d, available: %v", size, images.thumbSizes))
+	}
+
+	f, err := images.storage.Get(ctx, thumbKey(groupId, uid, size))
+	if !failure.IsNotFoundError(err) {
+		return f, err
+	}
 
-	f, err := os.OpenFile(path, os.O_RDONLY, os.ModePerm)
+	src, err := images.Open(ctx, groupId, uid)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, failure.NewNotFoundError(err.Error())
-		}
-		return nil, fmt.Errorf("open file failed: %w", err)
+		return nil, err
+	}
+	defer src.Close()
+
+	img, err := jpeg.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("decode image failed: %w", err)
 	}
 
-	return f, nil
+	data, err := images.saveThumb(ctx, groupId, uid, img, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
 }
 
-func (images *Images) OpenMask(groupId int, uid uuid.UUID) (*os.File, error) {
-	path := filepath.Join(images.path, strconv.Itoa(groupId), fmt.Sprintf("%s_mask.png", uid))
+func (images *Images) OpenMask(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error) {
+	return images.storage.Get(ctx, maskKey(groupId, uid))
+}
 
-	f, err := os.OpenFile(path, os.O_RDONLY, os.ModePerm)
+// URL returns a temporary download link of the image or ErrNoURL.
+func (images *Images) URL(ctx context.Context, groupId int, uid uuid.UUID) (string, time.Time, error) {
+	signer, ok := images.storage.(URLSigner)
+	if !ok {
+		return "", time.Time{}, ErrNoURL
+	}
+
+	expireAt := time.Now().Add(images.urlTTL)
+
+	url, err := signer.URL(ctx, imageKey(groupId, uid), images.urlTTL)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, failure.NewNotFoundError(err.Error())
-		}
-		return nil, fmt.Errorf("open file failed: %w", err)
+		return "", time.Time{}, err
 	}
 
-	return f, nil
+	return url, expireAt, nil
 }
 
-func (images *Images) DeleteGroup(groupId int) error {
-	path := filepath.Join(images.path, strconv.Itoa(groupId))
-	if err := os.RemoveAll(path); err != nil {
-		if !os.IsNotExist(err) {
+// Delete removes the image with its original, thumbnails and mask, missing
+// files are ignored.
+func (images *Images) Delete(ctx context.Context, groupId int, uid uuid.UUID) error {
+	keys := []string{originalKey(groupId, uid), imageKey(groupId, uid), maskKey(groupId, uid)}
+	for _, size := range images.thumbSizes {
+		keys = append(keys, thumbKey(groupId, uid, size))
+	}
+
+	for _, key := range keys {
+		if err := images.storage.Delete(ctx, key); err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
+
+func (images *Images) DeleteGroup(ctx context.Context, groupId int) error {
+	return images.storage.DeleteDir(ctx, strconv.Itoa(groupId))
+}
+
+func (images *Images) saveThumb(ctx context.Context, groupId int, uid uuid.UUID, img image.Image, size int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail(img, size), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+
+	if err := images.storage.Put(ctx, thumbKey(groupId, uid, size), buf.Bytes(), "image/jpeg"); err != nil {
+		return nil, fmt.Errorf("save thumbnail failed: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// thumbnail scales img down so that its longest side is size pixels,
+// smaller images are kept as is.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// ContentType detects the type of an image file by its first bytes.
+func ContentType(data []byte) string {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+	return http.DetectContentType(data)
+}
+
+func imageKey(groupId int, uid uuid.UUID) string {
+	return fmt.Sprintf("%d/%s.jpeg", groupId, uid)
+}
+
+func originalKey(groupId int, uid uuid.UUID) string {
+	return fmt.Sprintf("%d/%s_original", groupId, uid)
+}
+
+func thumbKey(groupId int, uid uuid.UUID, size int) string {
+	return fmt.Sprintf("%d/%s_thumb_%d.jpeg", groupId, uid, size)
+}
+
+func maskKey(groupId int, uid uuid.UUID) string {
+	return fmt.Sprintf("%d/%s_mask.png", groupId, uid)
+}
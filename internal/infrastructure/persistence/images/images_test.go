@@ -0,0 +1,180 @@
+package images
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	testImages(t, New(NewMemory(), time.Minute, []int{4}))
+}
+
+func TestLocal(t *testing.T) {
+	testImages(t, New(NewLocal(t.TempDir()), time.Minute, []int{4}))
+}
+
+func TestS3(t *testing.T) {
+	backend := s3mem.New()
+	require.NoError(t, backend.CreateBucket("images"))
+
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	storage, err := NewS3(&config.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "images",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+
+	testImages(t, New(storage, time.Minute, []int{4}))
+}
+
+func TestURL(t *testing.T) {
+	rq := require.New(t)
+
+	_, _, err := New(NewMemory(), time.Minute, []int{4}).URL(context.Background(), 1, uuid.New())
+	rq.ErrorIs(err, ErrNoURL)
+}
+
+func testImages(t *testing.T, images *Images) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	opened := func(r io.ReadCloser, err error) io.ReadCloser {
+		rq.NoError(err)
+		t.Cleanup(func() { r.Close() })
+		return r
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	original := encodePng(t, img)
+
+	uid, err := images.Save(ctx, 1, entity.ImageFile{Image: img, Original: original})
+	rq.NoError(err)
+
+	decoded, err := jpeg.Decode(opened(images.Open(ctx, 1, uid)))
+	rq.NoError(err)
+	rq.Equal(img.Bounds(), decoded.Bounds())
+
+	rq.Equal(original, read(t, opened(images.OpenOriginal(ctx, 1, uid))))
+
+	thumb, err := jpeg.Decode(opened(images.OpenThumb(ctx, 1, uid, 0)))
+	rq.NoError(err)
+	rq.Equal(image.Rect(0, 0, 4, 2), thumb.Bounds())
+
+	_, err = images.OpenThumb(ctx, 1, uid, 5)
+	rq.True(failure.IsInvalidRequestError(err), err)
+
+	rq.NoError(images.SaveMask(ctx, 1, uid, strings.NewReader("mask")))
+	rq.Equal("mask", string(read(t, opened(images.OpenMask(ctx, 1, uid)))))
+
+	_, err = images.Open(ctx, 1, uuid.New())
+	rq.True(failure.IsNotFoundError(err), err)
+
+	if _, _, err := images.URL(ctx, 1, uid); err == nil {
+		t.Run("URL", func(t *testing.T) {
+			url, expireAt, err := images.URL(ctx, 1, uid)
+			require.NoError(t, err)
+			require.True(t, expireAt.After(time.Now()))
+
+			resp, err := http.Get(url)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, read(t, opened(images.Open(ctx, 1, uid))), read(t, resp.Body))
+		})
+	}
+
+	rq.NoError(images.Delete(ctx, 1, uid))
+	rq.NoError(images.Delete(ctx, 1, uid))
+
+	_, err = images.OpenMask(ctx, 1, uid)
+	rq.True(failure.IsNotFoundError(err), err)
+	_, err = images.OpenOriginal(ctx, 1, uid)
+	rq.True(failure.IsNotFoundError(err), err)
+
+	// group 10 shares the prefix of group 1 and must survive its deletion
+	first, err := images.Save(ctx, 1, entity.ImageFile{Image: img})
+	rq.NoError(err)
+	other, err := images.Save(ctx, 10, entity.ImageFile{Image: img})
+	rq.NoError(err)
+
+	rq.NoError(images.DeleteGroup(ctx, 1))
+	rq.NoError(images.DeleteGroup(ctx, 2))
+
+	_, err = images.Open(ctx, 1, first)
+	rq.True(failure.IsNotFoundError(err), err)
+	opened(images.Open(ctx, 10, other))
+
+	// without an original the normalized JPEG is served
+	rq.Equal(read(t, opened(images.Open(ctx, 10, other))), read(t, opened(images.OpenOriginal(ctx, 10, other))))
+}
+
+func TestThumbnail(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	storage := NewMemory()
+	images := New(storage, time.Minute, []int{4})
+
+	uid, err := images.Save(ctx, 1, entity.ImageFile{Image: image.NewRGBA(image.Rect(0, 0, 3, 12))})
+	rq.NoError(err)
+	rq.NoError(storage.Delete(ctx, thumbKey(1, uid, 4)))
+
+	// a missing thumbnail is made again from the image
+	f, err := images.OpenThumb(ctx, 1, uid, 4)
+	rq.NoError(err)
+	defer f.Close()
+
+	thumb, err := jpeg.Decode(f)
+	rq.NoError(err)
+	rq.Equal(image.Rect(0, 0, 1, 4), thumb.Bounds())
+
+	saved, err := storage.Get(ctx, thumbKey(1, uid, 4))
+	rq.NoError(err)
+	saved.Close()
+
+	// small images aren't upscaled
+	rq.Equal(image.Rect(0, 0, 2, 2), thumbnail(image.NewRGBA(image.Rect(0, 0, 2, 2)), 4).Bounds())
+}
+
+func TestContentType(t *testing.T) {
+	rq := require.New(t)
+
+	rq.Equal("image/png", ContentType(encodePng(t, image.NewRGBA(image.Rect(0, 0, 1, 1)))))
+	rq.Equal("image/tiff", ContentType([]byte("II*\x00rest")))
+	rq.Equal("image/tiff", ContentType([]byte("MM\x00*rest")))
+}
+
+func encodePng(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func read(t *testing.T, r io.Reader) []byte {
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, r)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
@@ -0,0 +1,75 @@
+package images
+
+import (
+	"FairLAP/pkg/failure"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Local keeps files in a directory of the local disk.
+type Local struct {
+	path string
+}
+
+func NewLocal(path string) *Local {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		if !os.IsExist(err) {
+			log.Fatal(err)
+		}
+	}
+
+	return &Local{
+		path: path,
+	}
+}
+
+func (l *Local) Put(_ context.Context, key string, data []byte, _ string) error {
+	path := l.file(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		if !os.IsExist(err) {
+			return fmt.Errorf("make dir failed: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write file failed: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(l.file(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, failure.NewNotFoundError(err.Error())
+		}
+		return nil, fmt.Errorf("open file failed: %w", err)
+	}
+
+	return f, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	if err := os.Remove(l.file(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) DeleteDir(_ context.Context, dir string) error {
+	if err := os.RemoveAll(l.file(dir)); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Local) file(key string) string {
+	return filepath.Join(l.path, filepath.FromSlash(key))
+}
@@ -0,0 +1,62 @@
+package images
+
+import (
+	"FairLAP/pkg/failure"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Memory keeps files in memory, it is meant for tests.
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		files: make(map[string][]byte),
+	}
+}
+
+func (m *Memory) Put(_ context.Context, key string, data []byte, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[key] = bytes.Clone(data)
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.files[key]
+	if !ok {
+		return nil, failure.NewNotFoundError("file " + key + " not found")
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, key)
+	return nil
+}
+
+func (m *Memory) DeleteDir(_ context.Context, dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.files {
+		if strings.HasPrefix(key, dir+"/") {
+			delete(m.files, key)
+		}
+	}
+	return nil
+}
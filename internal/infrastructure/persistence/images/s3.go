@@ -0,0 +1,140 @@
+package images
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/pkg/failure"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"time"
+)
+
+// S3 keeps files in a bucket of an S3 compatible object storage.
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3 connects to the storage and checks that the bucket exists.
+func NewS3(cfg *config.S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("check bucket failed: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %q doesn't exist", cfg.Bucket)
+	}
+
+	return &S3{
+		client: client,
+		bucket: cfg.Bucket,
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if _, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	}); err != nil {
+		return fmt.Errorf("put object failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get object failed: %w", err)
+	}
+
+	// the object is fetched lazily, stat reports a missing key before reading
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+			return nil, failure.NewNotFoundError("file " + key + " not found")
+		}
+		return nil, fmt.Errorf("get object failed: %w", err)
+	}
+
+	return obj, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+			return nil
+		}
+		return fmt.Errorf("remove object failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *S3) DeleteDir(ctx context.Context, dir string) error {
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    dir + "/",
+		Recursive: true,
+	})
+
+	var listErr error
+	keys := make(chan minio.ObjectInfo)
+	listed := make(chan struct{})
+
+	go func() {
+		defer close(listed)
+		defer close(keys)
+		for obj := range objects {
+			if obj.Err != nil {
+				listErr = obj.Err
+				return
+			}
+			select {
+			case keys <- obj:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var removeErr error
+	for res := range s.client.RemoveObjects(ctx, s.bucket, keys, minio.RemoveObjectsOptions{}) {
+		if res.Err != nil && removeErr == nil {
+			removeErr = fmt.Errorf("remove object %s failed: %w", res.ObjectName, res.Err)
+		}
+	}
+	<-listed
+
+	if removeErr != nil {
+		return removeErr
+	}
+	if listErr != nil {
+		return fmt.Errorf("list objects failed: %w", listErr)
+	}
+
+	return nil
+}
+
+// URL returns a presigned GET link of the key.
+func (s *S3) URL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, nil)
+	if err != nil {
+		return "", fmt.Errorf("presign object failed: %w", err)
+	}
+
+	return u.String(), nil
+}
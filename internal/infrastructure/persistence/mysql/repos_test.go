@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/infrastructure/persistence/repotest"
+	"FairLAP/internal/infrastructure/persistence/sqlrepo"
+	"FairLAP/migrations"
+	"FairLAP/pkg/migrate"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepos needs a MySQL server, it reads the connection from the MYSQL_*
+// variables and migrates TEST_MYSQL_SCHEMA, the test is skipped without it.
+func TestRepos(t *testing.T) {
+	schema := os.Getenv("TEST_MYSQL_SCHEMA")
+	if schema == "" {
+		t.Skip("TEST_MYSQL_SCHEMA is not set")
+	}
+
+	rq := require.New(t)
+
+	var cfg config.MySQLConfig
+	rq.NoError(cleanenv.ReadEnv(&cfg))
+	cfg.Schema = schema
+
+	db, err := Connect(&cfg)
+	rq.NoError(err)
+	t.Cleanup(func() { db.Close() })
+
+	fsys, err := migrations.FS(migrations.MySQL)
+	rq.NoError(err)
+	migrator, err := migrate.New(db, fsys)
+	rq.NoError(err)
+	_, err = migrator.Up(context.Background())
+	rq.NoError(err)
+
+	repotest.Run(t, repotest.Repos{
+		Laps:        sqlrepo.NewLapsRepo(db),
+		Groups:      sqlrepo.NewGroupsRepo(db),
+		Detections:  sqlrepo.NewDetectionsRepo(db),
+		LapConfig:   sqlrepo.NewLapConfigRepo(db),
+		Evaluations: sqlrepo.NewEvaluationsRepo(db),
+		Tasks:       sqlrepo.NewTasksRepo(db),
+		History:     sqlrepo.NewAnnotationHistoryRepo(db),
+		UnitOfWork:  sqlrepo.NewUnitOfWork(db),
+	})
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"FairLAP/internal/config"
+	"context"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func Connect(cfg *config.PostgresConfig) (*sqlx.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dataSource := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     cfg.Database,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+	}
+
+	db, err := sqlx.ConnectContext(ctx, "pgx", dataSource.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
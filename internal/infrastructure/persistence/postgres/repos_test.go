@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/infrastructure/persistence/repotest"
+	"FairLAP/internal/infrastructure/persistence/sqlrepo"
+	"FairLAP/migrations"
+	"FairLAP/pkg/migrate"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepos needs a PostGIS server, it reads the connection from the
+// POSTGRES_* variables and migrates TEST_POSTGRES_DATABASE, the test is
+// skipped without it.
+func TestRepos(t *testing.T) {
+	database := os.Getenv("TEST_POSTGRES_DATABASE")
+	if database == "" {
+		t.Skip("TEST_POSTGRES_DATABASE is not set")
+	}
+
+	rq := require.New(t)
+
+	var cfg config.PostgresConfig
+	rq.NoError(cleanenv.ReadEnv(&cfg))
+	cfg.Database = database
+
+	db, err := Connect(&cfg)
+	rq.NoError(err)
+	t.Cleanup(func() { db.Close() })
+
+	fsys, err := migrations.FS(migrations.Postgres)
+	rq.NoError(err)
+	migrator, err := migrate.New(db, fsys)
+	rq.NoError(err)
+	_, err = migrator.Up(context.Background())
+	rq.NoError(err)
+
+	repotest.Run(t, repotest.Repos{
+		Laps:        sqlrepo.NewLapsRepo(db),
+		Groups:      sqlrepo.NewGroupsRepo(db),
+		Detections:  sqlrepo.NewDetectionsRepo(db),
+		LapConfig:   sqlrepo.NewLapConfigRepo(db),
+		Evaluations: sqlrepo.NewEvaluationsRepo(db),
+		Tasks:       sqlrepo.NewTasksRepo(db),
+		History:     sqlrepo.NewAnnotationHistoryRepo(db),
+		UnitOfWork:  sqlrepo.NewUnitOfWork(db),
+	})
+}
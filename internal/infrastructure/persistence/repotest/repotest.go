@@ -0,0 +1,620 @@
+// Package repotest is the contract test suite of the repositories. Every
+// storage backend runs it against its implementation, so the services behave
+// the same whatever database is configured.
+package repotest
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/evaluation"
+	"FairLAP/pkg/failure"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type LapsRepo interface {
+	Save(ctx context.Context, lap *entity.Lap) error
+	Delete(ctx context.Context, id string) error
+}
+
+type GroupsRepo interface {
+	Save(ctx context.Context, group *entity.Group) error
+	GetByLap(ctx context.Context, lapId string) ([]entity.Group, error)
+	GetLaps(ctx context.Context) ([]aggregate.LapLastDetect, error)
+	GetLapId(ctx context.Context, groupId int) (string, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type DetectionsRepo interface {
+	SaveBatch(ctx context.Context, detections []entity.Detection) error
+	SaveRects(ctx context.Context, rects []entity.RectDetection) error
+	DeleteByImage(ctx context.Context, groupId int, imageUid uuid.UUID) error
+	GetByGroup(ctx context.Context, group int) ([]entity.Detection, error)
+	Get(ctx context.Context, id int) (*entity.Detection, error)
+	SetReview(ctx context.Context, id int, review entity.Review) error
+	SetImageReview(ctx context.Context, groupId int, imageUid uuid.UUID, review entity.Review) error
+	SetImageReviewed(ctx context.Context, review entity.ImageReview) error
+	GetReviewedImages(ctx context.Context, groupId int) ([]uuid.UUID, error)
+	Save(ctx context.Context, detection *entity.Detection) error
+	UpdateRect(ctx context.Context, rect entity.RectDetection) error
+	SetSource(ctx context.Context, id int, source entity.DetectionSource) error
+	Delete(ctx context.Context, id int) error
+	GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]aggregate.DetectionRect, error)
+	GetRectsByGroup(ctx context.Context, groupId int) ([]aggregate.DetectionRect, error)
+	GetRect(ctx context.Context, detectionId int) (*entity.RectDetection, string, error)
+	IsExistProblem(ctx context.Context, lapId string) (bool, error)
+}
+
+type LapConfigRepo interface {
+	SaveParameter(ctx context.Context, lapId string, params entity.LapParameter) error
+	UpdateParameter(ctx context.Context, lapId string, param entity.LapParameter) error
+	DeleteParameters(ctx context.Context, lapId string, classes []string) error
+	GetLapParameters(ctx context.Context, lapId string) ([]entity.LapParameter, error)
+}
+
+type EvaluationsRepo interface {
+	Save(ctx context.Context, e *entity.Evaluation) error
+	Get(ctx context.Context, id int) (*entity.Evaluation, error)
+	List(ctx context.Context, model string) ([]entity.Evaluation, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type TasksRepo interface {
+	Save(ctx context.Context, task *entity.Task) error
+	UpdateStatus(ctx context.Context, id int, status entity.JobStatus, errMsg string) error
+	Finish(ctx context.Context, task *entity.Task) error
+	Get(ctx context.Context, id int) (*entity.Task, error)
+	GetUnfinished(ctx context.Context) ([]entity.Task, error)
+	GetFinishedBefore(ctx context.Context, t time.Time) ([]entity.Task, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type AnnotationHistoryRepo interface {
+	Save(ctx context.Context, change *entity.AnnotationChange) error
+	GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]entity.AnnotationChange, error)
+}
+
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Repos struct {
+	Laps        LapsRepo
+	Groups      GroupsRepo
+	Detections  DetectionsRepo
+	LapConfig   LapConfigRepo
+	Evaluations EvaluationsRepo
+	Tasks       TasksRepo
+	History     AnnotationHistoryRepo
+	UnitOfWork  UnitOfWork
+}
+
+// Run runs the suite. Every test works on its own lap and removes it at the
+// end, so the database may be shared with other data.
+func Run(t *testing.T, repos Repos) {
+	t.Run("Groups", func(t *testing.T) { testGroups(t, repos) })
+	t.Run("Detections", func(t *testing.T) { testDetections(t, repos) })
+	t.Run("Review", func(t *testing.T) { testReview(t, repos) })
+	t.Run("Annotations", func(t *testing.T) { testAnnotations(t, repos) })
+	t.Run("UnitOfWork", func(t *testing.T) { testUnitOfWork(t, repos) })
+	t.Run("LapConfig", func(t *testing.T) { testLapConfig(t, repos) })
+	t.Run("Evaluations", func(t *testing.T) { testEvaluations(t, repos) })
+	t.Run("Tasks", func(t *testing.T) { testTasks(t, repos) })
+	t.Run("AnnotationHistory", func(t *testing.T) { testAnnotationHistory(t, repos) })
+}
+
+func testGroups(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+
+	first := newGroup(t, repos, lapId, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	last := newGroup(t, repos, lapId, time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC))
+	rq.Greater(last.Id, first.Id)
+
+	groups, err := repos.Groups.GetByLap(ctx, lapId)
+	rq.NoError(err)
+	rq.Len(groups, 2)
+	rq.Equal(lapId, groups[0].LapId)
+	rq.True(groups[0].CreateAt.Equal(first.CreateAt), groups[0].CreateAt)
+
+	got, err := repos.Groups.GetLapId(ctx, last.Id)
+	rq.NoError(err)
+	rq.Equal(lapId, got)
+
+	_, err = repos.Groups.GetLapId(ctx, -1)
+	rq.True(failure.IsNotFoundError(err), err)
+
+	laps, err := repos.Groups.GetLaps(ctx)
+	rq.NoError(err)
+	i := slices.IndexFunc(laps, func(lap aggregate.LapLastDetect) bool { return lap.LapId == lapId })
+	rq.NotEqual(-1, i)
+	rq.Equal(last.Id, laps[i].LastGroup)
+	rq.True(laps[i].LastDetect.Equal(last.CreateAt), laps[i].LastDetect)
+
+	rq.NoError(repos.Groups.Delete(ctx, first.Id))
+
+	groups, err = repos.Groups.GetByLap(ctx, lapId)
+	rq.NoError(err)
+	rq.Len(groups, 1)
+	rq.Equal(last.Id, groups[0].Id)
+}
+
+func testDetections(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+	group := newGroup(t, repos, lapId, time.Now().UTC().Truncate(time.Second))
+	imageUid := uuid.New()
+
+	detections := []entity.Detection{
+		{GroupId: group.Id, ImageUid: imageUid, Class: "insulator", ConfThreshold: 0.5, Source: entity.SourceModel, ReviewStatus: entity.ReviewPending},
+		{GroupId: group.Id, ImageUid: imageUid, Class: "nest", ConfThreshold: 0.25, Source: entity.SourceModel, ReviewStatus: entity.ReviewPending},
+	}
+	rq.NoError(repos.Detections.SaveBatch(ctx, detections))
+	rq.NotZero(detections[0].Id)
+	rq.Greater(detections[1].Id, detections[0].Id)
+
+	rects := []entity.RectDetection{
+		{DetectionId: detections[0].Id, Width: 640, Height: 480, X0: 1, Y0: 2, X1: 10, Y1: 20, Confidence: 0.75},
+		{DetectionId: detections[1].Id, Width: 640, Height: 480, X0: 3, Y0: 4, X1: 30, Y1: 40, Confidence: 0.5},
+	}
+	rq.NoError(repos.Detections.SaveRects(ctx, rects))
+	rq.NoError(repos.Detections.SaveRects(ctx, nil))
+
+	byImage, err := repos.Detections.GetByImage(ctx, group.Id, imageUid)
+	rq.NoError(err)
+	rq.Len(byImage, 2)
+	rq.Equal(detections[0].Id, byImage[0].Id)
+	rq.Equal(imageUid, byImage[0].ImageUid)
+	rq.Equal("insulator", byImage[0].Class)
+	rq.Equal(float32(0.5), byImage[0].Threshold)
+	rq.Equal(float32(0.75), byImage[0].Confidence)
+	rq.Equal(30, byImage[1].X1)
+
+	byGroup, err := repos.Detections.GetByGroup(ctx, group.Id)
+	rq.NoError(err)
+	rq.Len(byGroup, 2)
+	rq.Equal(imageUid, byGroup[1].ImageUid)
+	rq.Equal(entity.ReviewPending, byGroup[1].ReviewStatus)
+	rq.Nil(byGroup[1].ReviewedAt)
+
+	rectsByGroup, err := repos.Detections.GetRectsByGroup(ctx, group.Id)
+	rq.NoError(err)
+	rq.Equal(byImage, rectsByGroup)
+
+	rect, class, err := repos.Detections.GetRect(ctx, detections[1].Id)
+	rq.NoError(err)
+	rq.Equal("nest", class)
+	rq.Equal(detections[1].Id, rect.DetectionId)
+	rq.Equal(40, rect.Y1)
+
+	problem, err := repos.Detections.IsExistProblem(ctx, lapId)
+	rq.NoError(err)
+	rq.False(problem)
+
+	rq.NoError(repos.Detections.DeleteByImage(ctx, group.Id, imageUid))
+
+	byImage, err = repos.Detections.GetByImage(ctx, group.Id, imageUid)
+	rq.NoError(err)
+	rq.Empty(byImage)
+
+	byGroup, err = repos.Detections.GetByGroup(ctx, group.Id)
+	rq.NoError(err)
+	rq.Empty(byGroup)
+}
+
+func testReview(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+	group := newGroup(t, repos, lapId, time.Now().UTC().Truncate(time.Second))
+	imageUid := uuid.New()
+
+	detections := []entity.Detection{
+		{GroupId: group.Id, ImageUid: imageUid, Class: "insulator", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending},
+		{GroupId: group.Id, ImageUid: imageUid, Class: "nest", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending},
+	}
+	rq.NoError(repos.Detections.SaveBatch(ctx, detections))
+	rq.NoError(repos.Detections.SaveRects(ctx, []entity.RectDetection{
+		{DetectionId: detections[0].Id, X1: 1, Y1: 1},
+		{DetectionId: detections[1].Id, X1: 1, Y1: 1},
+	}))
+
+	_, err := repos.Detections.Get(ctx, -1)
+	rq.True(failure.IsNotFoundError(err), err)
+
+	at := time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	rq.NoError(repos.Detections.SetImageReview(ctx, group.Id, imageUid, entity.Review{
+		Status:   entity.ReviewConfirmed,
+		Reviewer: "engineer",
+		At:       at,
+	}))
+	rq.NoError(repos.Detections.SetReview(ctx, detections[1].Id, entity.Review{
+		Status:   entity.ReviewCorrected,
+		Reviewer: "lead",
+		Comment:  "a bird nest",
+		Class:    "bird_nest",
+		At:       at.Add(time.Hour),
+	}))
+
+	first, err := repos.Detections.Get(ctx, detections[0].Id)
+	rq.NoError(err)
+	rq.Equal("insulator", first.Class)
+	rq.Equal(entity.ReviewConfirmed, first.ReviewStatus)
+	rq.Equal("engineer", first.ReviewedBy)
+	rq.NotNil(first.ReviewedAt)
+	rq.True(first.ReviewedAt.Equal(at), first.ReviewedAt)
+
+	second, err := repos.Detections.Get(ctx, detections[1].Id)
+	rq.NoError(err)
+	rq.Equal("bird_nest", second.Class)
+	rq.Equal(entity.ReviewCorrected, second.ReviewStatus)
+	rq.Equal("lead", second.ReviewedBy)
+	rq.Equal("a bird nest", second.ReviewComment)
+
+	byImage, err := repos.Detections.GetByImage(ctx, group.Id, imageUid)
+	rq.NoError(err)
+	rq.Len(byImage, 2)
+	rq.Equal(entity.ReviewConfirmed, byImage[0].Review)
+	rq.Equal(entity.ReviewCorrected, byImage[1].Review)
+
+	// a clean image is reviewed without detections, a repeated review is kept once
+	cleanUid := uuid.New()
+	for _, uid := range []uuid.UUID{cleanUid, imageUid, cleanUid} {
+		rq.NoError(repos.Detections.SetImageReviewed(ctx, entity.ImageReview{GroupId: group.Id, ImageUid: uid, ReviewedBy: "engineer", ReviewedAt: at}))
+	}
+
+	reviewed, err := repos.Detections.GetReviewedImages(ctx, group.Id)
+	rq.NoError(err)
+	rq.Equal([]uuid.UUID{cleanUid, imageUid}, reviewed)
+}
+
+func testAnnotations(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+	group := newGroup(t, repos, lapId, time.Now().UTC().Truncate(time.Second))
+	imageUid := uuid.New()
+
+	model := []entity.Detection{
+		{GroupId: group.Id, ImageUid: imageUid, Class: "insulator", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending},
+		{GroupId: group.Id, ImageUid: imageUid, Class: "nest", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending},
+	}
+	rq.NoError(repos.Detections.SaveBatch(ctx, model))
+
+	reviewedAt := time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+	human := &entity.Detection{
+		GroupId:      group.Id,
+		ImageUid:     imageUid,
+		Class:        "nest",
+		Source:       entity.SourceHuman,
+		ReviewStatus: entity.ReviewConfirmed,
+		ReviewedBy:   "engineer",
+		ReviewedAt:   &reviewedAt,
+	}
+	rq.NoError(repos.Detections.Save(ctx, human))
+	rq.Greater(human.Id, model[1].Id)
+
+	rq.NoError(repos.Detections.SaveRects(ctx, []entity.RectDetection{
+		{DetectionId: model[0].Id, Width: 100, Height: 100, X1: 10, Y1: 10},
+		{DetectionId: model[1].Id, Width: 100, Height: 100, X1: 10, Y1: 10},
+		{DetectionId: human.Id, Width: 100, Height: 100, X1: 10, Y1: 10, Confidence: 1},
+	}))
+
+	got, err := repos.Detections.Get(ctx, human.Id)
+	rq.NoError(err)
+	rq.Equal(entity.SourceHuman, got.Source)
+	rq.Equal(entity.ReviewConfirmed, got.ReviewStatus)
+	rq.Equal("engineer", got.ReviewedBy)
+
+	// a model box edited by an engineer
+	rq.NoError(repos.Detections.UpdateRect(ctx, entity.RectDetection{DetectionId: model[0].Id, X0: 5, Y0: 6, X1: 50, Y1: 60}))
+	rq.NoError(repos.Detections.SetSource(ctx, model[0].Id, entity.SourceHuman))
+
+	rect, _, err := repos.Detections.GetRect(ctx, model[0].Id)
+	rq.NoError(err)
+	rq.Equal(entity.RectDetection{Id: rect.Id, DetectionId: model[0].Id, Width: 100, Height: 100, X0: 5, Y0: 6, X1: 50, Y1: 60}, *rect)
+
+	// detecting the image again replaces the model detections only
+	rq.NoError(repos.Detections.DeleteByImage(ctx, group.Id, imageUid))
+	again := []entity.Detection{{GroupId: group.Id, ImageUid: imageUid, Class: "nest", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending}}
+	rq.NoError(repos.Detections.SaveBatch(ctx, again))
+
+	byGroup, err := repos.Detections.GetByGroup(ctx, group.Id)
+	rq.NoError(err)
+	ids := make([]int, len(byGroup))
+	for i := range byGroup {
+		ids[i] = byGroup[i].Id
+	}
+	slices.Sort(ids)
+	rq.Equal([]int{model[0].Id, human.Id, again[0].Id}, ids)
+
+	rq.NoError(repos.Detections.Delete(ctx, human.Id))
+
+	_, err = repos.Detections.Get(ctx, human.Id)
+	rq.True(failure.IsNotFoundError(err), err)
+
+	byImage, err := repos.Detections.GetByImage(ctx, group.Id, imageUid)
+	rq.NoError(err)
+	rq.Len(byImage, 1)
+	rq.Equal(model[0].Id, byImage[0].Id)
+	rq.Equal(entity.SourceHuman, byImage[0].Source)
+}
+
+func testUnitOfWork(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+	group := newGroup(t, repos, lapId, time.Now().UTC().Truncate(time.Second))
+
+	save := func(imageUid uuid.UUID) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			detections := []entity.Detection{{GroupId: group.Id, ImageUid: imageUid, Class: "insulator", Source: entity.SourceModel, ReviewStatus: entity.ReviewPending}}
+			if err := repos.Detections.SaveBatch(ctx, detections); err != nil {
+				return err
+			}
+			return repos.Detections.SaveRects(ctx, []entity.RectDetection{{DetectionId: detections[0].Id, X1: 1, Y1: 1}})
+		}
+	}
+
+	errRollback := errors.New("rollback")
+	err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := save(uuid.New())(ctx); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	rq.ErrorIs(err, errRollback)
+
+	detections, err := repos.Detections.GetByGroup(ctx, group.Id)
+	rq.NoError(err)
+	rq.Empty(detections)
+
+	imageUid := uuid.New()
+	rq.NoError(repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		// nested calls join the outer transaction
+		return repos.UnitOfWork.Do(ctx, save(imageUid))
+	}))
+
+	byImage, err := repos.Detections.GetByImage(ctx, group.Id, imageUid)
+	rq.NoError(err)
+	rq.Len(byImage, 1)
+}
+
+func testLapConfig(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+
+	rq.NoError(repos.LapConfig.SaveParameter(ctx, lapId, entity.LapParameter{Class: "insulator", Value: 1}))
+	rq.NoError(repos.LapConfig.SaveParameter(ctx, lapId, entity.LapParameter{Class: "nest", Value: 2}))
+	rq.NoError(repos.LapConfig.SaveParameter(ctx, lapId, entity.LapParameter{Class: "insulator", Value: 3}))
+
+	rq.Equal([]entity.LapParameter{{Class: "insulator", Value: 3}, {Class: "nest", Value: 2}}, lapParameters(t, repos, lapId))
+
+	rq.NoError(repos.LapConfig.UpdateParameter(ctx, lapId, entity.LapParameter{Class: "nest", Value: 5}))
+	rq.NoError(repos.LapConfig.DeleteParameters(ctx, lapId, []string{"insulator"}))
+
+	rq.Equal([]entity.LapParameter{{Class: "nest", Value: 5}}, lapParameters(t, repos, lapId))
+
+	params, err := repos.LapConfig.GetLapParameters(ctx, "unknown-lap")
+	rq.NoError(err)
+	rq.Empty(params)
+}
+
+func testEvaluations(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	model := "test-" + uuid.NewString()
+	result := &evaluation.Result{
+		Images:  2,
+		MAP50:   0.5,
+		Classes: []evaluation.ClassMetrics{{Class: "nest", Truth: 3, Precision: 1, Recall: 0.5, AP50: 0.5}},
+		Confusion: evaluation.Confusion{
+			Labels: []string{"nest", evaluation.Background},
+			Matrix: [][]int{{1, 2}, {0, 0}},
+		},
+	}
+
+	var ids []int
+	for i := range 2 {
+		e := &entity.Evaluation{
+			Model:         model,
+			Comment:       "build " + strconv.Itoa(i),
+			GroupIds:      []int{1, 2},
+			ConfThreshold: 0.25,
+			Images:        result.Images,
+			Precision:     1,
+			Recall:        0.5,
+			MAP50:         result.MAP50,
+			MAP50_95:      0.25,
+			CreateAt:      time.Now().UTC().Truncate(time.Second),
+			Result:        result,
+		}
+		rq.NoError(repos.Evaluations.Save(ctx, e))
+		rq.NotZero(e.Id)
+		ids = append(ids, e.Id)
+		t.Cleanup(func() { repos.Evaluations.Delete(ctx, e.Id) })
+	}
+
+	saved, err := repos.Evaluations.Get(ctx, ids[0])
+	rq.NoError(err)
+	rq.Equal(model, saved.Model)
+	rq.Equal("build 0", saved.Comment)
+	rq.Equal([]int{1, 2}, saved.GroupIds)
+	rq.Equal(float32(0.25), saved.ConfThreshold)
+	rq.Equal(0.5, saved.MAP50)
+	rq.Equal(result, saved.Result)
+
+	list, err := repos.Evaluations.List(ctx, model)
+	rq.NoError(err)
+	rq.Len(list, 2)
+	rq.Equal(ids[1], list[0].Id)
+	rq.Nil(list[0].Result)
+	rq.Equal([]int{1, 2}, list[0].GroupIds)
+
+	all, err := repos.Evaluations.List(ctx, "")
+	rq.NoError(err)
+	rq.GreaterOrEqual(len(all), 2)
+
+	rq.NoError(repos.Evaluations.Delete(ctx, ids[0]))
+	_, err = repos.Evaluations.Get(ctx, ids[0])
+	rq.True(failure.IsNotFoundError(err), err)
+}
+
+func lapParameters(t *testing.T, repos Repos, lapId string) []entity.LapParameter {
+	params, err := repos.LapConfig.GetLapParameters(context.Background(), lapId)
+	require.NoError(t, err)
+
+	slices.SortFunc(params, func(a, b entity.LapParameter) int {
+		return strings.Compare(a.Class, b.Class)
+	})
+
+	return params
+}
+
+// newLap saves a lap with a unique free-text id and deletes it with its
+// groups when the test ends.
+func newLap(t *testing.T, repos Repos) string {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	lap := &entity.Lap{
+		Id:           "ВЛ " + uuid.NewString(),
+		Name:         t.Name(),
+		VoltageClass: "110",
+		CreateAt:     now,
+		UpdateAt:     now,
+	}
+	require.NoError(t, repos.Laps.Save(ctx, lap))
+
+	t.Cleanup(func() {
+		groups, err := repos.Groups.GetByLap(ctx, lap.Id)
+		require.NoError(t, err)
+		for _, group := range groups {
+			require.NoError(t, repos.Groups.Delete(ctx, group.Id))
+		}
+		require.NoError(t, repos.Laps.Delete(ctx, lap.Id))
+	})
+
+	return lap.Id
+}
+
+func newGroup(t *testing.T, repos Repos, lapId string, createAt time.Time) *entity.Group {
+	group := &entity.Group{
+		LapId:    lapId,
+		CreateAt: createAt,
+	}
+	require.NoError(t, repos.Groups.Save(context.Background(), group))
+	require.NotZero(t, group.Id)
+
+	return group
+}
+
+func testTasks(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	task := &entity.Task{
+		Kind:     entity.TaskBatchUpload,
+		Status:   entity.JobQueued,
+		Params:   []byte(`{"group_id":1}`),
+		Input:    "input-1",
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	rq.NoError(repos.Tasks.Save(ctx, task))
+	rq.NotZero(task.Id)
+	t.Cleanup(func() { repos.Tasks.Delete(ctx, task.Id) })
+
+	saved, err := repos.Tasks.Get(ctx, task.Id)
+	rq.NoError(err)
+	rq.Equal(entity.TaskBatchUpload, saved.Kind)
+	rq.JSONEq(`{"group_id":1}`, string(saved.Params))
+	rq.Nil(saved.Result)
+	rq.Equal("input-1", saved.Input)
+
+	rq.NoError(repos.Tasks.UpdateStatus(ctx, task.Id, entity.JobRunning, ""))
+	unfinished, err := repos.Tasks.GetUnfinished(ctx)
+	rq.NoError(err)
+	rq.True(slices.ContainsFunc(unfinished, func(u entity.Task) bool { return u.Id == task.Id }))
+
+	task.Status = entity.JobDone
+	task.Result = []byte(`{"total":2}`)
+	task.Output = "dataset.zip"
+	task.UpdateAt = now.Add(-time.Hour)
+	rq.NoError(repos.Tasks.Finish(ctx, task))
+
+	saved, err = repos.Tasks.Get(ctx, task.Id)
+	rq.NoError(err)
+	rq.Equal(entity.JobDone, saved.Status)
+	rq.JSONEq(`{"total":2}`, string(saved.Result))
+	rq.Equal("dataset.zip", saved.Output)
+
+	expired, err := repos.Tasks.GetFinishedBefore(ctx, now.Add(-time.Minute))
+	rq.NoError(err)
+	rq.True(slices.ContainsFunc(expired, func(e entity.Task) bool { return e.Id == task.Id }))
+
+	rq.NoError(repos.Tasks.Delete(ctx, task.Id))
+	_, err = repos.Tasks.Get(ctx, task.Id)
+	rq.True(failure.IsNotFoundError(err), err)
+}
+
+func testAnnotationHistory(t *testing.T, repos Repos) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	lapId := newLap(t, repos)
+	group := newGroup(t, repos, lapId, time.Now().UTC().Truncate(time.Second))
+	imageUid := uuid.New()
+
+	for i, action := range []entity.AnnotationAction{entity.AnnotationUpdated, entity.AnnotationDeleted} {
+		change := &entity.AnnotationChange{
+			DetectionId:  7,
+			GroupId:      group.Id,
+			ImageUid:     imageUid,
+			Action:       action,
+			Editor:       "ivanov",
+			Class:        "nest",
+			Source:       entity.SourceModel,
+			ReviewStatus: entity.ReviewPending,
+			X0:           i,
+			Y0:           2,
+			X1:           30,
+			Y1:           40,
+			CreateAt:     time.Now().UTC().Truncate(time.Second),
+		}
+		rq.NoError(repos.History.Save(ctx, change))
+		rq.NotZero(change.Id)
+	}
+
+	changes, err := repos.History.GetByImage(ctx, group.Id, imageUid)
+	rq.NoError(err)
+	rq.Len(changes, 2)
+	rq.Equal(entity.AnnotationUpdated, changes[0].Action)
+	rq.Equal(entity.AnnotationDeleted, changes[1].Action)
+	rq.Equal(imageUid, changes[1].ImageUid)
+	rq.Equal(1, changes[1].X0)
+	rq.Equal(entity.SourceModel, changes[1].Source)
+
+	other, err := repos.History.GetByImage(ctx, group.Id, uuid.New())
+	rq.NoError(err)
+	rq.Empty(other)
+}
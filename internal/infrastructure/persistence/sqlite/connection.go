@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"FairLAP/internal/config"
+	"context"
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+	"time"
+)
+
+const driverName = "sqlite"
+
+func init() {
+	sqlx.BindDriver(driverName, sqlx.QUESTION)
+}
+
+// Connect opens the database file, creating it if needed. Foreign keys are
+// enforced and transactions take the write lock at start, so concurrent
+// writers wait for busy_timeout instead of failing on lock upgrade.
+func Connect(cfg *config.SQLiteConfig) (*sqlx.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dataSource := "file:" + cfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+
+	db, err := sqlx.ConnectContext(ctx, driverName, dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
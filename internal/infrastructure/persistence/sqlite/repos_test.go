@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"FairLAP/internal/config"
+	"FairLAP/internal/infrastructure/persistence/repotest"
+	"FairLAP/internal/infrastructure/persistence/sqlrepo"
+	"FairLAP/migrations"
+	"FairLAP/pkg/migrate"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepos(t *testing.T) {
+	rq := require.New(t)
+
+	db, err := Connect(&config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	rq.NoError(err)
+	t.Cleanup(func() { db.Close() })
+
+	fsys, err := migrations.FS(migrations.SQLite)
+	rq.NoError(err)
+	migrator, err := migrate.New(db, fsys)
+	rq.NoError(err)
+	_, err = migrator.Up(context.Background())
+	rq.NoError(err)
+	rq.NoError(migrator.Check(context.Background()))
+
+	repotest.Run(t, repotest.Repos{
+		Laps:        sqlrepo.NewLapsRepo(db),
+		Groups:      sqlrepo.NewGroupsRepo(db),
+		Detections:  sqlrepo.NewDetectionsRepo(db),
+		LapConfig:   sqlrepo.NewLapConfigRepo(db),
+		Evaluations: sqlrepo.NewEvaluationsRepo(db),
+		Tasks:       sqlrepo.NewTasksRepo(db),
+		History:     sqlrepo.NewAnnotationHistoryRepo(db),
+		UnitOfWork:  sqlrepo.NewUnitOfWork(db),
+	})
+
+	_, err = migrator.Down(context.Background(), 14)
+	rq.NoError(err)
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	db, err := Connect(&config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	rq.NoError(err)
+	t.Cleanup(func() { db.Close() })
+
+	fsys, err := migrations.FS(migrations.SQLite)
+	rq.NoError(err)
+	migrator, err := migrate.New(db, fsys)
+	rq.NoError(err)
+	_, err = migrator.Up(ctx)
+	rq.NoError(err)
+	_, err = migrator.Down(ctx, 13)
+	rq.NoError(err)
+
+	db.MustExec("insert into `groups` (lap_id, create_at) values ('12', '2024-05-01 10:00:00')")
+	db.MustExec("insert into detections (group_id, image_uid, class) values (1, 'uid', 'bird')")
+	db.MustExec("insert into lap_config (lap_id, class, value) values (12, 'bird', 1), (7, 'nest', 0)")
+
+	_, err = migrator.Up(ctx)
+	rq.NoError(err)
+
+	var laps []string
+	rq.NoError(db.Select(&laps, "select id from laps order by id"))
+	rq.Equal([]string{"12", "7"}, laps)
+
+	var detections int
+	rq.NoError(db.Get(&detections, "select count(*) from detections"))
+	rq.Equal(1, detections)
+
+	var configs int
+	rq.NoError(db.Get(&configs, "select count(*) from lap_config where lap_id in ('12', '7')"))
+	rq.Equal(2, configs)
+}
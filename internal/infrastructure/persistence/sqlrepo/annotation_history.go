@@ -0,0 +1,50 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AnnotationHistoryRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewAnnotationHistoryRepo(db *sqlx.DB) *AnnotationHistoryRepo {
+	return &AnnotationHistoryRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+func (r *AnnotationHistoryRepo) Save(ctx context.Context, change *entity.AnnotationChange) error {
+	const op = "AnnotationHistoryRepo.Save"
+
+	id, err := r.dialect.insertNamed(ctx, conn(ctx, r.db), "INSERT INTO annotation_history (detection_id, group_id, image_uid, action, editor, class, source, review_status, x0, y0, x1, y1, create_at) VALUES (:detection_id, :group_id, :image_uid, :action, :editor, :class, :source, :review_status, :x0, :y0, :x1, :y1, :create_at)", change)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	change.Id = id
+
+	return nil
+}
+
+// GetByImage returns the changes of the image detections, the oldest first.
+func (r *AnnotationHistoryRepo) GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]entity.AnnotationChange, error) {
+	const op = "AnnotationHistoryRepo.GetByImage"
+
+	var changes []entity.AnnotationChange
+	if err := r.db.SelectContext(ctx, &changes, r.dialect.rebind("SELECT * FROM annotation_history WHERE group_id=? AND image_uid=? ORDER BY id"), groupId, imageUid); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,298 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/aggregate"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+const detectionColumns = "id, group_id, image_uid, class, conf_threshold, source, review_status, reviewed_by, reviewed_at, review_comment"
+
+type DetectionsRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewDetectionsRepo(db *sqlx.DB) *DetectionsRepo {
+	return &DetectionsRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+func (r *DetectionsRepo) Save(ctx context.Context, detections *entity.Detection) error {
+	const op = "DetectionsRepo.Save"
+	id, err := r.dialect.insertNamed(ctx, conn(ctx, r.db), "INSERT INTO detections (group_id, image_uid, class, conf_threshold, source, review_status, reviewed_by, reviewed_at, review_comment) VALUES (:group_id, :image_uid, :class, :conf_threshold, :source, :review_status, :reviewed_by, :reviewed_at, :review_comment)", detections)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	detections.Id = id
+
+	return nil
+}
+
+// SaveBatch inserts the detections of one image with a single statement and
+// sets their ids. The detections must have the same source and the image
+// must have no other detections of it, so model detections have to be saved
+// in a transaction after DeleteByImage.
+func (r *DetectionsRepo) SaveBatch(ctx context.Context, detections []entity.Detection) error {
+	const op = "DetectionsRepo.SaveBatch"
+
+	if len(detections) == 0 {
+		return nil
+	}
+
+	q := conn(ctx, r.db)
+
+	query := "INSERT INTO detections (group_id, image_uid, class, conf_threshold, source, review_status, reviewed_by, reviewed_at, review_comment) VALUES"
+	args := make([]any, 0, len(detections)*9)
+
+	for _, d := range detections {
+		query += " (?, ?, ?, ?, ?, ?, ?, ?, ?),"
+		args = append(args, d.GroupId, d.ImageUid, d.Class, d.ConfThreshold, d.Source, d.ReviewStatus, d.ReviewedBy, d.ReviewedAt, d.ReviewComment)
+	}
+
+	query = strings.TrimSuffix(query, ",")
+
+	if _, err := q.ExecContext(ctx, r.dialect.rebind(query), args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// serial ids of a multi-row insert are increasing, but not always
+	// consecutive, so they are read back instead of returned by the insert
+	var ids []int
+	if err := sqlx.SelectContext(ctx, q, &ids, r.dialect.rebind("SELECT id FROM detections WHERE group_id=? AND image_uid=? AND source=? ORDER BY id"), detections[0].GroupId, detections[0].ImageUid, detections[0].Source); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(ids) != len(detections) {
+		return fmt.Errorf("%s: expected %d detections of the image, got %d", op, len(detections), len(ids))
+	}
+
+	for i := range detections {
+		detections[i].Id = ids[i]
+	}
+
+	return nil
+}
+
+// DeleteByImage removes the model detections of the image, human
+// annotations are kept.
+func (r *DetectionsRepo) DeleteByImage(ctx context.Context, groupId int, imageUid uuid.UUID) error {
+	const op = "DetectionsRepo.DeleteByImage"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind("DELETE FROM detections WHERE group_id=? AND image_uid=? AND source=?"), groupId, imageUid, entity.SourceModel); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *DetectionsRepo) GetByGroup(ctx context.Context, group int) ([]entity.Detection, error) {
+	const op = "DetectionsRepo.GetByGroup"
+	var detections []entity.Detection
+
+	if err := r.db.SelectContext(ctx, &detections, r.dialect.rebind("SELECT "+detectionColumns+" FROM detections WHERE group_id=?"), group); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return detections, nil
+}
+
+func (r *DetectionsRepo) Get(ctx context.Context, id int) (*entity.Detection, error) {
+	const op = "DetectionsRepo.Get"
+	var detection entity.Detection
+
+	if err := r.db.GetContext(ctx, &detection, r.dialect.rebind("SELECT "+detectionColumns+" FROM detections WHERE id=?"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("detection not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &detection, nil
+}
+
+// SetReview saves the review of the detection, the class is replaced by
+// review.Class.
+func (r *DetectionsRepo) SetReview(ctx context.Context, id int, review entity.Review) error {
+	const op = "DetectionsRepo.SetReview"
+
+	query := "UPDATE detections SET class=?, review_status=?, reviewed_by=?, reviewed_at=?, review_comment=? WHERE id=?"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), review.Class, review.Status, review.Reviewer, review.At, review.Comment, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetImageReview saves the review of every detection of the image, their
+// classes are kept.
+func (r *DetectionsRepo) SetImageReview(ctx context.Context, groupId int, imageUid uuid.UUID, review entity.Review) error {
+	const op = "DetectionsRepo.SetImageReview"
+
+	query := "UPDATE detections SET review_status=?, reviewed_by=?, reviewed_at=?, review_comment=? WHERE group_id=? AND image_uid=?"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), review.Status, review.Reviewer, review.At, review.Comment, groupId, imageUid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetImageReviewed marks the image reviewed as a whole, a repeated review
+// replaces the reviewer.
+func (r *DetectionsRepo) SetImageReviewed(ctx context.Context, review entity.ImageReview) error {
+	const op = "DetectionsRepo.SetImageReviewed"
+
+	query := `
+INSERT INTO image_reviews (group_id, image_uid, reviewed_by, reviewed_at)
+VALUES (:group_id, :image_uid, :reviewed_by, :reviewed_at)
+` + r.dialect.upsert([]string{"group_id", "image_uid"}, "reviewed_by", "reviewed_at")
+
+	query, args, err := sqlx.Named(query, review)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetReviewedImages returns the images of the group reviewed as a whole.
+func (r *DetectionsRepo) GetReviewedImages(ctx context.Context, groupId int) ([]uuid.UUID, error) {
+	const op = "DetectionsRepo.GetReviewedImages"
+
+	var uids []uuid.UUID
+	if err := r.db.SelectContext(ctx, &uids, r.dialect.rebind("SELECT image_uid FROM image_reviews WHERE group_id=? ORDER BY id"), groupId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return uids, nil
+}
+
+func (r *DetectionsRepo) SetSource(ctx context.Context, id int, source entity.DetectionSource) error {
+	const op = "DetectionsRepo.SetSource"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind("UPDATE detections SET source=? WHERE id=?"), source, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *DetectionsRepo) Delete(ctx context.Context, id int) error {
+	const op = "DetectionsRepo.Delete"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind("DELETE FROM detections WHERE id=?"), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *DetectionsRepo) IsExistProblem(ctx context.Context, lapId string) (bool, error) {
+	const op = "DetectionsRepo.IsExistProblem"
+
+	query := "SELECT EXISTS(SELECT * FROM detections INNER JOIN `groups` ON detections.group_id = `groups`.id WHERE detections.is_problem AND `groups`.lap_id=?)"
+	var exist bool
+	if err := r.db.QueryRowContext(ctx, r.dialect.rebind(query), lapId).Scan(&exist); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exist, nil
+}
+
+func (r *DetectionsRepo) SaveRects(ctx context.Context, rects []entity.RectDetection) error {
+	const op = "DetectionsRepo.Save"
+
+	if len(rects) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO detection_rects (detection_id, width, height, x0, y0, x1, y1, confidence) VALUES"
+	args := make([]any, 0, len(rects)*8)
+
+	for _, rect := range rects {
+		query += " (?, ?, ?, ?, ?, ?, ?, ?),"
+		args = append(args, rect.DetectionId, rect.Width, rect.Height, rect.X0, rect.Y0, rect.X1, rect.Y1, rect.Confidence)
+	}
+
+	query = strings.TrimSuffix(query, ",")
+
+	q := conn(ctx, r.db)
+
+	if _, err := q.ExecContext(ctx, r.dialect.rebind(query), args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateRect moves the box of rect.DetectionId.
+func (r *DetectionsRepo) UpdateRect(ctx context.Context, rect entity.RectDetection) error {
+	const op = "DetectionsRepo.UpdateRect"
+
+	query := "UPDATE detection_rects SET x0=?, y0=?, x1=?, y1=? WHERE detection_id=?"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), rect.X0, rect.Y0, rect.X1, rect.Y1, rect.DetectionId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *DetectionsRepo) GetRect(ctx context.Context, detectionId int) (*entity.RectDetection, string, error) {
+	const op = "DetectionsRepo.GetRect"
+	var rect entity.RectDetection
+	var class string
+	if err := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT detection_rects.id, detection_rects.detection_id, detection_rects.width, detection_rects.height, detection_rects.x0, detection_rects.y0, detection_rects.x1, detection_rects.y1, detection_rects.confidence, detections.class FROM detection_rects INNER JOIN detections ON detection_rects.detection_id = detections.id WHERE detection_id=?"), detectionId).Scan(
+		&rect.Id, &rect.DetectionId, &rect.Width, &rect.Height, &rect.X0, &rect.Y0, &rect.X1, &rect.Y1, &rect.Confidence, &class); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return &rect, class, nil
+}
+
+func (r *DetectionsRepo) GetByImage(ctx context.Context, groupId int, imageUid uuid.UUID) ([]aggregate.DetectionRect, error) {
+	const op = "DetectionsRepo.GetByImage"
+
+	query := `
+SELECT detections.id, detections.group_id, detections.image_uid, detections.class, detections.conf_threshold, detections.source, detections.review_status, detection_rects.confidence,
+       detection_rects.width, detection_rects.height, detection_rects.x0, detection_rects.y0, detection_rects.x1, detection_rects.y1
+FROM detections INNER JOIN detection_rects ON detection_rects.detection_id = detections.id
+WHERE detections.group_id=? AND detections.image_uid=? ORDER BY detections.id`
+
+	var detections []aggregate.DetectionRect
+	if err := r.db.SelectContext(ctx, &detections, r.dialect.rebind(query), groupId, imageUid); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return detections, nil
+}
+
+// GetRectsByGroup returns the detections of the group with their boxes.
+func (r *DetectionsRepo) GetRectsByGroup(ctx context.Context, groupId int) ([]aggregate.DetectionRect, error) {
+	const op = "DetectionsRepo.GetRectsByGroup"
+
+	query := `
+SELECT detections.id, detections.group_id, detections.image_uid, detections.class, detections.conf_threshold, detections.source, detections.review_status, detection_rects.confidence,
+       detection_rects.width, detection_rects.height, detection_rects.x0, detection_rects.y0, detection_rects.x1, detection_rects.y1
+FROM detections INNER JOIN detection_rects ON detection_rects.detection_id = detections.id
+WHERE detections.group_id=? ORDER BY detections.id`
+
+	var detections []aggregate.DetectionRect
+	if err := r.db.SelectContext(ctx, &detections, r.dialect.rebind(query), groupId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return detections, nil
+}
@@ -0,0 +1,91 @@
+// Package sqlrepo implements the repositories on MySQL, PostgreSQL and
+// SQLite. Queries are written with ? placeholders and `groups` quoted with
+// backticks, dialect rewrites them for the database and holds the
+// statements the databases spell differently.
+package sqlrepo
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+type dialect struct {
+	bind  int
+	quote string
+	// returning reads the id of a new row with RETURNING id, Postgres has
+	// no LastInsertId and SQLite does not set it when an upsert updates
+	returning bool
+	// duplicateKey is set for MySQL, it has no ON CONFLICT
+	duplicateKey bool
+}
+
+func dialectOf(db *sqlx.DB) dialect {
+	switch db.DriverName() {
+	case "mysql":
+		return dialect{bind: sqlx.QUESTION, quote: "`", duplicateKey: true}
+	case "pgx", "postgres":
+		return dialect{bind: sqlx.DOLLAR, quote: `"`, returning: true}
+	default:
+		return dialect{bind: sqlx.QUESTION, quote: "`", returning: true}
+	}
+}
+
+// rebind converts the placeholders and quoted identifiers of query.
+func (d dialect) rebind(query string) string {
+	if d.quote != "`" {
+		query = strings.ReplaceAll(query, "`", d.quote)
+	}
+	return sqlx.Rebind(d.bind, query)
+}
+
+// upsert returns the clause of an insert that updates columns of the row
+// that conflicts on the key columns instead.
+func (d dialect) upsert(key []string, columns ...string) string {
+	set := make([]string, len(columns))
+
+	if d.duplicateKey {
+		for i, c := range columns {
+			set[i] = c + "=VALUES(" + c + ")"
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	}
+
+	for i, c := range columns {
+		set[i] = c + "=excluded." + c
+	}
+	return "ON CONFLICT (" + strings.Join(key, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+// insert runs the insert query and returns the id of the new row.
+func (d dialect) insert(ctx context.Context, q sqlx.ExtContext, query string, args ...any) (int, error) {
+	if d.returning {
+		var id int
+		if err := q.QueryRowxContext(ctx, d.rebind(query+" RETURNING id"), args...).Scan(&id); err != nil {
+			return 0, err
+		}
+		return id, nil
+	}
+
+	res, err := q.ExecContext(ctx, d.rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// insertNamed is insert with the arguments bound by name from arg.
+func (d dialect) insertNamed(ctx context.Context, q sqlx.ExtContext, query string, arg any) (int, error) {
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.insert(ctx, q, query, args...)
+}
@@ -0,0 +1,26 @@
+package sqlrepo
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialect(t *testing.T) {
+	rq := require.New(t)
+
+	mysql := dialectOf(sqlx.NewDb(nil, "mysql"))
+	postgres := dialectOf(sqlx.NewDb(nil, "pgx"))
+	sqlite := dialectOf(sqlx.NewDb(nil, "sqlite"))
+
+	query := "SELECT lap_id FROM `groups` WHERE id=? AND lap_id=?"
+	rq.Equal(query, mysql.rebind(query))
+	rq.Equal(query, sqlite.rebind(query))
+	rq.Equal(`SELECT lap_id FROM "groups" WHERE id=$1 AND lap_id=$2`, postgres.rebind(query))
+
+	key := []string{"lap_id", "class"}
+	rq.Equal("ON DUPLICATE KEY UPDATE value=VALUES(value), note=VALUES(note)", mysql.upsert(key, "value", "note"))
+	rq.Equal("ON CONFLICT (lap_id, class) DO UPDATE SET value=excluded.value, note=excluded.note", postgres.upsert(key, "value", "note"))
+	rq.Equal(postgres.upsert(key, "value"), sqlite.upsert(key, "value"))
+}
@@ -0,0 +1,130 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/evaluation"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+const evaluationColumns = "id, model, comment, group_ids, conf_threshold, images, precision_value, recall_value, map50, map50_95, create_at"
+
+type EvaluationsRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewEvaluationsRepo(db *sqlx.DB) *EvaluationsRepo {
+	return &EvaluationsRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+type evaluationRow struct {
+	entity.Evaluation
+	GroupIdsData string `db:"group_ids"`
+	ResultData   string `db:"result"`
+}
+
+func (row *evaluationRow) evaluation() (*entity.Evaluation, error) {
+	e := &row.Evaluation
+	if err := json.Unmarshal([]byte(row.GroupIdsData), &e.GroupIds); err != nil {
+		return nil, err
+	}
+	if row.ResultData != "" {
+		e.Result = new(evaluation.Result)
+		if err := json.Unmarshal([]byte(row.ResultData), e.Result); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func (r *EvaluationsRepo) Save(ctx context.Context, e *entity.Evaluation) error {
+	const op = "EvaluationsRepo.Save"
+
+	groupIds, err := json.Marshal(e.GroupIds)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	result, err := json.Marshal(e.Result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	row := evaluationRow{Evaluation: *e, GroupIdsData: string(groupIds), ResultData: string(result)}
+
+	id, err := r.dialect.insertNamed(ctx, conn(ctx, r.db), "INSERT INTO evaluations (model, comment, group_ids, conf_threshold, images, precision_value, recall_value, map50, map50_95, result, create_at) VALUES (:model, :comment, :group_ids, :conf_threshold, :images, :precision_value, :recall_value, :map50, :map50_95, :result, :create_at)", row)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	e.Id = id
+
+	return nil
+}
+
+func (r *EvaluationsRepo) Get(ctx context.Context, id int) (*entity.Evaluation, error) {
+	const op = "EvaluationsRepo.Get"
+
+	var row evaluationRow
+	if err := r.db.GetContext(ctx, &row, r.dialect.rebind("SELECT "+evaluationColumns+", result FROM evaluations WHERE id=?"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("evaluation not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	e, err := row.evaluation()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return e, nil
+}
+
+// List returns the runs of the model, newest first, without their results.
+// An empty model lists every run.
+func (r *EvaluationsRepo) List(ctx context.Context, model string) ([]entity.Evaluation, error) {
+	const op = "EvaluationsRepo.List"
+
+	query := "SELECT " + evaluationColumns + " FROM evaluations"
+	var args []any
+	if model != "" {
+		query += " WHERE model=?"
+		args = append(args, model)
+	}
+	query += " ORDER BY id DESC"
+
+	var rows []evaluationRow
+	if err := r.db.SelectContext(ctx, &rows, r.dialect.rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	evaluations := make([]entity.Evaluation, len(rows))
+	for i := range rows {
+		e, err := rows[i].evaluation()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		evaluations[i] = *e
+	}
+
+	return evaluations, nil
+}
+
+func (r *EvaluationsRepo) Delete(ctx context.Context, id int) error {
+	const op = "EvaluationsRepo.Delete"
+
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM evaluations WHERE id=?"), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
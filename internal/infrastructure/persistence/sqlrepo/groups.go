@@ -1,4 +1,4 @@
-package mysql
+package sqlrepo
 
 import (
 	"FairLAP/internal/domain/aggregate"
@@ -12,29 +12,26 @@ import (
 )
 
 type GroupsRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dialect dialect
 }
 
 func NewGroupsRepo(db *sqlx.DB) *GroupsRepo {
 	return &GroupsRepo{
-		db: db,
+		db:      db,
+		dialect: dialectOf(db),
 	}
 }
 
 func (r *GroupsRepo) Save(ctx context.Context, group *entity.Group) error {
 	const op = "DetectionsRepo.SaveGroup"
 
-	res, err := r.db.NamedExecContext(ctx, "INSERT INTO `groups` (lap_id, create_at) VALUES (:lap_id, :create_at)", group)
+	id, err := r.dialect.insertNamed(ctx, r.db, "INSERT INTO `groups` (lap_id, create_at) VALUES (:lap_id, :create_at)", group)
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, op)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("%w: %s", err, op)
-	}
-
-	group.Id = int(id)
+	group.Id = id
 
 	return nil
 }
@@ -42,7 +39,7 @@ func (r *GroupsRepo) Save(ctx context.Context, group *entity.Group) error {
 func (r *GroupsRepo) GetByLap(ctx context.Context, lapId string) ([]entity.Group, error) {
 	const op = "DetectionsRepo.GetByLap"
 	var groups []entity.Group
-	if err := r.db.SelectContext(ctx, &groups, "SELECT * FROM `groups` WHERE lap_id=?", lapId); err != nil {
+	if err := r.db.SelectContext(ctx, &groups, r.dialect.rebind("SELECT * FROM `groups` WHERE lap_id=?"), lapId); err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
@@ -52,8 +49,14 @@ func (r *GroupsRepo) GetByLap(ctx context.Context, lapId string) ([]entity.Group
 
 func (r *GroupsRepo) GetLaps(ctx context.Context) ([]aggregate.LapLastDetect, error) {
 	const op = "DetectionsRepo.GetLaps"
+	// aggregates lose the column type and SQLite returns them as text, so the
+	// last group is selected as a row to scan create_at as time
+	query := `
+SELECT id AS last_group, lap_id, create_at AS last_detect FROM ` + "`groups`" + ` g
+WHERE id = (SELECT max(id) FROM ` + "`groups`" + ` WHERE lap_id = g.lap_id)`
+
 	var laps []aggregate.LapLastDetect
-	if err := r.db.SelectContext(ctx, &laps, "SELECT max(id) AS last_group, lap_id, max(create_at) AS last_detect FROM `groups` GROUP BY lap_id"); err != nil {
+	if err := r.db.SelectContext(ctx, &laps, r.dialect.rebind(query)); err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
@@ -64,7 +67,7 @@ func (r *GroupsRepo) GetLaps(ctx context.Context) ([]aggregate.LapLastDetect, er
 func (r *GroupsRepo) GetLapId(ctx context.Context, groupId int) (string, error) {
 	const op = "DetectionsRepo.GetLaps"
 	var laps string
-	if err := r.db.GetContext(ctx, &laps, "SELECT lap_id FROM `groups` WHERE id=?", groupId); err != nil {
+	if err := r.db.GetContext(ctx, &laps, r.dialect.rebind("SELECT lap_id FROM `groups` WHERE id=?"), groupId); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", fmt.Errorf("%s: %w", op, failure.NewNotFoundError(err.Error()))
 		}
@@ -75,7 +78,7 @@ func (r *GroupsRepo) GetLapId(ctx context.Context, groupId int) (string, error)
 
 func (r *GroupsRepo) Delete(ctx context.Context, id int) error {
 	const op = "DetectionsRepo.DeleteGroup"
-	if _, err := r.db.ExecContext(ctx, "DELETE FROM `groups` WHERE id = ?", id); err != nil {
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM `groups` WHERE id = ?"), id); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
@@ -0,0 +1,69 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ImageMetaRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewImageMetaRepo(db *sqlx.DB) *ImageMetaRepo {
+	return &ImageMetaRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+const imageMetaColumns = "group_id, image_uid, latitude, longitude, altitude, relative_altitude, gimbal_pitch, gimbal_yaw, gimbal_roll, capture_at, camera_make, camera_model"
+
+func (r *ImageMetaRepo) Save(ctx context.Context, meta *entity.ImageMeta) error {
+	const op = "ImageMetaRepo.Save"
+
+	query := `
+INSERT INTO image_meta (` + imageMetaColumns + `)
+VALUES (:group_id, :image_uid, :latitude, :longitude, :altitude, :relative_altitude, :gimbal_pitch, :gimbal_yaw, :gimbal_roll, :capture_at, :camera_make, :camera_model)
+` + r.dialect.upsert([]string{"group_id", "image_uid"}, "latitude", "longitude", "altitude", "relative_altitude",
+		"gimbal_pitch", "gimbal_yaw", "gimbal_roll", "capture_at", "camera_make", "camera_model")
+
+	if _, err := r.db.NamedExecContext(ctx, r.dialect.rebind(query), meta); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ImageMetaRepo) Get(ctx context.Context, groupId int, imageUid uuid.UUID) (*entity.ImageMeta, error) {
+	const op = "ImageMetaRepo.Get"
+
+	var meta entity.ImageMeta
+	if err := r.db.GetContext(ctx, &meta, r.dialect.rebind("SELECT "+imageMetaColumns+" FROM image_meta WHERE group_id=? AND image_uid=?"), groupId, imageUid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("image meta not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &meta, nil
+}
+
+func (r *ImageMetaRepo) GetByGroup(ctx context.Context, groupId int) ([]entity.ImageMeta, error) {
+	const op = "ImageMetaRepo.GetByGroup"
+
+	var meta []entity.ImageMeta
+	if err := r.db.SelectContext(ctx, &meta, r.dialect.rebind("SELECT "+imageMetaColumns+" FROM image_meta WHERE group_id=?"), groupId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return meta, nil
+}
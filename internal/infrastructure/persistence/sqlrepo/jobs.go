@@ -0,0 +1,137 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
+)
+
+type JobsRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewJobsRepo(db *sqlx.DB) *JobsRepo {
+	return &JobsRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+func (r *JobsRepo) Save(ctx context.Context, job *entity.Job) error {
+	const op = "JobsRepo.Save"
+
+	id, err := r.dialect.insertNamed(ctx, conn(ctx, r.db), "INSERT INTO detection_jobs (group_id, status, thresholds, error, create_at, update_at) VALUES (:group_id, :status, :thresholds, :error, :create_at, :update_at)", job)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Id = id
+
+	return nil
+}
+
+func (r *JobsRepo) SaveImages(ctx context.Context, images []entity.JobImage) error {
+	const op = "JobsRepo.SaveImages"
+
+	if len(images) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO detection_job_images (job_id, image_uid, status, error, detections_count) VALUES"
+	args := make([]any, 0, len(images)*5)
+
+	for _, img := range images {
+		query += " (?, ?, ?, ?, ?),"
+		args = append(args, img.JobId, img.ImageUid, img.Status, img.Error, img.DetectionsCount)
+	}
+
+	query = strings.TrimSuffix(query, ",")
+
+	q := conn(ctx, r.db)
+
+	if _, err := q.ExecContext(ctx, r.dialect.rebind(query), args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *JobsRepo) UpdateStatus(ctx context.Context, id int, status entity.JobStatus, errMsg string) error {
+	const op = "JobsRepo.UpdateStatus"
+
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("UPDATE detection_jobs SET status=?, error=?, update_at=? WHERE id=?"), status, errMsg, time.Now().In(time.UTC), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *JobsRepo) UpdateImage(ctx context.Context, img *entity.JobImage) error {
+	const op = "JobsRepo.UpdateImage"
+
+	if _, err := r.db.NamedExecContext(ctx, r.dialect.rebind("UPDATE detection_job_images SET status=:status, error=:error, detections_count=:detections_count WHERE id=:id"), img); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *JobsRepo) Get(ctx context.Context, id int) (*entity.Job, error) {
+	const op = "JobsRepo.Get"
+
+	job := new(entity.Job)
+	if err := r.db.GetContext(ctx, job, r.dialect.rebind("SELECT * FROM detection_jobs WHERE id=?"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError(err.Error()))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+func (r *JobsRepo) GetImages(ctx context.Context, jobId int) ([]entity.JobImage, error) {
+	const op = "JobsRepo.GetImages"
+
+	var images []entity.JobImage
+	if err := r.db.SelectContext(ctx, &images, r.dialect.rebind("SELECT * FROM detection_job_images WHERE job_id=? ORDER BY id"), jobId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return images, nil
+}
+
+func (r *JobsRepo) GetByGroup(ctx context.Context, groupId int) ([]entity.Job, error) {
+	const op = "JobsRepo.GetByGroup"
+
+	var jobs []entity.Job
+	if err := r.db.SelectContext(ctx, &jobs, r.dialect.rebind("SELECT * FROM detection_jobs WHERE group_id=? ORDER BY id"), groupId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return jobs, nil
+}
+
+func (r *JobsRepo) GetUnfinished(ctx context.Context) ([]entity.Job, error) {
+	const op = "JobsRepo.GetUnfinished"
+
+	var jobs []entity.Job
+	if err := r.db.SelectContext(ctx, &jobs, r.dialect.rebind("SELECT * FROM detection_jobs WHERE status IN (?, ?) ORDER BY id"), entity.JobQueued, entity.JobRunning); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return jobs, nil
+}
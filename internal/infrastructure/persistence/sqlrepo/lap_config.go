@@ -1,4 +1,4 @@
-package mysql
+package sqlrepo
 
 import (
 	"FairLAP/internal/domain/entity"
@@ -11,12 +11,14 @@ import (
 )
 
 type LapConfigRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dialect dialect
 }
 
 func NewLapConfigRepo(db *sqlx.DB) *LapConfigRepo {
 	return &LapConfigRepo{
-		db: db,
+		db:      db,
+		dialect: dialectOf(db),
 	}
 }
 
@@ -25,9 +27,9 @@ func (r *LapConfigRepo) SaveParameter(ctx context.Context, lapId string, params
 
 	query := `
 INSERT INTO lap_config (lap_id, class, value) VALUES (?, ?, ?)
-ON DUPLICATE KEY UPDATE value=?`
+` + r.dialect.upsert([]string{"lap_id", "class"}, "value")
 
-	if _, err := r.db.ExecContext(ctx, query, lapId, params.Class, params.Value, params.Value); err != nil {
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind(query), lapId, params.Class, params.Value); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -48,7 +50,7 @@ func (r *LapConfigRepo) DeleteParameters(ctx context.Context, lapId string, clas
 		args[i+1] = class
 	}
 
-	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind(query), args...); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -57,7 +59,7 @@ func (r *LapConfigRepo) DeleteParameters(ctx context.Context, lapId string, clas
 
 func (r *LapConfigRepo) UpdateParameter(ctx context.Context, lapId string, param entity.LapParameter) error {
 	const op = "LapConfigRepo.UpdateParameters"
-	if _, err := r.db.ExecContext(ctx, "UPDATE lap_config SET value=? WHERE lap_id=? AND class=?", param.Value, lapId, param.Class); err != nil {
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("UPDATE lap_config SET value=? WHERE lap_id=? AND class=?"), param.Value, lapId, param.Class); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
@@ -67,7 +69,7 @@ func (r *LapConfigRepo) GetLapParameters(ctx context.Context, lapId string) ([]e
 	const op = "LapConfigRepo.GetConfig"
 
 	var params []entity.LapParameter
-	if err := r.db.SelectContext(ctx, &params, "SELECT class, value FROM lap_config WHERE lap_id=?", lapId); err != nil {
+	if err := r.db.SelectContext(ctx, &params, r.dialect.rebind("SELECT class, value FROM lap_config WHERE lap_id=?"), lapId); err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
@@ -0,0 +1,95 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type LapsRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewLapsRepo(db *sqlx.DB) *LapsRepo {
+	return &LapsRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+func (r *LapsRepo) Save(ctx context.Context, lap *entity.Lap) error {
+	const op = "LapsRepo.Save"
+
+	query := `
+INSERT INTO laps (id, name, voltage_class, operator, region, route_length, create_at, update_at)
+VALUES (:id, :name, :voltage_class, :operator, :region, :route_length, :create_at, :update_at)`
+
+	if _, err := r.db.NamedExecContext(ctx, r.dialect.rebind(query), lap); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *LapsRepo) Update(ctx context.Context, lap *entity.Lap) error {
+	const op = "LapsRepo.Update"
+
+	query := `
+UPDATE laps SET name=:name, voltage_class=:voltage_class, operator=:operator, region=:region,
+route_length=:route_length, update_at=:update_at WHERE id=:id`
+
+	res, err := r.db.NamedExecContext(ctx, r.dialect.rebind(query), lap)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, failure.NewNotFoundError("lap not found"))
+	}
+
+	return nil
+}
+
+func (r *LapsRepo) Get(ctx context.Context, id string) (*entity.Lap, error) {
+	const op = "LapsRepo.Get"
+
+	var lap entity.Lap
+	if err := r.db.GetContext(ctx, &lap, r.dialect.rebind("SELECT * FROM laps WHERE id=?"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("lap not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &lap, nil
+}
+
+func (r *LapsRepo) GetAll(ctx context.Context) ([]entity.Lap, error) {
+	const op = "LapsRepo.GetAll"
+
+	var laps []entity.Lap
+	if err := r.db.SelectContext(ctx, &laps, r.dialect.rebind("SELECT * FROM laps ORDER BY id")); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return laps, nil
+}
+
+func (r *LapsRepo) Delete(ctx context.Context, id string) error {
+	const op = "LapsRepo.Delete"
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM laps WHERE id=?"), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
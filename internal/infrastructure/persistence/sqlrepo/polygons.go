@@ -0,0 +1,89 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"image"
+)
+
+type PolygonsRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewPolygonsRepo(db *sqlx.DB) *PolygonsRepo {
+	return &PolygonsRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+type polygonsRow struct {
+	entity.ImagePolygons
+	Points []byte `db:"points"`
+}
+
+func (r *PolygonsRepo) Save(ctx context.Context, polygons *entity.ImagePolygons) error {
+	const op = "PolygonsRepo.Save"
+
+	points := make([][][2]int, len(polygons.Polygons))
+	for i, polygon := range polygons.Polygons {
+		points[i] = make([][2]int, len(polygon))
+		for j, p := range polygon {
+			points[i][j] = [2]int{p.X, p.Y}
+		}
+	}
+
+	data, err := json.Marshal(points)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+INSERT INTO image_polygons (group_id, image_uid, width, height, points) VALUES (?, ?, ?, ?, ?)
+` + r.dialect.upsert([]string{"group_id", "image_uid"}, "width", "height", "points")
+
+	id, err := r.dialect.insert(ctx, r.db, query, polygons.GroupId, polygons.ImageUid, polygons.Width, polygons.Height, string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	polygons.Id = id
+
+	return nil
+}
+
+func (r *PolygonsRepo) Get(ctx context.Context, groupId int, imageUid uuid.UUID) (*entity.ImagePolygons, error) {
+	const op = "PolygonsRepo.Get"
+
+	var row polygonsRow
+	if err := r.db.GetContext(ctx, &row, r.dialect.rebind("SELECT * FROM image_polygons WHERE group_id=? AND image_uid=?"), groupId, imageUid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError(err.Error()))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var points [][][2]int
+	if err := json.Unmarshal(row.Points, &points); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	polygons := &row.ImagePolygons
+	polygons.Polygons = make([][]image.Point, len(points))
+	for i, polygon := range points {
+		polygons.Polygons[i] = make([]image.Point, len(polygon))
+		for j, p := range polygon {
+			polygons.Polygons[i][j] = image.Pt(p[0], p[1])
+		}
+	}
+
+	return polygons, nil
+}
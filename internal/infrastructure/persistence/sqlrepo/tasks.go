@@ -0,0 +1,139 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type TasksRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewTasksRepo(db *sqlx.DB) *TasksRepo {
+	return &TasksRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+type taskRow struct {
+	entity.Task
+	ParamsData string `db:"params"`
+	ResultData string `db:"result"`
+}
+
+func (row *taskRow) task() *entity.Task {
+	t := &row.Task
+	t.Params = []byte(row.ParamsData)
+	if row.ResultData != "" {
+		t.Result = []byte(row.ResultData)
+	}
+	return t
+}
+
+func (r *TasksRepo) Save(ctx context.Context, task *entity.Task) error {
+	const op = "TasksRepo.Save"
+
+	row := taskRow{Task: *task, ParamsData: string(task.Params), ResultData: string(task.Result)}
+
+	id, err := r.dialect.insertNamed(ctx, conn(ctx, r.db), "INSERT INTO tasks (kind, status, params, result, input, output, error, create_at, update_at) VALUES (:kind, :status, :params, :result, :input, :output, :error, :create_at, :update_at)", row)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	task.Id = id
+
+	return nil
+}
+
+func (r *TasksRepo) UpdateStatus(ctx context.Context, id int, status entity.JobStatus, errMsg string) error {
+	const op = "TasksRepo.UpdateStatus"
+
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("UPDATE tasks SET status=?, error=?, update_at=? WHERE id=?"), status, errMsg, time.Now().In(time.UTC), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Finish stores the status, result and output of a finished task.
+func (r *TasksRepo) Finish(ctx context.Context, task *entity.Task) error {
+	const op = "TasksRepo.Finish"
+
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("UPDATE tasks SET status=?, result=?, output=?, error=?, update_at=? WHERE id=?"),
+		task.Status, string(task.Result), task.Output, task.Error, task.UpdateAt, task.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *TasksRepo) Get(ctx context.Context, id int) (*entity.Task, error) {
+	const op = "TasksRepo.Get"
+
+	var row taskRow
+	if err := r.db.GetContext(ctx, &row, r.dialect.rebind("SELECT * FROM tasks WHERE id=?"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("task not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return row.task(), nil
+}
+
+func (r *TasksRepo) GetUnfinished(ctx context.Context) ([]entity.Task, error) {
+	const op = "TasksRepo.GetUnfinished"
+
+	tasks, err := r.selectTasks(ctx, "SELECT * FROM tasks WHERE status IN (?, ?) ORDER BY id", entity.JobQueued, entity.JobRunning)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+// GetFinishedBefore returns the tasks finished before t.
+func (r *TasksRepo) GetFinishedBefore(ctx context.Context, t time.Time) ([]entity.Task, error) {
+	const op = "TasksRepo.GetFinishedBefore"
+
+	tasks, err := r.selectTasks(ctx, "SELECT * FROM tasks WHERE status IN (?, ?) AND update_at<? ORDER BY id", entity.JobDone, entity.JobFailed, t)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+func (r *TasksRepo) Delete(ctx context.Context, id int) error {
+	const op = "TasksRepo.Delete"
+
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM tasks WHERE id=?"), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *TasksRepo) selectTasks(ctx context.Context, query string, args ...any) ([]entity.Task, error) {
+	var rows []taskRow
+	if err := r.db.SelectContext(ctx, &rows, r.dialect.rebind(query), args...); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	tasks := make([]entity.Task, len(rows))
+	for i := range rows {
+		tasks[i] = *rows[i].task()
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,185 @@
+package sqlrepo
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/failure"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TowersRepo struct {
+	db      *sqlx.DB
+	dialect dialect
+}
+
+func NewTowersRepo(db *sqlx.DB) *TowersRepo {
+	return &TowersRepo{
+		db:      db,
+		dialect: dialectOf(db),
+	}
+}
+
+// towerColumns leaves out location, it is generated from the coordinates
+// for spatial queries.
+const towerColumns = "id, lap_id, number, name, latitude, longitude, create_at"
+
+func (r *TowersRepo) SaveTower(ctx context.Context, tower *entity.Tower) error {
+	const op = "TowersRepo.SaveTower"
+
+	query := `
+INSERT INTO towers (lap_id, number, name, latitude, longitude, create_at)
+VALUES (:lap_id, :number, :name, :latitude, :longitude, :create_at)`
+
+	id, err := r.dialect.insertNamed(ctx, r.db, query, tower)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tower.Id = id
+
+	return nil
+}
+
+func (r *TowersRepo) UpdateTower(ctx context.Context, tower *entity.Tower) error {
+	const op = "TowersRepo.UpdateTower"
+
+	query := "UPDATE towers SET number=:number, name=:name, latitude=:latitude, longitude=:longitude WHERE id=:id"
+
+	if _, err := r.db.NamedExecContext(ctx, r.dialect.rebind(query), tower); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *TowersRepo) GetTower(ctx context.Context, id int) (*entity.Tower, error) {
+	const op = "TowersRepo.GetTower"
+
+	var tower entity.Tower
+	if err := r.db.GetContext(ctx, &tower, r.dialect.rebind("SELECT "+towerColumns+" FROM towers WHERE id=?"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("tower not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &tower, nil
+}
+
+func (r *TowersRepo) GetTowerByNumber(ctx context.Context, lapId string, number int) (*entity.Tower, error) {
+	const op = "TowersRepo.GetTowerByNumber"
+
+	var tower entity.Tower
+	if err := r.db.GetContext(ctx, &tower, r.dialect.rebind("SELECT "+towerColumns+" FROM towers WHERE lap_id=? AND number=?"), lapId, number); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("tower not found"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &tower, nil
+}
+
+func (r *TowersRepo) GetTowersByLap(ctx context.Context, lapId string) ([]entity.Tower, error) {
+	const op = "TowersRepo.GetTowersByLap"
+
+	var towers []entity.Tower
+	if err := r.db.SelectContext(ctx, &towers, r.dialect.rebind("SELECT "+towerColumns+" FROM towers WHERE lap_id=? ORDER BY number"), lapId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return towers, nil
+}
+
+func (r *TowersRepo) DeleteTower(ctx context.Context, id int) error {
+	const op = "TowersRepo.DeleteTower"
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM towers WHERE id=?"), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *TowersRepo) SaveSpan(ctx context.Context, span *entity.Span) error {
+	const op = "TowersRepo.SaveSpan"
+
+	query := `
+INSERT INTO spans (lap_id, from_tower_id, to_tower_id, length)
+VALUES (:lap_id, :from_tower_id, :to_tower_id, :length)`
+
+	id, err := r.dialect.insertNamed(ctx, r.db, query, span)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	span.Id = id
+
+	return nil
+}
+
+func (r *TowersRepo) GetSpansByLap(ctx context.Context, lapId string) ([]entity.Span, error) {
+	const op = "TowersRepo.GetSpansByLap"
+
+	var spans []entity.Span
+	if err := r.db.SelectContext(ctx, &spans, r.dialect.rebind("SELECT * FROM spans WHERE lap_id=? ORDER BY id"), lapId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return spans, nil
+}
+
+func (r *TowersRepo) DeleteSpan(ctx context.Context, id int) error {
+	const op = "TowersRepo.DeleteSpan"
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM spans WHERE id=?"), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *TowersRepo) LinkImage(ctx context.Context, link entity.ImageTower) error {
+	const op = "TowersRepo.LinkImage"
+
+	query := `
+INSERT INTO image_towers (group_id, image_uid, tower_id, source) VALUES (:group_id, :image_uid, :tower_id, :source)
+` + r.dialect.upsert([]string{"group_id", "image_uid"}, "tower_id", "source")
+
+	if _, err := r.db.NamedExecContext(ctx, r.dialect.rebind(query), link); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *TowersRepo) GetImageLinks(ctx context.Context, groupId int) ([]entity.ImageTower, error) {
+	const op = "TowersRepo.GetImageLinks"
+
+	var links []entity.ImageTower
+	if err := r.db.SelectContext(ctx, &links, r.dialect.rebind("SELECT group_id, image_uid, tower_id, source FROM image_towers WHERE group_id=?"), groupId); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return links, nil
+}
+
+func (r *TowersRepo) GetImageLink(ctx context.Context, groupId int, imageUid uuid.UUID) (*entity.ImageTower, error) {
+	const op = "TowersRepo.GetImageLink"
+
+	var link entity.ImageTower
+	if err := r.db.GetContext(ctx, &link, r.dialect.rebind("SELECT group_id, image_uid, tower_id, source FROM image_towers WHERE group_id=? AND image_uid=?"), groupId, imageUid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, failure.NewNotFoundError("image is not linked to a tower"))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &link, nil
+}
@@ -0,0 +1,56 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// UnitOfWork runs repository calls in one transaction. Repositories of the
+// package use the transaction when called with the context passed to the
+// Do callback.
+type UnitOfWork struct {
+	db *sqlx.DB
+}
+
+func NewUnitOfWork(db *sqlx.DB) *UnitOfWork {
+	return &UnitOfWork{
+		db: db,
+	}
+}
+
+// Do commits the transaction if fn succeeds and rolls it back otherwise.
+// Nested calls join the outer transaction.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "UnitOfWork.Do"
+
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// conn returns the transaction of the context if there is one, db otherwise.
+func conn(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
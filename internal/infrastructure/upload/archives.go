@@ -0,0 +1,141 @@
+// Package upload decodes uploaded images and the archives of batch uploads.
+package upload
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/batch"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"mime/multipart"
+	"os"
+	"strings"
+)
+
+// Archives opens spooled batches, a ZIP entry larger than maxFileSize bytes
+// uncompressed is rejected.
+type Archives struct {
+	maxFileSize int64
+}
+
+func NewArchives(maxFileSize int64) *Archives {
+	return &Archives{
+		maxFileSize: maxFileSize,
+	}
+}
+
+// Open reads a spooled batch, a ZIP archive or a multipart form of files,
+// contentType is the Content-Type of the upload request.
+func (a *Archives) Open(path, contentType string) (iter.Seq[batch.File], io.Closer, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid content type: %w", err)
+	}
+
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed":
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid zip archive: %w", err)
+		}
+		return zipFiles(&zr.Reader, a.maxFileSize), zr, nil
+	case "multipart/form-data":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return multipartFiles(multipart.NewReader(f, params["boundary"])), f, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported batch type: %s", mediaType)
+	}
+}
+
+// zipFiles yields the entries of the archive. The size in the entry header
+// is checked before decoding and the entry is read through a limit as well,
+// so an archive lying about its sizes can't inflate past maxFileSize.
+func zipFiles(zr *zip.Reader, maxFileSize int64) iter.Seq[batch.File] {
+	return func(yield func(batch.File) bool) {
+		for _, zf := range zr.File {
+			if zf.FileInfo().IsDir() || isHiddenFile(zf.Name) {
+				continue
+			}
+
+			file := batch.File{
+				Name: zf.Name,
+				Decode: func() (entity.ImageFile, *entity.ImageMeta, error) {
+					if zf.UncompressedSize64 > uint64(maxFileSize) {
+						return entity.ImageFile{}, nil, fmt.Errorf("file exceeds %d MB", maxFileSize>>20)
+					}
+
+					rc, err := zf.Open()
+					if err != nil {
+						return entity.ImageFile{}, nil, err
+					}
+					defer rc.Close()
+
+					return Decode(io.LimitReader(rc, maxFileSize), MimeByExt(zf.Name))
+				},
+			}
+
+			if !yield(file) {
+				return
+			}
+		}
+	}
+}
+
+// multipartFiles yields file parts in order. A part has to be decoded before
+// the next one is requested, which batch.Service guarantees.
+func multipartFiles(mr *multipart.Reader) iter.Seq[batch.File] {
+	return func(yield func(batch.File) bool) {
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(batch.File{
+						Name: "multipart",
+						Decode: func() (entity.ImageFile, *entity.ImageMeta, error) {
+							return entity.ImageFile{}, nil, err
+						},
+					})
+				}
+				return
+			}
+
+			if part.FileName() == "" {
+				part.Close()
+				continue
+			}
+
+			contentType := part.Header.Get("Content-Type")
+			if !strings.HasPrefix(contentType, "image/") {
+				contentType = MimeByExt(part.FileName())
+			}
+
+			file := batch.File{
+				Name: part.FileName(),
+				Decode: func() (entity.ImageFile, *entity.ImageMeta, error) {
+					return Decode(part, contentType)
+				},
+			}
+
+			ok := yield(file)
+			part.Close()
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func isHiddenFile(name string) bool {
+	for _, p := range strings.Split(name, "/") {
+		if strings.HasPrefix(p, ".") || p == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encodePng(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2))))
+	return buf.Bytes()
+}
+
+func writeFile(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "input")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func TestOpenZip(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"12/IMG_0001.png", "__MACOSX/12/._IMG_0001.png", "notes.txt"} {
+		f, err := zw.Create(name)
+		rq.NoError(err)
+		_, err = f.Write(encodePng(t))
+		rq.NoError(err)
+	}
+	rq.NoError(zw.Close())
+
+	files, closer, err := NewArchives(1<<20).Open(writeFile(t, buf.Bytes()), "application/zip")
+	rq.NoError(err)
+	defer closer.Close()
+
+	var names []string
+	var errs []error
+	for file := range files {
+		names = append(names, file.Name)
+		decoded, _, err := file.Decode()
+		if err == nil {
+			rq.Equal(4, decoded.Image.Bounds().Dx())
+		}
+		errs = append(errs, err)
+	}
+
+	rq.Equal([]string{"12/IMG_0001.png", "notes.txt"}, names)
+	rq.NoError(errs[0])
+	rq.Error(errs[1])
+}
+
+func TestOpenZipFileSize(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"small.png", "large.png"} {
+		f, err := zw.Create(name)
+		rq.NoError(err)
+		data := encodePng(t)
+		if name == "large.png" {
+			// zeros compress well, the entry is large only once inflated
+			data = append(data, make([]byte, 2<<20)...)
+		}
+		_, err = f.Write(data)
+		rq.NoError(err)
+	}
+	rq.NoError(zw.Close())
+
+	files, closer, err := NewArchives(1<<20).Open(writeFile(t, buf.Bytes()), "application/zip")
+	rq.NoError(err)
+	defer closer.Close()
+
+	var errs []error
+	for file := range files {
+		_, _, err := file.Decode()
+		errs = append(errs, err)
+	}
+
+	rq.Len(errs, 2)
+	rq.NoError(errs[0])
+	rq.ErrorContains(errs[1], "file exceeds 1 MB")
+}
+
+func TestOpenMultipart(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	rq.NoError(mw.WriteField("comment", "not a file"))
+	f, err := mw.CreateFormFile("files", "tower_3.png")
+	rq.NoError(err)
+	_, err = f.Write(encodePng(t))
+	rq.NoError(err)
+	rq.NoError(mw.Close())
+
+	files, closer, err := NewArchives(1<<20).Open(writeFile(t, buf.Bytes()), mw.FormDataContentType())
+	rq.NoError(err)
+	defer closer.Close()
+
+	var names []string
+	for file := range files {
+		names = append(names, file.Name)
+		_, _, err := file.Decode()
+		rq.NoError(err)
+	}
+
+	rq.Equal([]string{"tower_3.png"}, names)
+
+	_, _, err = NewArchives(1<<20).Open(writeFile(t, buf.Bytes()), "application/x-tar")
+	rq.Error(err)
+}
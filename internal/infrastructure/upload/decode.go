@@ -0,0 +1,131 @@
+package upload
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/pkg/exif"
+	"bytes"
+	"fmt"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Decode reads an image of the mime type with its metadata. The image is
+// rotated by the EXIF orientation, the original bytes are kept as sent.
+func Decode(r io.Reader, mime string) (entity.ImageFile, *entity.ImageMeta, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return entity.ImageFile{}, nil, err
+	}
+
+	var img image.Image
+
+	switch mime {
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/jpeg", "image/jpg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	case "image/webp":
+		img, err = webp.Decode(bytes.NewReader(data))
+	case "image/bmp":
+		img, err = bmp.Decode(bytes.NewReader(data))
+	case "image/tiff":
+		img, err = tiff.Decode(bytes.NewReader(data))
+	default:
+		return entity.ImageFile{}, nil, fmt.Errorf("unknown image type: %s", mime)
+	}
+	if err != nil {
+		return entity.ImageFile{}, nil, err
+	}
+
+	e, err := exif.Decode(data)
+	if err == nil {
+		img = exif.ApplyOrientation(img, e.Orientation())
+	}
+
+	return entity.ImageFile{Image: img, Original: data}, imageMeta(e, data), nil
+}
+
+// imageMeta collects the photo metadata from EXIF and DJI XMP, nil if the
+// photo has neither. e is nil if the photo has no EXIF.
+func imageMeta(e *exif.Exif, data []byte) *entity.ImageMeta {
+	xmp, xmpErr := exif.DecodeXMP(data)
+	if e == nil && xmpErr != nil {
+		return nil
+	}
+
+	meta := new(entity.ImageMeta)
+
+	if e != nil {
+		meta.CameraMake = e.Make()
+		meta.CameraModel = e.Model()
+
+		if t, ok := e.DateTimeOriginal(); ok {
+			t = t.In(time.UTC)
+			meta.CaptureAt = &t
+		}
+
+		if gps, ok := e.GPS(); ok {
+			meta.Latitude = &gps.Latitude
+			meta.Longitude = &gps.Longitude
+			meta.Altitude = gps.Altitude
+		}
+	}
+
+	xmpValue := func(name string) *float64 {
+		if v, ok := xmp.Float(name); ok {
+			return &v
+		}
+		return nil
+	}
+
+	meta.RelativeAltitude = xmpValue("RelativeAltitude")
+	meta.GimbalPitch = xmpValue("GimbalPitchDegree")
+	meta.GimbalYaw = xmpValue("GimbalYawDegree")
+	meta.GimbalRoll = xmpValue("GimbalRollDegree")
+
+	// DJI writes the position to XMP as well, it is used when EXIF GPS is stripped
+	if !meta.HasLocation() {
+		meta.Latitude = xmpValue("GpsLatitude")
+		meta.Longitude = xmpValue("GpsLongitude")
+		if !meta.HasLocation() || !exif.ValidCoordinates(*meta.Latitude, *meta.Longitude) {
+			meta.Latitude, meta.Longitude = nil, nil
+		}
+	}
+	if meta.Altitude == nil {
+		meta.Altitude = xmpValue("AbsoluteAltitude")
+	}
+
+	return meta
+}
+
+// MimeByExt returns the image mime type by the file extension, empty for
+// unknown ones.
+func MimeByExt(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".bmp":
+		return "image/bmp"
+	case ".tif", ".tiff":
+		return "image/tiff"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"FairLAP/internal/domain/service/annotations"
+	"FairLAP/pkg/failure"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type AnnotationsServer struct {
+	annotations *annotations.Service
+}
+
+func NewAnnotationsServer(annotations *annotations.Service) *AnnotationsServer {
+	return &AnnotationsServer{
+		annotations: annotations,
+	}
+}
+
+func (s *AnnotationsServer) GetClasses(w http.ResponseWriter, r *http.Request) {
+	writeJson(r.Context(), w, s.annotations.Classes(), http.StatusOK)
+}
+
+type createAnnotationRequest struct {
+	GroupId  int             `json:"group_id"`
+	ImageUid uuid.UUID       `json:"image_uid"`
+	Class    string          `json:"class"`
+	Box      annotations.Box `json:"box"`
+	Editor   string          `json:"editor"`
+}
+
+func (s *AnnotationsServer) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req createAnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid annotation"))
+		return
+	}
+
+	rect, err := s.annotations.Create(ctx, req.GroupId, req.ImageUid, req.Class, req.Box, req.Editor)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, rect, http.StatusOK)
+}
+
+type updateAnnotationRequest struct {
+	DetectionId int `json:"detection_id"`
+	annotations.Edit
+}
+
+func (s *AnnotationsServer) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req updateAnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid annotation"))
+		return
+	}
+
+	rect, err := s.annotations.Update(ctx, req.DetectionId, req.Edit)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, rect, http.StatusOK)
+}
+
+func (s *AnnotationsServer) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("detection_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid detection_id"))
+		return
+	}
+
+	if err := s.annotations.Delete(ctx, id, r.FormValue("editor")); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+}
+
+func (s *AnnotationsServer) History(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groupId, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+
+	imageUid, err := uuid.Parse(r.FormValue("image_uid"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+
+	changes, err := s.annotations.History(ctx, groupId, imageUid)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, changes, http.StatusOK)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"FairLAP/internal/domain/service/batch"
+	"FairLAP/pkg/failure"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+type BatchServer struct {
+	batch         *batch.Service
+	maxUploadSize int64
+}
+
+func NewBatchServer(batch *batch.Service, maxUploadSize int64) *BatchServer {
+	return &BatchServer{
+		batch:         batch,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// Upload accepts a ZIP archive or a multipart form of photos. The body is
+// spooled to disk and decoded by a background task, the answer is the task.
+func (s *BatchServer) Upload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := batch.Request{
+		LapId:       r.URL.Query().Get("lap_id"),
+		ContentType: r.Header.Get("Content-Type"),
+	}
+	if req.LapId == "" {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid lap_id"))
+		return
+	}
+
+	var err error
+	if req.TowerId, err = parseTowerId(r); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	if req.Thresholds, err = parseThresholds(r.URL.Query()); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.ContentType)
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid Content-Type"))
+		return
+	}
+
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed", "multipart/form-data":
+	default:
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("unsupported batch type: "+mediaType))
+		return
+	}
+
+	defer r.Body.Close()
+
+	task, err := s.batch.Upload(ctx, req, http.MaxBytesReader(w, r.Body, s.maxUploadSize))
+	if err != nil {
+		writeAndLogErr(ctx, w, uploadErr(err))
+		return
+	}
+
+	writeJson(ctx, w, task, http.StatusAccepted)
+}
+
+// uploadErr reports a body over the upload limit as a bad request.
+func uploadErr(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return failure.NewInvalidRequestError(fmt.Sprintf("request body exceeds %d MB", tooLarge.Limit>>20))
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"FairLAP/pkg/cache"
+	"net/http"
+)
+
+type CacheStats interface {
+	Stats() cache.Stats
+}
+
+type CacheServer struct {
+	cache CacheStats
+}
+
+func NewCacheServer(cache CacheStats) *CacheServer {
+	return &CacheServer{
+		cache: cache,
+	}
+}
+
+func (s *CacheServer) GetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	writeJson(ctx, w, s.cache.Stats(), http.StatusOK)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/datasetexport"
+	"FairLAP/internal/domain/service/datasetimport"
+	"FairLAP/pkg/dataset"
+	"FairLAP/pkg/failure"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DatasetServer struct {
+	export        *datasetexport.Service
+	imp           *datasetimport.Service
+	maxUploadSize int64
+}
+
+func NewDatasetServer(export *datasetexport.Service, imp *datasetimport.Service, maxUploadSize int64) *DatasetServer {
+	return &DatasetServer{
+		export:        export,
+		imp:           imp,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// Export queues the export of the training dataset as a ZIP archive, the
+// archive is downloaded from the finished task. Dates are YYYY-MM-DD or
+// RFC 3339, a date "to" includes the whole day.
+func (s *DatasetServer) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := datasetexport.Request{
+		Format: dataset.FormatYOLO,
+		Filter: datasetexport.Filter{
+			LapId: r.FormValue("lap_id"),
+		},
+	}
+	if v := r.FormValue("format"); v != "" {
+		req.Format = dataset.Format(v)
+	}
+
+	var err error
+	if req.From, err = parseDate(r.FormValue("from"), false); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid from"))
+		return
+	}
+	if req.To, err = parseDate(r.FormValue("to"), true); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid to"))
+		return
+	}
+	if v := r.FormValue("val_ratio"); v != "" {
+		if req.ValRatio, err = strconv.ParseFloat(v, 64); err != nil {
+			writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid val_ratio"))
+			return
+		}
+	}
+	if v := r.FormValue("review"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			req.Review = append(req.Review, entity.ReviewStatus(strings.TrimSpace(status)))
+		}
+	}
+
+	task, err := s.export.Export(ctx, req)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, task, http.StatusAccepted)
+}
+
+// Import queues the import of a labeled YOLO or COCO dataset sent as a ZIP
+// archive to a new group of the lap, the summary is the result of the task.
+// create_at dates the group, YYYY-MM-DD or RFC 3339, class_map renames
+// dataset classes, e.g. bird_nest:nest,glass:insulator.
+func (s *DatasetServer) Import(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts := datasetimport.Options{
+		LapId:    r.URL.Query().Get("lap_id"),
+		Format:   dataset.Format(r.URL.Query().Get("format")),
+		Reviewer: r.URL.Query().Get("reviewer"),
+	}
+	if opts.LapId == "" {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid lap_id"))
+		return
+	}
+	if opts.Format == "" {
+		opts.Format = dataset.FormatYOLO
+	}
+
+	var err error
+	if opts.CreateAt, err = parseDate(r.URL.Query().Get("create_at"), false); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid create_at"))
+		return
+	}
+	if opts.ClassMap, err = datasetimport.ParseClassMap(r.URL.Query().Get("class_map")); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError(err.Error()))
+		return
+	}
+
+	defer r.Body.Close()
+
+	task, err := s.imp.Upload(ctx, opts, http.MaxBytesReader(w, r.Body, s.maxUploadSize))
+	if err != nil {
+		writeAndLogErr(ctx, w, uploadErr(err))
+		return
+	}
+
+	writeJson(ctx, w, task, http.StatusAccepted)
+}
+
+func parseDate(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
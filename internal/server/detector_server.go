@@ -1,27 +1,52 @@
 package server
 
 import (
-	"FairLAP/internal/domain/service/detector"
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/jobs"
+	"FairLAP/internal/domain/service/metrics"
+	"FairLAP/internal/domain/service/towers"
+	"FairLAP/internal/infrastructure/upload"
+	"FairLAP/pkg/contextx"
 	"FairLAP/pkg/failure"
-	"fmt"
-	"golang.org/x/image/bmp"
-	"golang.org/x/image/tiff"
-	"golang.org/x/image/webp"
-	"image"
-	"image/gif"
-	"image/jpeg"
-	"image/png"
-	"io"
+	"FairLAP/pkg/logx"
+	"context"
+	"github.com/google/uuid"
+	"log/slog"
 	"net/http"
 	"strconv"
 )
 
+type ImageMetaRepo interface {
+	Save(ctx context.Context, meta *entity.ImageMeta) error
+}
+
 type DetectorServer struct {
-	detector *detector.Service
+	jobs    *jobs.Service
+	metrics *metrics.Service
+	towers  *towers.Service
+	meta    ImageMetaRepo
+}
+
+func NewDetectorServer(jobs *jobs.Service, metrics *metrics.Service, towers *towers.Service, meta ImageMetaRepo) *DetectorServer {
+	return &DetectorServer{
+		jobs:    jobs,
+		metrics: metrics,
+		towers:  towers,
+		meta:    meta,
+	}
 }
 
-func NewDetectorServer(detector *detector.Service) *DetectorServer {
-	return &DetectorServer{detector: detector}
+// DetectResponse is the detection of an uploaded photo. MetaError and
+// TowerError are set when the photo is queued but its metadata could not be
+// saved or it could not be linked to a tower.
+type DetectResponse struct {
+	JobId      int                         `json:"job_id"`
+	ImageUid   uuid.UUID                   `json:"image_uid"`
+	Status     entity.JobStatus            `json:"status"`
+	Error      string                      `json:"error,omitempty"`
+	MetaError  string                      `json:"meta_error,omitempty"`
+	TowerError string                      `json:"tower_error,omitempty"`
+	Detections []metrics.ImageDetectionBox `json:"detections"`
 }
 
 func (s *DetectorServer) Detect(w http.ResponseWriter, r *http.Request) {
@@ -33,35 +58,98 @@ func (s *DetectorServer) Detect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	towerId, err := parseTowerId(r)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+	if towerId != 0 {
+		if err := s.towers.CheckGroupTower(ctx, groupId, towerId); err != nil {
+			writeAndLogErr(ctx, w, err)
+			return
+		}
+	}
+
+	thresholds, err := parseThresholds(r.URL.Query())
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
 	defer r.Body.Close()
 
-	img, err := decodeImg(r.Body, r.Header.Get("Content-Type"))
+	file, meta, err := upload.Decode(r.Body, r.Header.Get("Content-Type"))
 	if err != nil {
 		writeAndLogErr(ctx, w, failure.NewInvalidRequestError(err.Error()))
 		return
 	}
 
-	if err := s.detector.Detect(ctx, groupId, img); err != nil {
+	job, err := s.jobs.Enqueue(ctx, groupId, []entity.ImageFile{file}, thresholds)
+	if err != nil {
 		writeAndLogErr(ctx, w, err)
 		return
 	}
-}
 
-func decodeImg(r io.Reader, mime string) (image.Image, error) {
-	switch mime {
-	case "image/png":
-		return png.Decode(r)
-	case "image/jpeg", "image/jpg":
-		return jpeg.Decode(r)
-	case "image/gif":
-		return gif.Decode(r)
-	case "image/webp":
-		return webp.Decode(r)
-	case "image/bmp":
-		return bmp.Decode(r)
-	case "image/tiff":
-		return tiff.Decode(r)
-	default:
-		return nil, fmt.Errorf("unknown image type: %service", mime)
+	imageUid := job.Images[0].ImageUid
+	l := contextx.GetLoggerOrDefault(ctx)
+
+	// the image is queued already, so these errors are reported in the
+	// response instead of failing the request
+	var metaErr, towerErr error
+
+	if meta != nil {
+		meta.GroupId = groupId
+		meta.ImageUid = imageUid
+		if metaErr = s.meta.Save(ctx, meta); metaErr != nil {
+			l.ErrorContext(ctx, "save image meta", slog.String("image_uid", imageUid.String()), logx.Error(metaErr))
+		}
 	}
+
+	if towerId != 0 {
+		towerErr = s.towers.LinkImage(ctx, groupId, imageUid, towerId, entity.TowerLinkExplicit)
+	} else if meta.HasLocation() {
+		towerErr = s.towers.LinkNearest(ctx, groupId, imageUid, *meta.Latitude, *meta.Longitude)
+	}
+	if towerErr != nil {
+		l.ErrorContext(ctx, "link image to tower", slog.String("image_uid", imageUid.String()), logx.Error(towerErr))
+	}
+
+	if wait, _ := strconv.ParseBool(r.FormValue("wait")); wait {
+		job, err = s.jobs.Wait(ctx, job.Id)
+		if err != nil {
+			writeAndLogErr(ctx, w, err)
+			return
+		}
+	}
+
+	jobImage := job.Images[0]
+
+	resp := DetectResponse{
+		JobId:      job.Id,
+		ImageUid:   jobImage.ImageUid,
+		Status:     jobImage.Status,
+		Error:      jobImage.Error,
+		Detections: []metrics.ImageDetectionBox{},
+	}
+	if metaErr != nil {
+		resp.MetaError = metaErr.Error()
+	}
+	if towerErr != nil {
+		resp.TowerError = towerErr.Error()
+	}
+
+	if jobImage.Status != entity.JobDone {
+		writeJson(ctx, w, resp, http.StatusAccepted)
+		return
+	}
+
+	metric, err := s.metrics.GetImageMetric(ctx, groupId, jobImage.ImageUid)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	resp.Detections = metric.Detections
+
+	writeJson(ctx, w, resp, http.StatusOK)
 }
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"FairLAP/internal/domain/service/modeleval"
+	"FairLAP/pkg/failure"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type EvaluationServer struct {
+	eval *modeleval.Service
+}
+
+func NewEvaluationServer(eval *modeleval.Service) *EvaluationServer {
+	return &EvaluationServer{
+		eval: eval,
+	}
+}
+
+// Run starts an evaluation of the served model, it takes a model run per
+// reviewed image. The answer is the task to poll at /tasks/get.
+func (s *EvaluationServer) Run(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req modeleval.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid evaluation request"))
+		return
+	}
+
+	task, err := s.eval.Start(ctx, req)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, task, http.StatusAccepted)
+}
+
+func (s *EvaluationServer) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	e, err := s.eval.Get(ctx, id)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, e, http.StatusOK)
+}
+
+func (s *EvaluationServer) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	evaluations, err := s.eval.List(ctx, r.FormValue("model"))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, evaluations, http.StatusOK)
+}
+
+// Compare takes the run ids as ids=1,2,3.
+func (s *EvaluationServer) Compare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var ids []int
+	for _, v := range strings.Split(r.FormValue("ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid ids"))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	comparison, err := s.eval.Compare(ctx, ids)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, comparison, http.StatusOK)
+}
+
+func (s *EvaluationServer) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	if err := s.eval.Delete(ctx, id); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"FairLAP/internal/domain/service/geoexport"
+	"FairLAP/pkg/failure"
+	"FairLAP/pkg/geo"
+	"fmt"
+	"github.com/gorilla/mux"
+	"html"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ExportServer struct {
+	export *geoexport.Service
+}
+
+func NewExportServer(export *geoexport.Service) *ExportServer {
+	return &ExportServer{
+		export: export,
+	}
+}
+
+func (s *ExportServer) GroupGeoJSON(w http.ResponseWriter, r *http.Request) {
+	s.group(w, r, writeGeoJSON)
+}
+
+func (s *ExportServer) GroupKML(w http.ResponseWriter, r *http.Request) {
+	s.group(w, r, writeKML)
+}
+
+func (s *ExportServer) LapGeoJSON(w http.ResponseWriter, r *http.Request) {
+	s.lap(w, r, writeGeoJSON)
+}
+
+func (s *ExportServer) LapKML(w http.ResponseWriter, r *http.Request) {
+	s.lap(w, r, writeKML)
+}
+
+type featuresWriter func(w http.ResponseWriter, name string, features []geo.Feature) error
+
+func (s *ExportServer) group(w http.ResponseWriter, r *http.Request, write featuresWriter) {
+	ctx := r.Context()
+
+	groupId, err := strconv.Atoi(mux.Vars(r)["group_id"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+
+	features, err := s.export.GroupFeatures(ctx, groupId, problemCount(r))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	if err := write(w, fmt.Sprintf("group %d", groupId), geoFeatures(baseUrl(r), features)); err != nil {
+		writeAndLogErr(ctx, w, err)
+	}
+}
+
+func (s *ExportServer) lap(w http.ResponseWriter, r *http.Request, write featuresWriter) {
+	ctx := r.Context()
+
+	lapId := mux.Vars(r)["lap_id"]
+
+	features, err := s.export.LapFeatures(ctx, lapId, problemCount(r))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	if err := write(w, "lap "+lapId, geoFeatures(baseUrl(r), features)); err != nil {
+		writeAndLogErr(ctx, w, err)
+	}
+}
+
+func writeGeoJSON(w http.ResponseWriter, _ string, features []geo.Feature) error {
+	w.Header().Set("Content-Type", "application/geo+json")
+	return geo.WriteGeoJSON(w, features)
+}
+
+func writeKML(w http.ResponseWriter, name string, features []geo.Feature) error {
+	w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+	return geo.WriteKML(w, name, features)
+}
+
+// baseUrl is the scheme and host the request was sent to, so exported links
+// open in GIS apps outside of the service.
+func baseUrl(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+func geoFeatures(baseUrl string, features []geoexport.ImageFeature) []geo.Feature {
+	result := make([]geo.Feature, len(features))
+
+	for i, f := range features {
+		imageUrl := fmt.Sprintf("%s/image/%d/%s.jpeg", baseUrl, f.GroupId, f.ImageUid)
+
+		classes := make([]string, 0, len(f.Classes))
+		for class, count := range f.Classes {
+			classes = append(classes, fmt.Sprintf("%s: %d", class, count))
+		}
+		slices.Sort(classes)
+
+		properties := map[string]any{
+			"group_id":         f.GroupId,
+			"image_uid":        f.ImageUid.String(),
+			"image_url":        imageUrl,
+			"classes":          f.Classes,
+			"max_damage_level": f.MaxDamageLevel,
+			"detection_ids":    f.DetectionIds,
+			"description":      fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(imageUrl), html.EscapeString(strings.Join(classes, ", "))),
+		}
+		if f.CaptureAt != nil {
+			properties["capture_at"] = f.CaptureAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+
+		result[i] = geo.Feature{
+			Id:   f.ImageUid.String(),
+			Name: strings.Join(classes, ", "),
+			Point: geo.Point{
+				Latitude:  f.Latitude,
+				Longitude: f.Longitude,
+				Altitude:  f.Altitude,
+			},
+			Properties: properties,
+		}
+	}
+
+	return result
+}
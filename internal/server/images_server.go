@@ -2,20 +2,46 @@ package server
 
 import (
 	"FairLAP/internal/infrastructure/persistence/images"
+	"FairLAP/pkg/contextx"
 	"FairLAP/pkg/failure"
+	"FairLAP/pkg/logx"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+type BytesCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+type ImageStorage interface {
+	Open(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+	OpenOriginal(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+	OpenThumb(ctx context.Context, groupId int, uid uuid.UUID, size int) (io.ReadCloser, error)
+	OpenMask(ctx context.Context, groupId int, uid uuid.UUID) (io.ReadCloser, error)
+	SaveMask(ctx context.Context, groupId int, uid uuid.UUID, mask io.Reader) error
+	URL(ctx context.Context, groupId int, uid uuid.UUID) (string, time.Time, error)
+}
+
 type ImagesServer struct {
-	images *images.Images
+	images ImageStorage
+	cache  BytesCache
 }
 
-func NewImagesServer(images *images.Images) *ImagesServer {
-	return &ImagesServer{images: images}
+func NewImagesServer(images ImageStorage, cache BytesCache) *ImagesServer {
+	return &ImagesServer{
+		images: images,
+		cache:  cache,
+	}
 }
 
 func (s *ImagesServer) HandleImage(w http.ResponseWriter, r *http.Request) {
@@ -34,16 +60,88 @@ func (s *ImagesServer) HandleImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	f, err := s.images.Open(groupId, imageUid)
+	data, err := s.read(fmt.Sprintf("image:%d:%s", groupId, imageUid), func() (io.ReadCloser, error) {
+		return s.images.Open(ctx, groupId, imageUid)
+	})
 	if err != nil {
 		writeAndLogErr(ctx, w, err)
 		return
 	}
-	defer f.Close()
 
 	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(data)
+}
+
+// HandleThumb returns a thumbnail of the image, the size query parameter
+// selects one of the configured sizes.
+func (s *ImagesServer) HandleThumb(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	groupId, err := strconv.Atoi(vars["group_id"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+	imageUid, err := uuid.Parse(vars["image_uid"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+
+	var size int
+	if v := r.URL.Query().Get("size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil {
+			writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid size"))
+			return
+		}
+	}
+
+	data, err := s.read(fmt.Sprintf("image_thumb:%d:%s:%d", groupId, imageUid, size), func() (io.ReadCloser, error) {
+		return s.images.OpenThumb(ctx, groupId, imageUid, size)
+	})
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(data)
+}
+
+// HandleOriginal streams the uploaded file as is. Originals may be large, so
+// they bypass the cache.
+func (s *ImagesServer) HandleOriginal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	groupId, err := strconv.Atoi(vars["group_id"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+	imageUid, err := uuid.Parse(vars["image_uid"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+
+	f, err := s.images.OpenOriginal(ctx, groupId, imageUid)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 512)
+	head, _ := br.Peek(512)
 
-	io.Copy(w, f)
+	w.Header().Set("Content-Type", images.ContentType(head))
+	if _, err := io.Copy(w, br); err != nil {
+		contextx.GetLoggerOrDefault(ctx).ErrorContext(ctx, "stream original failed", logx.Error(err))
+	}
 }
 
 func (s *ImagesServer) HandleMask(w http.ResponseWriter, r *http.Request) {
@@ -62,21 +160,82 @@ func (s *ImagesServer) HandleMask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	key := fmt.Sprintf("image_mask:%d:%s", groupId, imageUid)
+
 	if r.Method == http.MethodPost {
-		if err := s.images.SaveMask(groupId, imageUid, r.Body); err != nil {
+		// invalidated after saving, so a concurrent read can not cache the
+		// old mask again
+		err := s.images.SaveMask(ctx, groupId, imageUid, r.Body)
+		s.cache.Delete(key)
+		if err != nil {
 			writeAndLogErr(ctx, w, err)
 		}
 	} else {
-		f, err := s.images.OpenMask(groupId, imageUid)
+		data, err := s.read(key, func() (io.ReadCloser, error) {
+			return s.images.OpenMask(ctx, groupId, imageUid)
+		})
 		if err != nil {
 			writeAndLogErr(ctx, w, err)
 			return
 		}
-		defer f.Close()
 
 		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	}
+
+}
+
+type imageUrl struct {
+	Url      string    `json:"url"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// HandleImageUrl returns a temporary direct link to the image, so clients
+// download it from the storage instead of through the service.
+func (s *ImagesServer) HandleImageUrl(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
 
-		io.Copy(w, f)
+	groupId, err := strconv.Atoi(vars["group_id"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+	imageUid, err := uuid.Parse(vars["image_uid"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+
+	url, expireAt, err := s.images.URL(ctx, groupId, imageUid)
+	if err != nil {
+		if errors.Is(err, images.ErrNoURL) {
+			err = failure.NewInvalidRequestError(err.Error())
+		}
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, imageUrl{Url: url, ExpireAt: expireAt}, http.StatusOK)
+}
+
+func (s *ImagesServer) read(key string, open func() (io.ReadCloser, error)) ([]byte, error) {
+	if data, ok := s.cache.Get(key); ok {
+		return data, nil
+	}
+
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
 	}
 
+	s.cache.Set(key, data)
+	return data, nil
 }
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"FairLAP/internal/domain/service/jobs"
+	"FairLAP/pkg/failure"
+	"net/http"
+	"strconv"
+)
+
+type JobsServer struct {
+	jobs *jobs.Service
+}
+
+func NewJobsServer(jobs *jobs.Service) *JobsServer {
+	return &JobsServer{
+		jobs: jobs,
+	}
+}
+
+func (s *JobsServer) GetJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	job, err := s.jobs.Get(ctx, id)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, job, http.StatusOK)
+}
+
+func (s *JobsServer) GetByGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groupId, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+
+	jobs, err := s.jobs.GetByGroup(ctx, groupId)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, jobs, http.StatusOK)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/laps"
+	"FairLAP/pkg/failure"
+	"encoding/json"
+	"net/http"
+)
+
+type LapsServer struct {
+	laps *laps.Service
+}
+
+func NewLapsServer(laps *laps.Service) *LapsServer {
+	return &LapsServer{
+		laps: laps,
+	}
+}
+
+func (s *LapsServer) CreateLap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var lap entity.Lap
+	if err := json.NewDecoder(r.Body).Decode(&lap); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid lap"))
+		return
+	}
+
+	if err := s.laps.Create(ctx, &lap); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, lap, http.StatusOK)
+}
+
+func (s *LapsServer) UpdateLap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var lap entity.Lap
+	if err := json.NewDecoder(r.Body).Decode(&lap); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid lap"))
+		return
+	}
+
+	if err := s.laps.Update(ctx, &lap); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, lap, http.StatusOK)
+}
+
+func (s *LapsServer) GetLap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	lap, err := s.laps.Get(ctx, r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, lap, http.StatusOK)
+}
+
+func (s *LapsServer) GetLaps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	l, err := s.laps.GetAll(ctx)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, l, http.StatusOK)
+}
+
+func (s *LapsServer) DeleteLap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := s.laps.Delete(ctx, r.FormValue("id")); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+}
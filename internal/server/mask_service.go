@@ -5,7 +5,6 @@ import (
 	"FairLAP/pkg/failure"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"image/png"
 	"net/http"
 	"strconv"
 )
@@ -38,11 +37,7 @@ func (s *MaskServer) GetRect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "image/png")
-
-	if err := png.Encode(w, rect); err != nil {
-		writeAndLogErr(ctx, w, err)
-		return
-	}
+	w.Write(rect)
 }
 
 func (s *MaskServer) GetPolygon(w http.ResponseWriter, r *http.Request) {
@@ -68,9 +63,54 @@ func (s *MaskServer) GetPolygon(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "image/png")
+	w.Write(polygon)
+}
+
+type PolygonsResponse struct {
+	GroupId  int        `json:"group_id"`
+	ImageUid uuid.UUID  `json:"image_uid"`
+	Width    int        `json:"width"`
+	Height   int        `json:"height"`
+	Polygons [][][2]int `json:"polygons"`
+}
 
-	if err := png.Encode(w, polygon); err != nil {
+func (s *MaskServer) GetPolygonJson(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	groupId, err := strconv.Atoi(vars["group_id"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+
+	imageUid, err := uuid.Parse(vars["image_uid"])
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+
+	polygons, err := s.service.GetPolygons(ctx, groupId, imageUid)
+	if err != nil {
 		writeAndLogErr(ctx, w, err)
 		return
 	}
+
+	resp := PolygonsResponse{
+		GroupId:  polygons.GroupId,
+		ImageUid: polygons.ImageUid,
+		Width:    polygons.Width,
+		Height:   polygons.Height,
+		Polygons: make([][][2]int, len(polygons.Polygons)),
+	}
+
+	for i, polygon := range polygons.Polygons {
+		resp.Polygons[i] = make([][2]int, len(polygon))
+		for j, p := range polygon {
+			resp.Polygons[i][j] = [2]int{p.X, p.Y}
+		}
+	}
+
+	writeJson(ctx, w, resp, http.StatusOK)
 }
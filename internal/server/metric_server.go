@@ -1,8 +1,10 @@
 package server
 
 import (
+	"FairLAP/internal/domain/entity"
 	"FairLAP/internal/domain/service/metrics"
 	"FairLAP/pkg/failure"
+	"github.com/google/uuid"
 	"net/http"
 	"strconv"
 )
@@ -17,10 +19,19 @@ func NewMetricServer(metrics *metrics.Service) *MetricServer {
 	}
 }
 
+// problemCount reads the count parameter, the detections not reviewed yet
+// are counted by default.
+func problemCount(r *http.Request) entity.ProblemCount {
+	if v := r.FormValue("count"); v != "" {
+		return entity.ProblemCount(v)
+	}
+	return entity.CountConfirmedPending
+}
+
 func (s *MetricServer) GetLaps(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	laps, err := s.metrics.GetLaps(ctx)
+	laps, err := s.metrics.GetLaps(ctx, problemCount(r))
 	if err != nil {
 		writeAndLogErr(ctx, w, err)
 		return
@@ -38,7 +49,57 @@ func (s *MetricServer) GetGroupMetric(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metric, err := s.metrics.GetGroupMetricV2(ctx, groupId)
+	metric, err := s.metrics.GetGroupMetricV2(ctx, groupId, problemCount(r))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, metric, http.StatusOK)
+}
+
+func (s *MetricServer) GetImageMetric(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groupId, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+
+	imageUid, err := uuid.Parse(r.FormValue("image_uid"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+
+	metric, err := s.metrics.GetImageMetric(ctx, groupId, imageUid)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, metric, http.StatusOK)
+}
+
+func (s *MetricServer) GetTowersMetric(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var (
+		metric *metrics.TowersMetric
+		err    error
+	)
+
+	if lapId := r.FormValue("lap_id"); lapId != "" {
+		metric, err = s.metrics.GetLapTowersMetric(ctx, lapId, problemCount(r))
+	} else {
+		groupId, convErr := strconv.Atoi(r.FormValue("group_id"))
+		if convErr != nil {
+			writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+			return
+		}
+		metric, err = s.metrics.GetTowersMetric(ctx, groupId, problemCount(r))
+	}
 	if err != nil {
 		writeAndLogErr(ctx, w, err)
 		return
@@ -46,3 +107,21 @@ func (s *MetricServer) GetGroupMetric(w http.ResponseWriter, r *http.Request) {
 
 	writeJson(ctx, w, metric, http.StatusOK)
 }
+
+func (s *MetricServer) GetGroupMeta(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groupId, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+
+	meta, err := s.metrics.GetGroupMeta(ctx, groupId)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, meta, http.StatusOK)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"FairLAP/pkg/inference"
+	"net/http"
+)
+
+type ModelStats interface {
+	Stats() inference.PoolStats
+}
+
+type ModelsServer struct {
+	model    ModelStats
+	modelSeg ModelStats
+}
+
+func NewModelsServer(model, modelSeg ModelStats) *ModelsServer {
+	return &ModelsServer{
+		model:    model,
+		modelSeg: modelSeg,
+	}
+}
+
+type ModelsStatsResponse struct {
+	Model    inference.PoolStats `json:"model"`
+	ModelSeg inference.PoolStats `json:"model_seg"`
+}
+
+func (s *ModelsServer) GetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	writeJson(ctx, w, ModelsStatsResponse{
+		Model:    s.model.Stats(),
+		ModelSeg: s.modelSeg.Stats(),
+	}, http.StatusOK)
+}
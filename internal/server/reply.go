@@ -44,6 +44,8 @@ func getErrorStatus(err error) (errcodes.Code, int) {
 		return errcodes.ErrInvalidRequest, http.StatusBadRequest
 	case failure.IsUnauthorizedError(err):
 		return errcodes.ErrUnauthorized, http.StatusUnauthorized
+	case failure.IsUnavailableError(err):
+		return errcodes.ErrUnavailable, http.StatusServiceUnavailable
 	default:
 		return errcodes.ErrUnknown, http.StatusInternalServerError
 	}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/review"
+	"FairLAP/pkg/failure"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type ReviewServer struct {
+	review *review.Service
+}
+
+func NewReviewServer(review *review.Service) *ReviewServer {
+	return &ReviewServer{
+		review: review,
+	}
+}
+
+type reviewDetectionRequest struct {
+	DetectionId int `json:"detection_id"`
+	entity.Review
+}
+
+func (s *ReviewServer) ReviewDetection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req reviewDetectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid review"))
+		return
+	}
+
+	detection, err := s.review.Review(ctx, req.DetectionId, req.Review)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, detection, http.StatusOK)
+}
+
+type reviewImageRequest struct {
+	GroupId  int       `json:"group_id"`
+	ImageUid uuid.UUID `json:"image_uid"`
+	entity.Review
+}
+
+func (s *ReviewServer) ReviewImage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req reviewImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid review"))
+		return
+	}
+
+	detections, err := s.review.ReviewImage(ctx, req.GroupId, req.ImageUid, req.Review)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, detections, http.StatusOK)
+}
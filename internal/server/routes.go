@@ -5,21 +5,88 @@ import (
 	"net/http"
 )
 
+// streamingPaths are the routes sending or receiving large bodies, they are
+// not logged and have no deadlines.
+var streamingPaths = map[string]bool{
+	"/detect/batch":   true,
+	"/import/dataset": true,
+	"/tasks/download": true,
+}
+
+// IsStreaming tells whether the request is to a streaming route.
+func IsStreaming(r *http.Request) bool {
+	return streamingPaths[r.URL.Path]
+}
+
 func (s *Server) InitRoutes(rtr *mux.Router) {
 	rtr.HandleFunc("/detect", s.detector.Detect).Methods(http.MethodPost)
+	rtr.HandleFunc("/detect/batch", s.batch.Upload).Methods(http.MethodPost)
+
+	rtr.HandleFunc("/jobs/get", s.jobs.GetJob).Methods(http.MethodGet)
+	rtr.HandleFunc("/jobs/by_group", s.jobs.GetByGroup).Methods(http.MethodGet)
+
+	rtr.HandleFunc("/tasks/get", s.tasks.GetTask).Methods(http.MethodGet)
+	rtr.HandleFunc("/tasks/download", s.tasks.Download).Methods(http.MethodGet)
+
+	rtr.HandleFunc("/laps/create", s.laps.CreateLap).Methods(http.MethodPost)
+	rtr.HandleFunc("/laps/update", s.laps.UpdateLap).Methods(http.MethodPost)
+	rtr.HandleFunc("/laps/get", s.laps.GetLap).Methods(http.MethodGet)
+	rtr.HandleFunc("/laps/list", s.laps.GetLaps).Methods(http.MethodGet)
+	rtr.HandleFunc("/laps/delete", s.laps.DeleteLap).Methods(http.MethodDelete)
+
+	rtr.HandleFunc("/towers/create", s.towers.CreateTower).Methods(http.MethodPost)
+	rtr.HandleFunc("/towers/update", s.towers.UpdateTower).Methods(http.MethodPost)
+	rtr.HandleFunc("/towers/by_lap", s.towers.GetByLap).Methods(http.MethodGet)
+	rtr.HandleFunc("/towers/delete", s.towers.DeleteTower).Methods(http.MethodDelete)
+	rtr.HandleFunc("/towers/link_image", s.towers.LinkImage).Methods(http.MethodPost)
+	rtr.HandleFunc("/spans/create", s.towers.CreateSpan).Methods(http.MethodPost)
+	rtr.HandleFunc("/spans/by_lap", s.towers.GetSpans).Methods(http.MethodGet)
+	rtr.HandleFunc("/spans/delete", s.towers.DeleteSpan).Methods(http.MethodDelete)
 
 	rtr.HandleFunc("/groups/create", s.groups.CreateGroup).Methods(http.MethodPost)
 	rtr.HandleFunc("/groups/by_lap", s.groups.GetByLap).Methods(http.MethodGet)
 	rtr.HandleFunc("/groups/delete", s.groups.DeleteGroup).Methods(http.MethodDelete)
 
+	rtr.HandleFunc("/detections/review", s.review.ReviewDetection).Methods(http.MethodPost)
+	rtr.HandleFunc("/detections/review_image", s.review.ReviewImage).Methods(http.MethodPost)
+
+	rtr.HandleFunc("/annotations/classes", s.annotations.GetClasses).Methods(http.MethodGet)
+	rtr.HandleFunc("/annotations/create", s.annotations.Create).Methods(http.MethodPost)
+	rtr.HandleFunc("/annotations/update", s.annotations.Update).Methods(http.MethodPost)
+	rtr.HandleFunc("/annotations/delete", s.annotations.Delete).Methods(http.MethodDelete)
+	rtr.HandleFunc("/annotations/history", s.annotations.History).Methods(http.MethodGet)
+
+	rtr.HandleFunc("/evaluations/run", s.evaluation.Run).Methods(http.MethodPost)
+	rtr.HandleFunc("/evaluations/get", s.evaluation.Get).Methods(http.MethodGet)
+	rtr.HandleFunc("/evaluations/list", s.evaluation.List).Methods(http.MethodGet)
+	rtr.HandleFunc("/evaluations/compare", s.evaluation.Compare).Methods(http.MethodGet)
+	rtr.HandleFunc("/evaluations/delete", s.evaluation.Delete).Methods(http.MethodDelete)
+
 	rtr.HandleFunc("/metric/laps", s.metrics.GetLaps).Methods(http.MethodGet)
 	rtr.HandleFunc("/metric/group", s.metrics.GetGroupMetric).Methods(http.MethodGet)
+	rtr.HandleFunc("/metric/group/meta", s.metrics.GetGroupMeta).Methods(http.MethodGet)
+	rtr.HandleFunc("/metric/towers", s.metrics.GetTowersMetric).Methods(http.MethodGet)
+	rtr.HandleFunc("/metric/image", s.metrics.GetImageMetric).Methods(http.MethodGet)
+	rtr.HandleFunc("/metric/models", s.models.GetStats).Methods(http.MethodGet)
+	rtr.HandleFunc("/metric/cache", s.cache.GetStats).Methods(http.MethodGet)
+
+	rtr.HandleFunc("/export/group/{group_id}.geojson", s.export.GroupGeoJSON).Methods(http.MethodGet)
+	rtr.HandleFunc("/export/group/{group_id}.kml", s.export.GroupKML).Methods(http.MethodGet)
+	rtr.HandleFunc("/export/lap/{lap_id}.geojson", s.export.LapGeoJSON).Methods(http.MethodGet)
+	rtr.HandleFunc("/export/lap/{lap_id}.kml", s.export.LapKML).Methods(http.MethodGet)
+	rtr.HandleFunc("/export/dataset", s.dataset.Export).Methods(http.MethodPost)
+	rtr.HandleFunc("/import/dataset", s.dataset.Import).Methods(http.MethodPost)
 
 	rtr.HandleFunc("/lap_config/get", s.lapConfig.GetLapConfig).Methods(http.MethodGet)
 	rtr.HandleFunc("/lap_config/save", s.lapConfig.SaveLapConfig).Methods(http.MethodPost)
 
+	// registered before the image route, whose {image_uid} would match "<uid>_thumb"
+	rtr.HandleFunc("/image/{group_id}/{image_uid}_thumb.jpeg", s.images.HandleThumb).Methods(http.MethodGet)
 	rtr.HandleFunc("/image/{group_id}/{image_uid}.jpeg", s.images.HandleImage).Methods(http.MethodGet)
 	rtr.HandleFunc("/image/{group_id}/{image_uid}_mask.png", s.images.HandleMask).Methods(http.MethodGet, http.MethodPost)
+	rtr.HandleFunc("/image/{group_id}/{image_uid}/url", s.images.HandleImageUrl).Methods(http.MethodGet)
+	rtr.HandleFunc("/image/{group_id}/{image_uid}/original", s.images.HandleOriginal).Methods(http.MethodGet)
 	rtr.HandleFunc("/mask/{detection_id}.png", s.mask.GetRect).Methods(http.MethodGet)
 	rtr.HandleFunc("/polygon/{group_id}/{image_uid}.png", s.mask.GetPolygon).Methods(http.MethodGet)
+	rtr.HandleFunc("/polygon/{group_id}/{image_uid}.json", s.mask.GetPolygonJson).Methods(http.MethodGet)
 }
@@ -1,12 +1,24 @@
 package server
 
 type Server struct {
-	detector  *DetectorServer
-	groups    *GroupsServer
-	metrics   *MetricServer
-	lapConfig *LapConfigServer
-	mask      *MaskServer
-	images    *ImagesServer
+	detector    *DetectorServer
+	groups      *GroupsServer
+	metrics     *MetricServer
+	lapConfig   *LapConfigServer
+	mask        *MaskServer
+	images      *ImagesServer
+	jobs        *JobsServer
+	batch       *BatchServer
+	models      *ModelsServer
+	cache       *CacheServer
+	laps        *LapsServer
+	towers      *TowersServer
+	export      *ExportServer
+	review      *ReviewServer
+	annotations *AnnotationsServer
+	dataset     *DatasetServer
+	evaluation  *EvaluationServer
+	tasks       *TasksServer
 }
 
 func NewServer(
@@ -16,13 +28,37 @@ func NewServer(
 	lapConfig *LapConfigServer,
 	mask *MaskServer,
 	images *ImagesServer,
+	jobs *JobsServer,
+	batch *BatchServer,
+	models *ModelsServer,
+	cache *CacheServer,
+	laps *LapsServer,
+	towers *TowersServer,
+	export *ExportServer,
+	review *ReviewServer,
+	annotations *AnnotationsServer,
+	dataset *DatasetServer,
+	evaluation *EvaluationServer,
+	tasks *TasksServer,
 ) *Server {
 	return &Server{
-		detector:  detector,
-		groups:    groups,
-		metrics:   metrics,
-		lapConfig: lapConfig,
-		mask:      mask,
-		images:    images,
+		detector:    detector,
+		groups:      groups,
+		metrics:     metrics,
+		lapConfig:   lapConfig,
+		mask:        mask,
+		images:      images,
+		jobs:        jobs,
+		batch:       batch,
+		models:      models,
+		cache:       cache,
+		laps:        laps,
+		towers:      towers,
+		export:      export,
+		review:      review,
+		annotations: annotations,
+		dataset:     dataset,
+		evaluation:  evaluation,
+		tasks:       tasks,
 	}
 }
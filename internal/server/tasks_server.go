@@ -0,0 +1,69 @@
+package server
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/tasks"
+	"FairLAP/pkg/failure"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type TasksServer struct {
+	tasks *tasks.Service
+}
+
+func NewTasksServer(tasks *tasks.Service) *TasksServer {
+	return &TasksServer{
+		tasks: tasks,
+	}
+}
+
+type TaskResponse struct {
+	*entity.Task
+	DownloadUrl string `json:"download_url,omitempty"`
+}
+
+func (s *TasksServer) GetTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	task, err := s.tasks.Get(ctx, id)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	resp := TaskResponse{Task: task}
+	if task.Status == entity.JobDone && task.Output != "" {
+		resp.DownloadUrl = fmt.Sprintf("/tasks/download?id=%d", task.Id)
+	}
+
+	writeJson(ctx, w, resp, http.StatusOK)
+}
+
+// Download sends the file produced by a finished task.
+func (s *TasksServer) Download(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	task, f, err := s.tasks.Output(ctx, id)
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename=%q`, task.Output))
+	http.ServeContent(w, r, task.Output, task.UpdateAt, f)
+}
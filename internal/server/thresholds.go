@@ -0,0 +1,54 @@
+package server
+
+import (
+	"FairLAP/pkg/failure"
+	"FairLAP/pkg/inference"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseThresholds reads a request level override of the model thresholds:
+// conf_threshold=0.4&class_thresholds=nest:0.35,traverse:0.6&classes=nest,traverse
+// Returns nil if the request has no override.
+func parseThresholds(query url.Values) (*inference.Thresholds, error) {
+	var thresholds inference.Thresholds
+	override := false
+
+	if v := query.Get("conf_threshold"); v != "" {
+		conf, err := strconv.ParseFloat(v, 32)
+		if err != nil || conf < 0 || conf > 1 {
+			return nil, failure.NewInvalidRequestError("invalid conf_threshold")
+		}
+		def := float32(conf)
+		thresholds.Default = &def
+		override = true
+	}
+
+	if v := query.Get("class_thresholds"); v != "" {
+		thresholds.Classes = make(map[string]float32)
+		for _, item := range strings.Split(v, ",") {
+			class, value, ok := strings.Cut(item, ":")
+			if !ok || class == "" {
+				return nil, failure.NewInvalidRequestError("invalid class_thresholds")
+			}
+			conf, err := strconv.ParseFloat(value, 32)
+			if err != nil || conf < 0 || conf > 1 {
+				return nil, failure.NewInvalidRequestError("invalid class_thresholds")
+			}
+			thresholds.Classes[class] = float32(conf)
+		}
+		override = true
+	}
+
+	if v := query.Get("classes"); v != "" {
+		thresholds.Enabled = strings.Split(v, ",")
+		override = true
+	}
+
+	if !override {
+		return nil, nil
+	}
+
+	return &thresholds, nil
+}
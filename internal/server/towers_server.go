@@ -0,0 +1,171 @@
+package server
+
+import (
+	"FairLAP/internal/domain/entity"
+	"FairLAP/internal/domain/service/towers"
+	"FairLAP/pkg/failure"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type TowersServer struct {
+	towers *towers.Service
+}
+
+func NewTowersServer(towers *towers.Service) *TowersServer {
+	return &TowersServer{
+		towers: towers,
+	}
+}
+
+func (s *TowersServer) CreateTower(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var tower entity.Tower
+	if err := json.NewDecoder(r.Body).Decode(&tower); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid tower"))
+		return
+	}
+
+	if err := s.towers.CreateTower(ctx, &tower); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, tower, http.StatusOK)
+}
+
+func (s *TowersServer) UpdateTower(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var tower entity.Tower
+	if err := json.NewDecoder(r.Body).Decode(&tower); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid tower"))
+		return
+	}
+
+	if err := s.towers.UpdateTower(ctx, &tower); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, tower, http.StatusOK)
+}
+
+func (s *TowersServer) GetByLap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	t, err := s.towers.GetTowers(ctx, r.FormValue("lap_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, t, http.StatusOK)
+}
+
+func (s *TowersServer) DeleteTower(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	if err := s.towers.DeleteTower(ctx, id); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+}
+
+func (s *TowersServer) CreateSpan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var span entity.Span
+	if err := json.NewDecoder(r.Body).Decode(&span); err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid span"))
+		return
+	}
+
+	if err := s.towers.CreateSpan(ctx, &span); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, span, http.StatusOK)
+}
+
+func (s *TowersServer) GetSpans(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	spans, err := s.towers.GetSpans(ctx, r.FormValue("lap_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	writeJson(ctx, w, spans, http.StatusOK)
+}
+
+func (s *TowersServer) DeleteSpan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid id"))
+		return
+	}
+
+	if err := s.towers.DeleteSpan(ctx, id); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+}
+
+func (s *TowersServer) LinkImage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groupId, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid group_id"))
+		return
+	}
+	imageUid, err := uuid.Parse(r.FormValue("image_uid"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid image_uid"))
+		return
+	}
+	towerId, err := strconv.Atoi(r.FormValue("tower_id"))
+	if err != nil {
+		writeAndLogErr(ctx, w, failure.NewInvalidRequestError("invalid tower_id"))
+		return
+	}
+
+	if err := s.towers.CheckGroupTower(ctx, groupId, towerId); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+
+	if err := s.towers.LinkImage(ctx, groupId, imageUid, towerId, entity.TowerLinkExplicit); err != nil {
+		writeAndLogErr(ctx, w, err)
+		return
+	}
+}
+
+// parseTowerId reads the optional tower_id query parameter, 0 means not set.
+func parseTowerId(r *http.Request) (int, error) {
+	value := r.FormValue("tower_id")
+	if value == "" {
+		return 0, nil
+	}
+
+	towerId, err := strconv.Atoi(value)
+	if err != nil || towerId <= 0 {
+		return 0, failure.NewInvalidRequestError("invalid tower_id")
+	}
+
+	return towerId, nil
+}
@@ -0,0 +1,30 @@
+// Package migrations embeds the versioned schema migrations, one directory
+// per SQL dialect. Files are named NNNN_name.up.sql and NNNN_name.down.sql,
+// applied migrations must not be edited, add a new version instead. Every
+// version is added to all dialects.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+const (
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql postgres/*.sql
+var files embed.FS
+
+// FS returns the migrations of the dialect.
+func FS(dialect string) (fs.FS, error) {
+	switch dialect {
+	case MySQL, SQLite, Postgres:
+		return fs.Sub(files, dialect)
+	default:
+		return nil, fmt.Errorf("unknown migrations dialect %q", dialect)
+	}
+}
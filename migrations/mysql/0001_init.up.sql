@@ -48,4 +48,3 @@ create table lap_config
     value  int default 0 not null,
     primary key (lap_id, class)
 );
-
@@ -0,0 +1,37 @@
+create table detection_jobs
+(
+    id         int auto_increment
+        primary key,
+    group_id   int         not null,
+    status     varchar(16) not null,
+    thresholds text        null,
+    error      text        not null,
+    create_at  timestamp   not null,
+    update_at  timestamp   not null,
+    constraint job_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
+
+create index job_to_group_idx
+    on detection_jobs (group_id);
+
+create index job_status_idx
+    on detection_jobs (status);
+
+create table detection_job_images
+(
+    id               int auto_increment
+        primary key,
+    job_id           int           not null,
+    image_uid        tinyblob      not null,
+    status           varchar(16)   not null,
+    error            text          not null,
+    detections_count int default 0 not null,
+    constraint job_image_to_job
+        foreign key (job_id) references detection_jobs (id)
+            on delete cascade
+);
+
+create index job_image_to_job_idx
+    on detection_job_images (job_id);
@@ -0,0 +1,15 @@
+create table image_polygons
+(
+    id        int auto_increment
+        primary key,
+    group_id  int      not null,
+    image_uid tinyblob not null,
+    width     int      not null,
+    height    int      not null,
+    points    json     not null,
+    constraint image_polygons_uindex
+        unique (group_id, image_uid(36)),
+    constraint polygons_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
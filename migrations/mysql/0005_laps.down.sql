@@ -0,0 +1,12 @@
+alter table lap_config
+    drop foreign key config_to_lap;
+
+alter table `groups`
+    drop foreign key group_to_lap;
+
+drop index group_to_lap on `groups`;
+
+alter table lap_config
+    modify lap_id int not null;
+
+drop table if exists laps;
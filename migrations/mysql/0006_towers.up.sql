@@ -0,0 +1,53 @@
+create table towers
+(
+    id        int auto_increment
+        primary key,
+    lap_id    varchar(45)  not null,
+    number    int          not null,
+    name      varchar(255) not null,
+    latitude  double       null,
+    longitude double       null,
+    create_at timestamp    not null,
+    constraint towers_uindex
+        unique (lap_id, number),
+    constraint tower_to_lap
+        foreign key (lap_id) references laps (id)
+            on delete cascade
+);
+
+create table spans
+(
+    id            int auto_increment
+        primary key,
+    lap_id        varchar(45) not null,
+    from_tower_id int         not null,
+    to_tower_id   int         not null,
+    length        double      not null,
+    constraint span_to_lap
+        foreign key (lap_id) references laps (id)
+            on delete cascade,
+    constraint span_from_tower
+        foreign key (from_tower_id) references towers (id)
+            on delete cascade,
+    constraint span_to_tower
+        foreign key (to_tower_id) references towers (id)
+            on delete cascade
+);
+
+create table image_towers
+(
+    id        int auto_increment
+        primary key,
+    group_id  int         not null,
+    image_uid tinyblob    not null,
+    tower_id  int         not null,
+    source    varchar(16) not null,
+    constraint image_towers_uindex
+        unique (group_id, image_uid(36)),
+    constraint image_tower_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade,
+    constraint image_tower_to_tower
+        foreign key (tower_id) references towers (id)
+            on delete cascade
+);
@@ -0,0 +1,22 @@
+create table image_meta
+(
+    id                int auto_increment
+        primary key,
+    group_id          int          not null,
+    image_uid         tinyblob     not null,
+    latitude          double       null,
+    longitude         double       null,
+    altitude          double       null,
+    relative_altitude double       null,
+    gimbal_pitch      double       null,
+    gimbal_yaw        double       null,
+    gimbal_roll       double       null,
+    capture_at        datetime     null,
+    camera_make       varchar(255) not null,
+    camera_model      varchar(255) not null,
+    constraint image_meta_uindex
+        unique (group_id, image_uid(36)),
+    constraint image_meta_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
@@ -0,0 +1,2 @@
+alter table detections
+    add is_problem tinyint(1) default 0 not null;
@@ -0,0 +1,14 @@
+create table image_reviews
+(
+    id          int auto_increment
+        primary key,
+    group_id    int          not null,
+    image_uid   tinyblob     not null,
+    reviewed_by varchar(255) not null,
+    reviewed_at timestamp    not null,
+    constraint image_reviews_uindex
+        unique (group_id, image_uid(36)),
+    constraint image_reviews_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
@@ -0,0 +1,4 @@
+drop table if exists lap_config;
+drop table if exists detection_rects;
+drop table if exists detections;
+drop table if exists groups;
@@ -0,0 +1,50 @@
+create table groups
+(
+    id        serial
+        primary key,
+    lap_id    varchar(45) not null,
+    create_at timestamp   not null
+);
+
+create table detections
+(
+    id        serial
+        primary key,
+    group_id  integer     not null
+        constraint detection_to_group
+            references groups (id)
+            on delete cascade,
+    image_uid uuid        not null,
+    class     varchar(45) not null
+);
+
+create table detection_rects
+(
+    id           serial
+        primary key,
+    detection_id integer not null
+        constraint rect_to_detection
+            references detections (id)
+            on delete cascade,
+    width        integer not null,
+    height       integer not null,
+    x0           integer not null,
+    y0           integer not null,
+    x1           integer not null,
+    y1           integer not null,
+    confidence   real    not null
+);
+
+create index rect_to_detection_idx
+    on detection_rects (detection_id);
+
+create index detection_to_group_idx
+    on detections (group_id);
+
+create table lap_config
+(
+    lap_id integer           not null,
+    class  varchar(45)       not null,
+    value  integer default 0 not null,
+    primary key (lap_id, class)
+);
@@ -0,0 +1,37 @@
+create table detection_jobs
+(
+    id         serial
+        primary key,
+    group_id   integer     not null
+        constraint job_to_group
+            references groups (id)
+            on delete cascade,
+    status     varchar(16) not null,
+    thresholds jsonb       null,
+    error      text        not null,
+    create_at  timestamp   not null,
+    update_at  timestamp   not null
+);
+
+create index job_to_group_idx
+    on detection_jobs (group_id);
+
+create index job_status_idx
+    on detection_jobs (status);
+
+create table detection_job_images
+(
+    id               serial
+        primary key,
+    job_id           integer           not null
+        constraint job_image_to_job
+            references detection_jobs (id)
+            on delete cascade,
+    image_uid        uuid              not null,
+    status           varchar(16)       not null,
+    error            text              not null,
+    detections_count integer default 0 not null
+);
+
+create index job_image_to_job_idx
+    on detection_job_images (job_id);
@@ -0,0 +1,2 @@
+alter table detections
+    drop column conf_threshold;
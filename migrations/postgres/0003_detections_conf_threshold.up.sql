@@ -0,0 +1,2 @@
+alter table detections
+    add conf_threshold real default 0 not null;
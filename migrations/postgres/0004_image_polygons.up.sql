@@ -0,0 +1,15 @@
+create table image_polygons
+(
+    id        serial
+        primary key,
+    group_id  integer not null
+        constraint polygons_to_group
+            references groups (id)
+            on delete cascade,
+    image_uid uuid    not null,
+    width     integer not null,
+    height    integer not null,
+    points    jsonb   not null,
+    constraint image_polygons_uindex
+        unique (group_id, image_uid)
+);
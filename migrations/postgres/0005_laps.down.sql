@@ -0,0 +1,12 @@
+alter table lap_config
+    drop constraint config_to_lap;
+
+drop index if exists group_to_lap_idx;
+
+alter table groups
+    drop constraint group_to_lap;
+
+alter table lap_config
+    alter column lap_id type integer using lap_id::integer;
+
+drop table if exists laps;
@@ -0,0 +1,38 @@
+create table laps
+(
+    id            varchar(45)      not null
+        primary key,
+    name          varchar(255)     not null,
+    voltage_class varchar(45)      not null,
+    operator      varchar(255)     not null,
+    region        varchar(255)     not null,
+    route_length  double precision not null,
+    create_at     timestamp        not null,
+    update_at     timestamp        not null
+);
+
+-- laps of existing groups and lap configs, details are filled in through the API
+insert into laps (id, name, voltage_class, operator, region, route_length, create_at, update_at)
+select lap_id, lap_id, '', '', '', 0, min(create_at), min(create_at)
+from groups
+group by lap_id;
+
+alter table lap_config
+    alter column lap_id type varchar(45) using lap_id::varchar;
+
+insert into laps (id, name, voltage_class, operator, region, route_length, create_at, update_at)
+select distinct lap_id, lap_id, '', '', '', 0, now() at time zone 'utc', now() at time zone 'utc'
+from lap_config
+where lap_id not in (select id from laps);
+
+alter table groups
+    add constraint group_to_lap
+        foreign key (lap_id) references laps (id);
+
+create index group_to_lap_idx
+    on groups (lap_id);
+
+alter table lap_config
+    add constraint config_to_lap
+        foreign key (lap_id) references laps (id)
+            on delete cascade;
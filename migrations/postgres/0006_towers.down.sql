@@ -0,0 +1,3 @@
+drop table if exists image_towers;
+drop table if exists spans;
+drop table if exists towers;
@@ -0,0 +1,64 @@
+-- tower and photo locations are geography points
+create extension if not exists postgis;
+
+create table towers
+(
+    id        serial
+        primary key,
+    lap_id    varchar(45)      not null
+        constraint tower_to_lap
+            references laps (id)
+            on delete cascade,
+    number    integer          not null,
+    name      varchar(255)     not null,
+    latitude  double precision null,
+    longitude double precision null,
+    location  geography(Point, 4326) generated always as (
+        case
+            when latitude is not null and longitude is not null
+                then st_setsrid(st_makepoint(longitude, latitude), 4326)::geography
+            end) stored,
+    create_at timestamp        not null,
+    constraint towers_uindex
+        unique (lap_id, number)
+);
+
+create index towers_location_idx
+    on towers using gist (location);
+
+create table spans
+(
+    id            serial
+        primary key,
+    lap_id        varchar(45)      not null
+        constraint span_to_lap
+            references laps (id)
+            on delete cascade,
+    from_tower_id integer          not null
+        constraint span_from_tower
+            references towers (id)
+            on delete cascade,
+    to_tower_id   integer          not null
+        constraint span_to_tower
+            references towers (id)
+            on delete cascade,
+    length        double precision not null
+);
+
+create table image_towers
+(
+    id        serial
+        primary key,
+    group_id  integer     not null
+        constraint image_tower_to_group
+            references groups (id)
+            on delete cascade,
+    image_uid uuid        not null,
+    tower_id  integer     not null
+        constraint image_tower_to_tower
+            references towers (id)
+            on delete cascade,
+    source    varchar(16) not null,
+    constraint image_towers_uindex
+        unique (group_id, image_uid)
+);
@@ -0,0 +1,30 @@
+create table image_meta
+(
+    id                serial
+        primary key,
+    group_id          integer          not null
+        constraint image_meta_to_group
+            references groups (id)
+            on delete cascade,
+    image_uid         uuid             not null,
+    latitude          double precision null,
+    longitude         double precision null,
+    altitude          double precision null,
+    relative_altitude double precision null,
+    gimbal_pitch      double precision null,
+    gimbal_yaw        double precision null,
+    gimbal_roll       double precision null,
+    capture_at        timestamp        null,
+    camera_make       varchar(255)     not null,
+    camera_model      varchar(255)     not null,
+    location          geography(Point, 4326) generated always as (
+        case
+            when latitude is not null and longitude is not null
+                then st_setsrid(st_makepoint(longitude, latitude), 4326)::geography
+            end) stored,
+    constraint image_meta_uindex
+        unique (group_id, image_uid)
+);
+
+create index image_meta_location_idx
+    on image_meta using gist (location);
@@ -0,0 +1,2 @@
+alter table detections
+    add is_problem boolean default false not null;
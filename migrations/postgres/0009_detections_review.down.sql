@@ -0,0 +1,5 @@
+alter table detections
+    drop column review_status,
+    drop column reviewed_by,
+    drop column reviewed_at,
+    drop column review_comment;
@@ -0,0 +1,5 @@
+alter table detections
+    add review_status  varchar(16)   default 'pending' not null,
+    add reviewed_by    varchar(255)  default ''        not null,
+    add reviewed_at    timestamp                       null,
+    add review_comment varchar(1024) default ''        not null;
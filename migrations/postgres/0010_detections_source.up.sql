@@ -0,0 +1,2 @@
+alter table detections
+    add source varchar(16) default 'model' not null;
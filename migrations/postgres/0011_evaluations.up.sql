@@ -0,0 +1,19 @@
+create table evaluations
+(
+    id              serial
+        primary key,
+    model           varchar(255)     not null,
+    comment         text             not null,
+    group_ids       jsonb            not null,
+    conf_threshold  real             not null,
+    images          integer          not null,
+    precision_value double precision not null,
+    recall_value    double precision not null,
+    map50           double precision not null,
+    map50_95        double precision not null,
+    result          jsonb            not null,
+    create_at       timestamp        not null
+);
+
+create index evaluation_model_idx
+    on evaluations (model);
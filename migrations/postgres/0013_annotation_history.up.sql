@@ -0,0 +1,24 @@
+create table annotation_history
+(
+    id            serial
+        primary key,
+    detection_id  integer      not null,
+    group_id      integer      not null
+        constraint history_to_group
+            references groups (id)
+            on delete cascade,
+    image_uid     uuid         not null,
+    action        varchar(16)  not null,
+    editor        varchar(255) not null,
+    class         varchar(255) not null,
+    source        varchar(16)  not null,
+    review_status varchar(16)  not null,
+    x0            integer      not null,
+    y0            integer      not null,
+    x1            integer      not null,
+    y1            integer      not null,
+    create_at     timestamp    not null
+);
+
+create index history_image_idx
+    on annotation_history (group_id, image_uid);
@@ -0,0 +1,14 @@
+create table image_reviews
+(
+    id          serial
+        primary key,
+    group_id    integer      not null
+        constraint image_reviews_to_group
+            references groups (id)
+            on delete cascade,
+    image_uid   uuid         not null,
+    reviewed_by varchar(255) not null,
+    reviewed_at timestamp    not null,
+    constraint image_reviews_uindex
+        unique (group_id, image_uid)
+);
@@ -0,0 +1,2 @@
+drop table if exists detection_job_images;
+drop table if exists detection_jobs;
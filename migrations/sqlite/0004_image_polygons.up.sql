@@ -0,0 +1,15 @@
+create table image_polygons
+(
+    id        integer     not null
+        primary key autoincrement,
+    group_id  integer     not null,
+    image_uid varchar(36) not null,
+    width     int         not null,
+    height    int         not null,
+    points    text        not null,
+    constraint image_polygons_uindex
+        unique (group_id, image_uid),
+    constraint polygons_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
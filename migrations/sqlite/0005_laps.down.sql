@@ -0,0 +1,20 @@
+create table lap_config_old
+(
+    lap_id int           not null,
+    class  varchar(45)   not null,
+    value  int default 0 not null,
+    primary key (lap_id, class)
+);
+
+insert into lap_config_old (lap_id, class, value)
+select lap_id, class, value
+from lap_config;
+
+drop table lap_config;
+
+alter table lap_config_old
+    rename to lap_config;
+
+drop index if exists group_to_lap_idx;
+
+drop table if exists laps;
@@ -0,0 +1,49 @@
+create table laps
+(
+    id            varchar(45)  not null
+        primary key,
+    name          varchar(255) not null,
+    voltage_class varchar(45)  not null,
+    operator      varchar(255) not null,
+    region        varchar(255) not null,
+    route_length  double       not null,
+    create_at     timestamp    not null,
+    update_at     timestamp    not null
+);
+
+-- laps of existing groups and lap configs, details are filled in through the API
+insert into laps (id, name, voltage_class, operator, region, route_length, create_at, update_at)
+select lap_id, lap_id, '', '', '', 0, min(create_at), min(create_at)
+from `groups`
+group by lap_id;
+
+insert into laps (id, name, voltage_class, operator, region, route_length, create_at, update_at)
+select distinct cast(lap_id as text), cast(lap_id as text), '', '', '', 0, current_timestamp, current_timestamp
+from lap_config
+where cast(lap_id as text) not in (select id from laps);
+
+-- SQLite changes column types and constraints by rebuilding the table
+create table lap_config_new
+(
+    lap_id varchar(45)   not null,
+    class  varchar(45)   not null,
+    value  int default 0 not null,
+    primary key (lap_id, class),
+    constraint config_to_lap
+        foreign key (lap_id) references laps (id)
+            on delete cascade
+);
+
+insert into lap_config_new (lap_id, class, value)
+select cast(lap_id as text), class, value
+from lap_config;
+
+drop table lap_config;
+
+alter table lap_config_new
+    rename to lap_config;
+
+-- rebuilding `groups` would cascade the drop of the old table to every
+-- detection, so the lap of a group is checked by the service only
+create index group_to_lap_idx
+    on `groups` (lap_id);
@@ -0,0 +1,22 @@
+create table image_meta
+(
+    id                integer      not null
+        primary key autoincrement,
+    group_id          integer      not null,
+    image_uid         varchar(36)  not null,
+    latitude          double       null,
+    longitude         double       null,
+    altitude          double       null,
+    relative_altitude double       null,
+    gimbal_pitch      double       null,
+    gimbal_yaw        double       null,
+    gimbal_roll       double       null,
+    capture_at        timestamp    null,
+    camera_make       varchar(255) not null,
+    camera_model      varchar(255) not null,
+    constraint image_meta_uindex
+        unique (group_id, image_uid),
+    constraint image_meta_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
@@ -0,0 +1,2 @@
+alter table detections
+    drop column is_problem;
@@ -0,0 +1,2 @@
+alter table detections
+    add is_problem integer default 0 not null;
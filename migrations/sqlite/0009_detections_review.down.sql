@@ -0,0 +1,8 @@
+alter table detections
+    drop column review_status;
+alter table detections
+    drop column reviewed_by;
+alter table detections
+    drop column reviewed_at;
+alter table detections
+    drop column review_comment;
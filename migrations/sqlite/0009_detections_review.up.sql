@@ -0,0 +1,8 @@
+alter table detections
+    add review_status varchar(16) default 'pending' not null;
+alter table detections
+    add reviewed_by varchar(255) default '' not null;
+alter table detections
+    add reviewed_at timestamp null;
+alter table detections
+    add review_comment varchar(1024) default '' not null;
@@ -0,0 +1,19 @@
+create table evaluations
+(
+    id              integer      not null
+        primary key autoincrement,
+    model           varchar(255) not null,
+    comment         text         not null,
+    group_ids       text         not null,
+    conf_threshold  float        not null,
+    images          integer      not null,
+    precision_value double       not null,
+    recall_value    double       not null,
+    map50           double       not null,
+    map50_95        double       not null,
+    result          text         not null,
+    create_at       timestamp    not null
+);
+
+create index evaluation_model_idx
+    on evaluations (model);
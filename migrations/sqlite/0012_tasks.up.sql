@@ -0,0 +1,17 @@
+create table tasks
+(
+    id        integer      not null
+        primary key autoincrement,
+    kind      varchar(32)  not null,
+    status    varchar(16)  not null,
+    params    text         not null,
+    result    text         not null,
+    input     varchar(255) not null,
+    output    varchar(255) not null,
+    error     text         not null,
+    create_at timestamp    not null,
+    update_at timestamp    not null
+);
+
+create index task_status_idx
+    on tasks (status);
@@ -0,0 +1,24 @@
+create table annotation_history
+(
+    id            integer      not null
+        primary key autoincrement,
+    detection_id  integer      not null,
+    group_id      integer      not null,
+    image_uid     varchar(36)  not null,
+    action        varchar(16)  not null,
+    editor        varchar(255) not null,
+    class         varchar(255) not null,
+    source        varchar(16)  not null,
+    review_status varchar(16)  not null,
+    x0            int          not null,
+    y0            int          not null,
+    x1            int          not null,
+    y1            int          not null,
+    create_at     timestamp    not null,
+    constraint history_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
+
+create index history_image_idx
+    on annotation_history (group_id, image_uid);
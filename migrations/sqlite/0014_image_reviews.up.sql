@@ -0,0 +1,14 @@
+create table image_reviews
+(
+    id          integer      not null
+        primary key autoincrement,
+    group_id    integer      not null,
+    image_uid   varchar(36)  not null,
+    reviewed_by varchar(255) not null,
+    reviewed_at timestamp    not null,
+    constraint image_reviews_uindex
+        unique (group_id, image_uid),
+    constraint image_reviews_to_group
+        foreign key (group_id) references `groups` (id)
+            on delete cascade
+);
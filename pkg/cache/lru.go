@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a thread-safe cache bounded by the total size of its values.
+// The least recently used entries are evicted first, entries older than ttl
+// are treated as missing (ttl <= 0 disables expiration).
+type LRU[K comparable, V any] struct {
+	mu sync.Mutex
+
+	maxSize int64
+	size    int64
+	ttl     time.Duration
+	sizeOf  func(V) int64
+
+	ll    *list.List
+	items map[K]*list.Element
+
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	size     int64
+	expireAt time.Time
+}
+
+type Stats struct {
+	Items     int   `json:"items"`
+	Size      int64 `json:"size"`
+	MaxSize   int64 `json:"max_size"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+func NewLRU[K comparable, V any](maxSize int64, ttl time.Duration, sizeOf func(V) int64) *LRU[K, V] {
+	return &LRU[K, V]{
+		maxSize: maxSize,
+		ttl:     ttl,
+		sizeOf:  sizeOf,
+		ll:      list.New(),
+		items:   make(map[K]*list.Element),
+	}
+}
+
+// NewBytes returns a cache of byte slices measured by their length.
+func NewBytes[K comparable](maxSize int64, ttl time.Duration) *LRU[K, []byte] {
+	return NewLRU[K, []byte](maxSize, ttl, func(b []byte) int64 {
+		return int64(len(b))
+	})
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+
+	e := el.Value.(*entry[K, V])
+	if c.ttl > 0 && time.Now().After(e.expireAt) {
+		c.removeElement(el)
+		c.misses++
+		var zero V
+		return zero, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.hits++
+
+	return e.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	size := c.sizeOf(value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+
+	if size > c.maxSize {
+		return
+	}
+
+	e := &entry[K, V]{
+		key:   key,
+		value: value,
+		size:  size,
+	}
+	if c.ttl > 0 {
+		e.expireAt = time.Now().Add(c.ttl)
+	}
+
+	c.items[key] = c.ll.PushFront(e)
+	c.size += size
+
+	for c.size > c.maxSize {
+		c.removeElement(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// DeleteFunc removes the entries whose keys match and returns their number.
+func (c *LRU[K, V]) DeleteFunc(match func(key K) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for key, el := range c.items {
+		if match(key) {
+			c.removeElement(el)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Items:     len(c.items),
+		Size:      c.size,
+		MaxSize:   c.maxSize,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+func (c *LRU[K, V]) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*entry[K, V])
+	delete(c.items, e.key)
+	c.size -= e.size
+}
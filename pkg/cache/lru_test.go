@@ -0,0 +1,103 @@
+package cache_test
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"FairLAP/pkg/cache"
+)
+
+func TestLRUEviction(t *testing.T) {
+	rq := require.New(t)
+
+	c := cache.NewBytes[string](10, 0)
+
+	c.Set("a", []byte("1234"))
+	c.Set("b", []byte("1234"))
+
+	_, ok := c.Get("a")
+	rq.True(ok)
+
+	c.Set("c", []byte("1234"))
+
+	_, ok = c.Get("b")
+	rq.False(ok, "least recently used entry must be evicted")
+
+	_, ok = c.Get("a")
+	rq.True(ok)
+
+	c.Set("big", []byte("12345678901"))
+	_, ok = c.Get("big")
+	rq.False(ok, "entry larger than the cache must not be stored")
+
+	stats := c.Stats()
+	rq.Equal(2, stats.Items)
+	rq.Equal(int64(8), stats.Size)
+	rq.Equal(int64(1), stats.Evictions)
+	rq.Equal(int64(2), stats.Hits)
+	rq.Equal(int64(2), stats.Misses)
+}
+
+func TestLRUExpiration(t *testing.T) {
+	rq := require.New(t)
+
+	c := cache.NewBytes[string](10, time.Millisecond)
+	c.Set("a", []byte("1"))
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := c.Get("a")
+	rq.False(ok)
+	rq.Equal(0, c.Stats().Items)
+}
+
+func TestLRUDeleteFunc(t *testing.T) {
+	rq := require.New(t)
+
+	c := cache.NewBytes[string](100, 0)
+	c.Set("image:1:a", []byte("1234"))
+	c.Set("image_thumb:1:a:256", []byte("12"))
+	c.Set("image:12:a", []byte("1234"))
+
+	deleted := c.DeleteFunc(func(key string) bool {
+		return strings.Split(key, ":")[1] == "1"
+	})
+	rq.Equal(2, deleted)
+
+	_, ok := c.Get("image:1:a")
+	rq.False(ok)
+	_, ok = c.Get("image:12:a")
+	rq.True(ok)
+
+	stats := c.Stats()
+	rq.Equal(1, stats.Items)
+	rq.Equal(int64(4), stats.Size)
+}
+
+func TestLRUConcurrentAccess(t *testing.T) {
+	c := cache.NewBytes[string](1000, 0)
+
+	wg := new(sync.WaitGroup)
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 1000 {
+				key := strconv.Itoa((i * j) % 50)
+				c.Set(key, make([]byte, 30))
+				c.Get(key)
+				if j%10 == 0 {
+					c.Delete(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, c.Stats().Size, int64(1000))
+}
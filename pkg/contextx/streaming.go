@@ -0,0 +1,17 @@
+package contextx
+
+import (
+	"context"
+)
+
+type contextKeyStreaming struct{}
+
+// WithStreaming marks the request as sending or receiving a large body.
+func WithStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyStreaming{}, true)
+}
+
+func IsStreaming(ctx context.Context) bool {
+	v, _ := ctx.Value(contextKeyStreaming{}).(bool)
+	return v
+}
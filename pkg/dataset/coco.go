@@ -0,0 +1,101 @@
+package dataset
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+)
+
+type cocoFile struct {
+	Images      []cocoImage      `json:"images"`
+	Annotations []cocoAnnotation `json:"annotations"`
+	Categories  []cocoCategory   `json:"categories"`
+}
+
+type cocoImage struct {
+	Id       int    `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type cocoAnnotation struct {
+	Id         int        `json:"id"`
+	ImageId    int        `json:"image_id"`
+	CategoryId int        `json:"category_id"`
+	BBox       [4]float64 `json:"bbox"`
+	Area       float64    `json:"area"`
+	IsCrowd    int        `json:"iscrowd"`
+	Segment    any        `json:"segmentation"`
+}
+
+type cocoCategory struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// writeCOCO writes images/<split>/<name>.jpg and
+// annotations/instances_<split>.json. Category ids start from 1, boxes are
+// [x, y, width, height].
+func writeCOCO(zw *zip.Writer, dataset *Dataset) error {
+	categories := make([]cocoCategory, len(dataset.Classes))
+	for i, class := range dataset.Classes {
+		categories[i] = cocoCategory{Id: i + 1, Name: class}
+	}
+
+	files := make(map[Split]*cocoFile, len(splits))
+	for _, split := range splits {
+		files[split] = &cocoFile{
+			Images:      []cocoImage{},
+			Annotations: []cocoAnnotation{},
+			Categories:  categories,
+		}
+	}
+
+	annotationId := 0
+
+	for i, img := range dataset.Images {
+		fileName := fmt.Sprintf("%s/%s.jpg", img.Split, img.Name)
+		if err := writeImage(zw, "images/"+fileName, img); err != nil {
+			return err
+		}
+
+		file := files[img.Split]
+		file.Images = append(file.Images, cocoImage{
+			Id:       i + 1,
+			FileName: fileName,
+			Width:    img.Width,
+			Height:   img.Height,
+		})
+
+		for _, box := range img.Boxes {
+			classId := dataset.classId(box.Class)
+			if classId == -1 {
+				continue
+			}
+
+			annotationId++
+			w, h := box.X1-box.X0, box.Y1-box.Y0
+			file.Annotations = append(file.Annotations, cocoAnnotation{
+				Id:         annotationId,
+				ImageId:    i + 1,
+				CategoryId: classId + 1,
+				BBox:       [4]float64{float64(box.X0), float64(box.Y0), float64(w), float64(h)},
+				Area:       float64(w * h),
+				Segment:    [][]int{},
+			})
+		}
+	}
+
+	for _, split := range splits {
+		data, err := json.Marshal(files[split])
+		if err != nil {
+			return err
+		}
+		if err := writeFile(zw, fmt.Sprintf("annotations/instances_%s.json", split), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
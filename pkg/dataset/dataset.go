@@ -0,0 +1,122 @@
+// Package dataset writes object detection datasets in the YOLO, COCO and
+// Pascal VOC formats as ZIP archives.
+package dataset
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"slices"
+)
+
+type Format string
+
+const (
+	FormatYOLO Format = "yolo"
+	FormatCOCO Format = "coco"
+	FormatVOC  Format = "voc"
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatYOLO || f == FormatCOCO || f == FormatVOC
+}
+
+type Split string
+
+const (
+	SplitTrain Split = "train"
+	SplitVal   Split = "val"
+)
+
+var splits = []Split{SplitTrain, SplitVal}
+
+// Box is a labeled object in pixels of the image.
+type Box struct {
+	Class string
+	X0    int
+	Y0    int
+	X1    int
+	Y1    int
+}
+
+// Image is a JPEG file of the dataset. Name is unique within the dataset and
+// has no extension, Open is called once while the archive is written. Err is
+// set by Read on an image that can't be decoded, it has no size and boxes.
+type Image struct {
+	Name   string
+	Width  int
+	Height int
+	Split  Split
+	Boxes  []Box
+	Open   func() (io.ReadCloser, error)
+	Err    error
+}
+
+// Dataset is a set of labeled images, the class id of a box is the index of
+// its class in Classes. Boxes of unknown classes are skipped.
+type Dataset struct {
+	Classes []string
+	Images  []Image
+}
+
+func (d *Dataset) classId(class string) int {
+	return slices.Index(d.Classes, class)
+}
+
+// WriteZip writes the dataset in the format as a ZIP archive.
+func WriteZip(w io.Writer, format Format, dataset *Dataset) error {
+	for _, img := range dataset.Images {
+		if !slices.Contains(splits, img.Split) {
+			return fmt.Errorf("unknown split %q of image %s", img.Split, img.Name)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+
+	var err error
+	switch format {
+	case FormatYOLO:
+		err = writeYOLO(zw, dataset)
+	case FormatCOCO:
+		err = writeCOCO(zw, dataset)
+	case FormatVOC:
+		err = writeVOC(zw, dataset)
+	default:
+		err = fmt.Errorf("unknown dataset format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeImage(zw *zip.Writer, name string, img Image) error {
+	f, err := img.Open()
+	if err != nil {
+		return fmt.Errorf("open image %s: %w", img.Name, err)
+	}
+	defer f.Close()
+
+	// JPEG is compressed already
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, f); err != nil {
+		return fmt.Errorf("copy image %s: %w", img.Name, err)
+	}
+
+	return nil
+}
+
+func writeFile(zw *zip.Writer, name string, data []byte) error {
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = dst.Write(data)
+	return err
+}
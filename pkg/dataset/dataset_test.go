@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDataset() *Dataset {
+	open := func(data string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(data)), nil
+		}
+	}
+
+	return &Dataset{
+		Classes: []string{"insulator", "nest"},
+		Images: []Image{
+			{
+				Name:   "1_a",
+				Width:  200,
+				Height: 100,
+				Split:  SplitTrain,
+				Boxes: []Box{
+					{Class: "nest", X0: 50, Y0: 25, X1: 150, Y1: 75},
+					{Class: "unknown", X0: 0, Y0: 0, X1: 10, Y1: 10},
+				},
+				Open: open("jpeg a"),
+			},
+			{
+				Name:   "1_b",
+				Width:  100,
+				Height: 100,
+				Split:  SplitVal,
+				Boxes:  []Box{{Class: "insulator", X0: 0, Y0: 0, X1: 10, Y1: 20}},
+				Open:   open("jpeg b"),
+			},
+		},
+	}
+}
+
+func writeZip(t *testing.T, format Format) map[string]string {
+	var buf bytes.Buffer
+	require.NoError(t, WriteZip(&buf, format, testDataset()))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+
+	return files
+}
+
+func TestYOLO(t *testing.T) {
+	rq := require.New(t)
+
+	files := writeZip(t, FormatYOLO)
+
+	rq.Equal("jpeg a", files["images/train/1_a.jpg"])
+	rq.Equal("jpeg b", files["images/val/1_b.jpg"])
+	rq.Equal("1 0.500000 0.500000 0.500000 0.500000\n", files["labels/train/1_a.txt"])
+	rq.Equal("0 0.050000 0.100000 0.100000 0.200000\n", files["labels/val/1_b.txt"])
+	rq.Equal("path: .\ntrain: images/train\nval: images/val\nnc: 2\nnames:\n  0: \"insulator\"\n  1: \"nest\"\n", files["data.yaml"])
+}
+
+func TestCOCO(t *testing.T) {
+	rq := require.New(t)
+
+	files := writeZip(t, FormatCOCO)
+	rq.Equal("jpeg a", files["images/train/1_a.jpg"])
+
+	var train cocoFile
+	rq.NoError(json.Unmarshal([]byte(files["annotations/instances_train.json"]), &train))
+	rq.Equal([]cocoCategory{{Id: 1, Name: "insulator"}, {Id: 2, Name: "nest"}}, train.Categories)
+	rq.Equal([]cocoImage{{Id: 1, FileName: "train/1_a.jpg", Width: 200, Height: 100}}, train.Images)
+	rq.Len(train.Annotations, 1)
+	rq.Equal(2, train.Annotations[0].CategoryId)
+	rq.Equal([4]float64{50, 25, 100, 50}, train.Annotations[0].BBox)
+	rq.Equal(float64(5000), train.Annotations[0].Area)
+
+	var val cocoFile
+	rq.NoError(json.Unmarshal([]byte(files["annotations/instances_val.json"]), &val))
+	rq.Len(val.Images, 1)
+	rq.Equal(2, val.Annotations[0].ImageId)
+	rq.Equal(1, val.Annotations[0].CategoryId)
+}
+
+func TestVOC(t *testing.T) {
+	rq := require.New(t)
+
+	files := writeZip(t, FormatVOC)
+	rq.Equal("jpeg b", files["JPEGImages/1_b.jpg"])
+	rq.Equal("1_a\n", files["ImageSets/Main/train.txt"])
+	rq.Equal("1_b\n", files["ImageSets/Main/val.txt"])
+	rq.Equal("insulator\nnest\n", files["labels.txt"])
+
+	var annotation vocAnnotation
+	rq.NoError(xml.Unmarshal([]byte(files["Annotations/1_a.xml"]), &annotation))
+	rq.Equal("1_a.jpg", annotation.Filename)
+	rq.Equal(vocSize{Width: 200, Height: 100, Depth: 3}, annotation.Size)
+	rq.Len(annotation.Objects, 1)
+	rq.Equal("nest", annotation.Objects[0].Name)
+	rq.Equal(vocBox{XMin: 50, YMin: 25, XMax: 150, YMax: 75}, annotation.Objects[0].BndBox)
+}
+
+func TestWriteZipErrors(t *testing.T) {
+	rq := require.New(t)
+
+	rq.Error(WriteZip(io.Discard, "csv", testDataset()))
+
+	dataset := testDataset()
+	dataset.Images[0].Split = "test"
+	rq.Error(WriteZip(io.Discard, FormatYOLO, dataset))
+}
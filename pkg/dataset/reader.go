@@ -0,0 +1,438 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"image"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+var imageExts = []string{".jpg", ".jpeg", ".png", ".bmp", ".tif", ".tiff", ".webp"}
+
+// maxClassId bounds the class ids of data.yaml, a bigger id is a typo rather
+// than a model with that many classes.
+const maxClassId = 1000
+
+// Read reads a YOLO or COCO dataset from fsys, a directory or a ZIP archive.
+// Images are opened from fsys, so it must stay open while they are read.
+// Boxes are in pixels of the image file as stored, EXIF orientation is not
+// applied. An image that can't be decoded is kept with Err set, so one broken
+// file doesn't fail the whole dataset. VOC datasets can't be read.
+func Read(fsys fs.FS, format Format) (*Dataset, error) {
+	files, err := listFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatYOLO:
+		return readYOLO(fsys, files)
+	case FormatCOCO:
+		return readCOCO(fsys, files)
+	default:
+		return nil, fmt.Errorf("reading %q datasets is not supported", format)
+	}
+}
+
+func listFiles(fsys fs.FS) ([]string, error) {
+	var files []string
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// macOS archives carry resource forks in __MACOSX
+		if d.IsDir() && (p == "__MACOSX" || strings.HasPrefix(d.Name(), ".") && p != ".") {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && !strings.HasPrefix(d.Name(), ".") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+
+	return files, nil
+}
+
+func isImage(p string) bool {
+	return slices.Contains(imageExts, strings.ToLower(path.Ext(p)))
+}
+
+// splitOf takes the split from the directories of the path, "val" and
+// "valid" are validation images, everything else is train.
+func splitOf(p string) Split {
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if dir == "val" || dir == "valid" {
+			return SplitVal
+		}
+	}
+	return SplitTrain
+}
+
+func newImage(fsys fs.FS, p string) Image {
+	img := Image{
+		Name:  strings.TrimSuffix(p, path.Ext(p)),
+		Split: splitOf(p),
+		Open: func() (io.ReadCloser, error) {
+			return fsys.Open(p)
+		},
+	}
+
+	f, err := fsys.Open(p)
+	if err != nil {
+		img.Err = err
+		return img
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		img.Err = fmt.Errorf("decode image %s: %w", p, err)
+		return img
+	}
+
+	img.Width, img.Height = cfg.Width, cfg.Height
+
+	return img
+}
+
+// readYOLO reads images with labels next to them or in the "labels" directory
+// mirroring "images". Classes come from data.yaml, classes.txt or obj.names.
+// Images without a label file have no objects.
+func readYOLO(fsys fs.FS, files []string) (*Dataset, error) {
+	classes, err := yoloClasses(fsys, files)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &Dataset{Classes: classes}
+
+	for _, p := range files {
+		if !isImage(p) {
+			continue
+		}
+
+		img := newImage(fsys, p)
+
+		labels, ok := yoloLabels(p, files)
+		// boxes are scaled by the image size, a broken image has none
+		if ok && img.Err == nil {
+			if img.Boxes, err = readYOLOLabels(fsys, labels, img, classes); err != nil {
+				return nil, err
+			}
+		}
+
+		dataset.Images = append(dataset.Images, img)
+	}
+
+	return dataset, nil
+}
+
+func yoloClasses(fsys fs.FS, files []string) ([]string, error) {
+	// the shallowest file wins, so a dataset root is preferred to a subdirectory
+	var found string
+	for _, p := range files {
+		switch path.Base(p) {
+		case "data.yaml", "data.yml", "classes.txt", "obj.names":
+			if found == "" || strings.Count(p, "/") < strings.Count(found, "/") {
+				found = p
+			}
+		}
+	}
+	if found == "" {
+		return nil, fmt.Errorf("no data.yaml, classes.txt or obj.names found")
+	}
+
+	data, err := fs.ReadFile(fsys, found)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := path.Ext(found); ext == ".txt" || ext == ".names" {
+		var classes []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				classes = append(classes, line)
+			}
+		}
+		return classes, nil
+	}
+
+	return parseDataYaml(data)
+}
+
+// parseDataYaml reads "names" of data.yaml given as a list or as a map of
+// class ids.
+func parseDataYaml(data []byte) ([]string, error) {
+	var list struct {
+		Names []string `yaml:"names"`
+	}
+	if err := yaml.Unmarshal(data, &list); err == nil && len(list.Names) > 0 {
+		return list.Names, nil
+	}
+
+	var ids struct {
+		Names map[int]string `yaml:"names"`
+	}
+	if err := yaml.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("parse data.yaml: %w", err)
+	}
+	if len(ids.Names) == 0 {
+		return nil, fmt.Errorf("data.yaml has no names")
+	}
+
+	for id := range ids.Names {
+		if id < 0 || id > maxClassId {
+			return nil, fmt.Errorf("data.yaml has an invalid class id %d", id)
+		}
+	}
+
+	classes := make([]string, slices.Max(mapKeys(ids.Names))+1)
+	for id, name := range ids.Names {
+		classes[id] = name
+	}
+
+	return classes, nil
+}
+
+func mapKeys(m map[int]string) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// yoloLabels finds the label file of the image: images/a/b.jpg has
+// labels/a/b.txt or images/a/b.txt.
+func yoloLabels(imagePath string, files []string) (string, bool) {
+	txt := strings.TrimSuffix(imagePath, path.Ext(imagePath)) + ".txt"
+
+	candidates := []string{txt}
+	segments := strings.Split(txt, "/")
+	for i := len(segments) - 2; i >= 0; i-- {
+		if segments[i] == "images" {
+			labels := slices.Clone(segments)
+			labels[i] = "labels"
+			candidates = append([]string{strings.Join(labels, "/")}, candidates...)
+			break
+		}
+	}
+
+	for _, candidate := range candidates {
+		if slices.Contains(files, candidate) {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// readYOLOLabels reads "<class> <x center> <y center> <width> <height>" boxes
+// and "<class> <x1> <y1> <x2> <y2> ..." segmentation polygons, a polygon
+// becomes its bounding box.
+func readYOLOLabels(fsys fs.FS, p string, img Image, classes []string) ([]Box, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var boxes []Box
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 5 || len(fields)%2 == 0 {
+			return nil, fmt.Errorf("%s:%d: expected a class and a box or a polygon", p, n)
+		}
+
+		classId, err := strconv.Atoi(fields[0])
+		if err != nil || classId < 0 || classId >= len(classes) {
+			return nil, fmt.Errorf("%s:%d: unknown class %q", p, n, fields[0])
+		}
+
+		values := make([]float64, len(fields)-1)
+		for i, field := range fields[1:] {
+			if values[i], err = strconv.ParseFloat(field, 64); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid number %q", p, n, field)
+			}
+		}
+
+		var x0, y0, x1, y1 float64
+		if len(values) == 4 {
+			x0, y0 = values[0]-values[2]/2, values[1]-values[3]/2
+			x1, y1 = values[0]+values[2]/2, values[1]+values[3]/2
+		} else {
+			x0, y0, x1, y1 = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+			for i := 0; i < len(values); i += 2 {
+				x0, x1 = min(x0, values[i]), max(x1, values[i])
+				y0, y1 = min(y0, values[i+1]), max(y1, values[i+1])
+			}
+		}
+
+		boxes = append(boxes, pixelBox(classes[classId], x0*float64(img.Width), y0*float64(img.Height), x1*float64(img.Width), y1*float64(img.Height), img))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", p, err)
+	}
+
+	return boxes, nil
+}
+
+// pixelBox rounds the box to pixels inside the image.
+func pixelBox(class string, x0, y0, x1, y1 float64, img Image) Box {
+	clamp := func(v float64, size int) int {
+		return min(max(int(math.Round(v)), 0), size)
+	}
+
+	return Box{
+		Class: class,
+		X0:    clamp(x0, img.Width),
+		Y0:    clamp(y0, img.Height),
+		X1:    clamp(x1, img.Width),
+		Y1:    clamp(y1, img.Height),
+	}
+}
+
+// readCOCO reads every JSON file with COCO images, the split is taken from
+// the file name, e.g. instances_val.json. Image files are found by
+// file_name relative to any directory of the dataset.
+func readCOCO(fsys fs.FS, files []string) (*Dataset, error) {
+	var images []string
+	for _, p := range files {
+		if isImage(p) {
+			images = append(images, p)
+		}
+	}
+
+	dataset := new(Dataset)
+
+	for _, p := range files {
+		if path.Ext(p) != ".json" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		var probe struct {
+			Images json.RawMessage `json:"images"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil || len(probe.Images) == 0 {
+			// not a COCO annotations file
+			continue
+		}
+
+		var file cocoFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", p, err)
+		}
+
+		if err := dataset.addCOCO(fsys, p, &file, images); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dataset.Images) == 0 {
+		return nil, fmt.Errorf("no COCO annotations found")
+	}
+
+	return dataset, nil
+}
+
+func (d *Dataset) addCOCO(fsys fs.FS, p string, file *cocoFile, images []string) error {
+	categories := make(map[int]string, len(file.Categories))
+	for _, category := range file.Categories {
+		categories[category.Id] = category.Name
+		if !slices.Contains(d.Classes, category.Name) {
+			d.Classes = append(d.Classes, category.Name)
+		}
+	}
+
+	split := SplitTrain
+	if name := strings.ToLower(path.Base(p)); strings.Contains(name, "val") {
+		split = SplitVal
+	}
+
+	imageIndex := make(map[int]int, len(file.Images))
+
+	for _, cocoImg := range file.Images {
+		imagePath, ok := findImage(cocoImg.FileName, images)
+		if !ok {
+			return fmt.Errorf("%s: image %s not found", p, cocoImg.FileName)
+		}
+
+		img := newImage(fsys, imagePath)
+		img.Split = split
+
+		imageIndex[cocoImg.Id] = len(d.Images)
+		d.Images = append(d.Images, img)
+	}
+
+	for _, annotation := range file.Annotations {
+		i, ok := imageIndex[annotation.ImageId]
+		if !ok {
+			return fmt.Errorf("%s: annotation %d of unknown image %d", p, annotation.Id, annotation.ImageId)
+		}
+		class, ok := categories[annotation.CategoryId]
+		if !ok {
+			return fmt.Errorf("%s: annotation %d of unknown category %d", p, annotation.Id, annotation.CategoryId)
+		}
+
+		if d.Images[i].Err != nil {
+			continue
+		}
+
+		b := annotation.BBox
+		d.Images[i].Boxes = append(d.Images[i].Boxes, pixelBox(class, b[0], b[1], b[0]+b[2], b[1]+b[3], d.Images[i]))
+	}
+
+	return nil
+}
+
+func findImage(fileName string, images []string) (string, bool) {
+	fileName = strings.TrimPrefix(path.Clean(strings.ReplaceAll(fileName, "\\", "/")), "/")
+
+	var found []string
+	for _, p := range images {
+		if p == fileName || strings.HasSuffix(p, "/"+fileName) {
+			found = append(found, p)
+		}
+	}
+	if len(found) == 0 {
+		// file names without the directory of the split
+		for _, p := range images {
+			if path.Base(p) == path.Base(fileName) {
+				found = append(found, p)
+			}
+		}
+	}
+
+	if len(found) != 1 {
+		return "", false
+	}
+	return found[0], true
+}
@@ -0,0 +1,152 @@
+package dataset
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encodePng(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func TestReadWritten(t *testing.T) {
+	for _, format := range []Format{FormatYOLO, FormatCOCO} {
+		t.Run(string(format), func(t *testing.T) {
+			rq := require.New(t)
+
+			written := testDataset()
+			for i, data := range [][]byte{encodePng(t, 200, 100), encodePng(t, 100, 100)} {
+				written.Images[i].Open = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(data)), nil
+				}
+			}
+
+			var buf bytes.Buffer
+			rq.NoError(WriteZip(&buf, format, written))
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			rq.NoError(err)
+
+			dataset, err := Read(zr, format)
+			rq.NoError(err)
+			rq.Equal(written.Classes, dataset.Classes)
+			rq.Len(dataset.Images, 2)
+
+			byName := make(map[string]Image)
+			for _, img := range dataset.Images {
+				byName[img.Name] = img
+			}
+
+			a, b := byName["images/train/1_a"], byName["images/val/1_b"]
+			rq.Equal(SplitTrain, a.Split)
+			rq.Equal(200, a.Width)
+			rq.Equal([]Box{{Class: "nest", X0: 50, Y0: 25, X1: 150, Y1: 75}}, a.Boxes)
+			rq.Equal(SplitVal, b.Split)
+			rq.Equal([]Box{{Class: "insulator", X0: 0, Y0: 0, X1: 10, Y1: 20}}, b.Boxes)
+
+			f, err := b.Open()
+			rq.NoError(err)
+			defer f.Close()
+			_, err = png.Decode(f)
+			rq.NoError(err)
+		})
+	}
+}
+
+func TestReadYOLO(t *testing.T) {
+	rq := require.New(t)
+
+	fsys := fstest.MapFS{
+		"classes.txt":      {Data: []byte("insulator\nnest\n")},
+		"a.png":            {Data: encodePng(t, 100, 50)},
+		"a.txt":            {Data: []byte("1 0.1 0.2 0.5 0.2 0.3 0.8\n\n0 0.5 0.5 2 2\n")},
+		"b.png":            {Data: encodePng(t, 10, 10)},
+		"c.jpg":            {Data: []byte("not an image")},
+		"c.txt":            {Data: []byte("0 0.5 0.5 0.1 0.1\n")},
+		"__MACOSX/._a.png": {Data: []byte("fork")},
+	}
+
+	dataset, err := Read(fsys, FormatYOLO)
+	rq.NoError(err)
+	rq.Equal([]string{"insulator", "nest"}, dataset.Classes)
+	rq.Len(dataset.Images, 3)
+
+	// a polygon becomes its bounding box, boxes are clipped by the image
+	rq.Equal([]Box{
+		{Class: "nest", X0: 10, Y0: 10, X1: 50, Y1: 40},
+		{Class: "insulator", X0: 0, Y0: 0, X1: 100, Y1: 50},
+	}, dataset.Images[0].Boxes)
+	rq.Empty(dataset.Images[1].Boxes)
+
+	// a broken image is kept to be reported, without boxes
+	rq.NoError(dataset.Images[0].Err)
+	rq.Equal("c", dataset.Images[2].Name)
+	rq.Error(dataset.Images[2].Err)
+	rq.Empty(dataset.Images[2].Boxes)
+
+	fsys["a.txt"] = &fstest.MapFile{Data: []byte("2 0.5 0.5 0.1 0.1\n")}
+	_, err = Read(fsys, FormatYOLO)
+	rq.ErrorContains(err, "unknown class")
+
+	delete(fsys, "classes.txt")
+	_, err = Read(fsys, FormatYOLO)
+	rq.Error(err)
+}
+
+func TestParseDataYaml(t *testing.T) {
+	rq := require.New(t)
+
+	classes, err := parseDataYaml([]byte("nc: 2\nnames: [insulator, nest]\n"))
+	rq.NoError(err)
+	rq.Equal([]string{"insulator", "nest"}, classes)
+
+	classes, err = parseDataYaml([]byte("names:\n  1: nest\n  0: insulator\n"))
+	rq.NoError(err)
+	rq.Equal([]string{"insulator", "nest"}, classes)
+
+	_, err = parseDataYaml([]byte("nc: 0\n"))
+	rq.Error(err)
+
+	_, err = parseDataYaml([]byte("names:\n  -5: nest\n"))
+	rq.ErrorContains(err, "invalid class id")
+
+	_, err = parseDataYaml([]byte("names:\n  1000000000: nest\n"))
+	rq.ErrorContains(err, "invalid class id")
+}
+
+func TestReadCOCO(t *testing.T) {
+	rq := require.New(t)
+
+	fsys := fstest.MapFS{
+		"photos/a.png": {Data: encodePng(t, 100, 50)},
+		"coco.json": {Data: []byte(`{
+			"images": [{"id": 7, "file_name": "C:\\data\\a.png", "width": 100, "height": 50}],
+			"categories": [{"id": 3, "name": "nest"}],
+			"annotations": [{"id": 1, "image_id": 7, "category_id": 3, "bbox": [10.4, 5.6, 20.2, 10],
+				"segmentation": {"counts": "abc", "size": [50, 100]}}]
+		}`)},
+		"other.json": {Data: []byte(`{"name": "not coco"}`)},
+	}
+
+	dataset, err := Read(fsys, FormatCOCO)
+	rq.NoError(err)
+	rq.Equal([]string{"nest"}, dataset.Classes)
+	rq.Len(dataset.Images, 1)
+	rq.Equal("photos/a", dataset.Images[0].Name)
+	rq.Equal([]Box{{Class: "nest", X0: 10, Y0: 6, X1: 31, Y1: 16}}, dataset.Images[0].Boxes)
+
+	delete(fsys, "photos/a.png")
+	_, err = Read(fsys, FormatCOCO)
+	rq.ErrorContains(err, "not found")
+
+	_, err = Read(fsys, FormatVOC)
+	rq.Error(err)
+}
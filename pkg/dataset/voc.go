@@ -0,0 +1,92 @@
+package dataset
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+type vocAnnotation struct {
+	XMLName  xml.Name    `xml:"annotation"`
+	Folder   string      `xml:"folder"`
+	Filename string      `xml:"filename"`
+	Size     vocSize     `xml:"size"`
+	Objects  []vocObject `xml:"object"`
+}
+
+type vocSize struct {
+	Width  int `xml:"width"`
+	Height int `xml:"height"`
+	Depth  int `xml:"depth"`
+}
+
+type vocObject struct {
+	Name      string `xml:"name"`
+	Pose      string `xml:"pose"`
+	Truncated int    `xml:"truncated"`
+	Difficult int    `xml:"difficult"`
+	BndBox    vocBox `xml:"bndbox"`
+}
+
+type vocBox struct {
+	XMin int `xml:"xmin"`
+	YMin int `xml:"ymin"`
+	XMax int `xml:"xmax"`
+	YMax int `xml:"ymax"`
+}
+
+// writeVOC writes JPEGImages/<name>.jpg, Annotations/<name>.xml, the
+// ImageSets/Main/<split>.txt image lists and labels.txt with the classes.
+func writeVOC(zw *zip.Writer, dataset *Dataset) error {
+	sets := make(map[Split]*bytes.Buffer, len(splits))
+	for _, split := range splits {
+		sets[split] = new(bytes.Buffer)
+	}
+
+	for _, img := range dataset.Images {
+		fileName := img.Name + ".jpg"
+		if err := writeImage(zw, "JPEGImages/"+fileName, img); err != nil {
+			return err
+		}
+
+		annotation := vocAnnotation{
+			Folder:   "JPEGImages",
+			Filename: fileName,
+			Size:     vocSize{Width: img.Width, Height: img.Height, Depth: 3},
+		}
+		for _, box := range img.Boxes {
+			if dataset.classId(box.Class) == -1 {
+				continue
+			}
+			annotation.Objects = append(annotation.Objects, vocObject{
+				Name:   box.Class,
+				Pose:   "Unspecified",
+				BndBox: vocBox{XMin: box.X0, YMin: box.Y0, XMax: box.X1, YMax: box.Y1},
+			})
+		}
+
+		data, err := xml.MarshalIndent(annotation, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(zw, "Annotations/"+img.Name+".xml", append([]byte(xml.Header), data...)); err != nil {
+			return err
+		}
+
+		sets[img.Split].WriteString(img.Name + "\n")
+	}
+
+	for _, split := range splits {
+		if err := writeFile(zw, fmt.Sprintf("ImageSets/Main/%s.txt", split), sets[split].Bytes()); err != nil {
+			return err
+		}
+	}
+
+	var labels bytes.Buffer
+	for _, class := range dataset.Classes {
+		labels.WriteString(class + "\n")
+	}
+
+	return writeFile(zw, "labels.txt", labels.Bytes())
+}
@@ -0,0 +1,59 @@
+package dataset
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// writeYOLO writes images/<split>/<name>.jpg, labels/<split>/<name>.txt with
+// a "<class id> <x center> <y center> <width> <height>" line per box in
+// coordinates relative to the image size, and data.yaml.
+func writeYOLO(zw *zip.Writer, dataset *Dataset) error {
+	if err := writeFile(zw, "data.yaml", yoloDataYaml(dataset.Classes)); err != nil {
+		return err
+	}
+
+	for _, img := range dataset.Images {
+		if err := writeImage(zw, fmt.Sprintf("images/%s/%s.jpg", img.Split, img.Name), img); err != nil {
+			return err
+		}
+
+		var labels bytes.Buffer
+		for _, box := range img.Boxes {
+			classId := dataset.classId(box.Class)
+			if classId == -1 {
+				continue
+			}
+
+			w, h := float64(img.Width), float64(img.Height)
+			fmt.Fprintf(&labels, "%d %.6f %.6f %.6f %.6f\n", classId,
+				float64(box.X0+box.X1)/2/w, float64(box.Y0+box.Y1)/2/h,
+				float64(box.X1-box.X0)/w, float64(box.Y1-box.Y0)/h)
+		}
+
+		if err := writeFile(zw, fmt.Sprintf("labels/%s/%s.txt", img.Split, img.Name), labels.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func yoloDataYaml(classes []string) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("path: .\n")
+	for _, split := range splits {
+		fmt.Fprintf(&buf, "%s: images/%s\n", split, split)
+	}
+	fmt.Fprintf(&buf, "nc: %d\n", len(classes))
+	buf.WriteString("names:\n")
+	for i, class := range classes {
+		// a double quoted string is a valid YAML scalar
+		fmt.Fprintf(&buf, "  %d: %s\n", i, strconv.Quote(class))
+	}
+
+	return buf.Bytes()
+}
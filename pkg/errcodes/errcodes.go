@@ -11,4 +11,5 @@ const (
 	ErrNotFound       Code = "not found"
 	ErrInvalidRequest Code = "invalid request"
 	ErrUnauthorized   Code = "unauthorized"
+	ErrUnavailable    Code = "service unavailable"
 )
@@ -0,0 +1,316 @@
+// Package evaluation scores object detections against ground truth boxes the
+// way COCO and Ultralytics validation do.
+package evaluation
+
+import (
+	"image"
+	"slices"
+)
+
+// Background is the confusion matrix label of missed objects and of
+// predictions matching no object.
+const Background = "background"
+
+// IoUThresholds are the thresholds mAP50-95 is averaged over.
+var IoUThresholds = []float64{0.5, 0.55, 0.6, 0.65, 0.7, 0.75, 0.8, 0.85, 0.9, 0.95}
+
+type Box struct {
+	Class      string
+	Rect       image.Rectangle
+	Confidence float32
+}
+
+// Sample is an image with its ground truth and model predictions.
+type Sample struct {
+	Truth       []Box
+	Predictions []Box
+}
+
+type ClassMetrics struct {
+	Class string `json:"class"`
+	// Truth is the number of ground truth objects of the class.
+	Truth int `json:"truth"`
+	// Predictions is the number of predictions above the confidence threshold.
+	Predictions int     `json:"predictions"`
+	Precision   float64 `json:"precision"`
+	Recall      float64 `json:"recall"`
+	AP50        float64 `json:"ap50"`
+	AP50_95     float64 `json:"ap50_95"`
+}
+
+// Confusion counts objects by their true class (rows) and the predicted
+// class (columns) at IoU 0.5. The last label is Background.
+type Confusion struct {
+	Labels []string `json:"labels"`
+	Matrix [][]int  `json:"matrix"`
+}
+
+// Result holds the metrics of classes with ground truth objects, the
+// totals are means over them.
+type Result struct {
+	Images    int            `json:"images"`
+	Precision float64        `json:"precision"`
+	Recall    float64        `json:"recall"`
+	MAP50     float64        `json:"map50"`
+	MAP50_95  float64        `json:"map50_95"`
+	Classes   []ClassMetrics `json:"classes"`
+	Confusion Confusion      `json:"confusion"`
+}
+
+// Evaluate matches predictions to ground truth. Average precision uses every
+// prediction, so the model should be run with a low confidence threshold;
+// precision, recall and the confusion matrix count only predictions of at
+// least confThreshold. Classes missing in classes are appended in the order
+// they are met.
+func Evaluate(classes []string, samples []Sample, confThreshold float32) *Result {
+	classes = slices.Clone(classes)
+	for _, sample := range samples {
+		for _, box := range slices.Concat(sample.Truth, sample.Predictions) {
+			if !slices.Contains(classes, box.Class) {
+				classes = append(classes, box.Class)
+			}
+		}
+	}
+
+	result := &Result{
+		Images:    len(samples),
+		Confusion: confusion(classes, samples, confThreshold),
+	}
+
+	for _, class := range classes {
+		metrics := classMetrics(class, samples, confThreshold)
+		if metrics.Truth == 0 {
+			continue
+		}
+
+		result.Classes = append(result.Classes, metrics)
+		result.Precision += metrics.Precision
+		result.Recall += metrics.Recall
+		result.MAP50 += metrics.AP50
+		result.MAP50_95 += metrics.AP50_95
+	}
+
+	if n := float64(len(result.Classes)); n > 0 {
+		result.Precision /= n
+		result.Recall /= n
+		result.MAP50 /= n
+		result.MAP50_95 /= n
+	}
+
+	return result
+}
+
+// match is a prediction marked as a true or a false positive.
+type match struct {
+	confidence float32
+	tp         bool
+}
+
+func classMetrics(class string, samples []Sample, confThreshold float32) ClassMetrics {
+	metrics := ClassMetrics{Class: class}
+
+	var truth [][]Box
+	var predictions [][]Box
+	for _, sample := range samples {
+		truth = append(truth, ofClass(sample.Truth, class))
+		predictions = append(predictions, ofClass(sample.Predictions, class))
+		metrics.Truth += len(truth[len(truth)-1])
+	}
+	if metrics.Truth == 0 {
+		return metrics
+	}
+
+	var apSum float64
+
+	for i, iou := range IoUThresholds {
+		var matches []match
+		for j := range samples {
+			matches = append(matches, matchBoxes(truth[j], predictions[j], iou)...)
+		}
+
+		ap := averagePrecision(matches, metrics.Truth)
+		apSum += ap
+
+		if i != 0 {
+			continue
+		}
+
+		metrics.AP50 = ap
+
+		tp := 0
+		for _, m := range matches {
+			if m.confidence >= confThreshold {
+				metrics.Predictions++
+				if m.tp {
+					tp++
+				}
+			}
+		}
+		if metrics.Predictions > 0 {
+			metrics.Precision = float64(tp) / float64(metrics.Predictions)
+		}
+		metrics.Recall = float64(tp) / float64(metrics.Truth)
+	}
+
+	metrics.AP50_95 = apSum / float64(len(IoUThresholds))
+
+	return metrics
+}
+
+func ofClass(boxes []Box, class string) []Box {
+	var filtered []Box
+	for _, box := range boxes {
+		if box.Class == class {
+			filtered = append(filtered, box)
+		}
+	}
+	return filtered
+}
+
+// matchBoxes greedily matches predictions from the most confident one to
+// the unmatched object with the highest IoU.
+func matchBoxes(truth, predictions []Box, iouThreshold float64) []match {
+	predictions = byConfidence(predictions)
+	matched := make([]bool, len(truth))
+	matches := make([]match, len(predictions))
+
+	for i, prediction := range predictions {
+		matches[i].confidence = prediction.Confidence
+
+		if best := bestMatch(prediction, truth, matched, iouThreshold); best >= 0 {
+			matched[best] = true
+			matches[i].tp = true
+		}
+	}
+
+	return matches
+}
+
+func bestMatch(prediction Box, truth []Box, matched []bool, iouThreshold float64) int {
+	best, bestIoU := -1, iouThreshold
+	for j, object := range truth {
+		if matched[j] {
+			continue
+		}
+		if v := IoU(prediction.Rect, object.Rect); v >= bestIoU {
+			best, bestIoU = j, v
+		}
+	}
+	return best
+}
+
+func byConfidence(boxes []Box) []Box {
+	boxes = slices.Clone(boxes)
+	slices.SortStableFunc(boxes, func(a, b Box) int {
+		switch {
+		case a.Confidence > b.Confidence:
+			return -1
+		case a.Confidence < b.Confidence:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return boxes
+}
+
+// averagePrecision is the area under the precision-recall curve
+// interpolated at 101 recall points as in COCO.
+func averagePrecision(matches []match, truth int) float64 {
+	matches = slices.Clone(matches)
+	slices.SortStableFunc(matches, func(a, b match) int {
+		switch {
+		case a.confidence > b.confidence:
+			return -1
+		case a.confidence < b.confidence:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	recall := make([]float64, len(matches))
+	precision := make([]float64, len(matches))
+	tp := 0
+	for i, m := range matches {
+		if m.tp {
+			tp++
+		}
+		recall[i] = float64(tp) / float64(truth)
+		precision[i] = float64(tp) / float64(i+1)
+	}
+
+	// the precision envelope, the best precision at any higher recall
+	for i := len(precision) - 2; i >= 0; i-- {
+		precision[i] = max(precision[i], precision[i+1])
+	}
+
+	var sum float64
+	for i := 0; i <= 100; i++ {
+		r := float64(i) / 100
+		if j, _ := slices.BinarySearch(recall, r); j < len(recall) {
+			sum += precision[j]
+		}
+	}
+
+	return sum / 101
+}
+
+// confusion matches confident predictions to objects of any class at IoU 0.5.
+func confusion(classes []string, samples []Sample, confThreshold float32) Confusion {
+	labels := append(slices.Clone(classes), Background)
+	index := func(class string) int {
+		return slices.Index(labels, class)
+	}
+	background := len(labels) - 1
+
+	matrix := make([][]int, len(labels))
+	for i := range matrix {
+		matrix[i] = make([]int, len(labels))
+	}
+
+	for _, sample := range samples {
+		matched := make([]bool, len(sample.Truth))
+
+		for _, prediction := range byConfidence(sample.Predictions) {
+			if prediction.Confidence < confThreshold {
+				continue
+			}
+
+			if best := bestMatch(prediction, sample.Truth, matched, IoUThresholds[0]); best >= 0 {
+				matched[best] = true
+				matrix[index(sample.Truth[best].Class)][index(prediction.Class)]++
+			} else {
+				matrix[background][index(prediction.Class)]++
+			}
+		}
+
+		for j, object := range sample.Truth {
+			if !matched[j] {
+				matrix[index(object.Class)][background]++
+			}
+		}
+	}
+
+	return Confusion{Labels: labels, Matrix: matrix}
+}
+
+// IoU is the intersection over union of two rectangles.
+func IoU(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+
+	interArea := area(inter)
+	union := area(a) + area(b) - interArea
+	if union <= 0 {
+		return 0
+	}
+
+	return float64(interArea) / float64(union)
+}
+
+func area(r image.Rectangle) int {
+	return r.Dx() * r.Dy()
+}
@@ -0,0 +1,101 @@
+package evaluation
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func box(class string, x0, y0, x1, y1 int, confidence float32) Box {
+	return Box{Class: class, Rect: image.Rect(x0, y0, x1, y1), Confidence: confidence}
+}
+
+func TestIoU(t *testing.T) {
+	rq := require.New(t)
+
+	rq.Equal(1.0, IoU(image.Rect(0, 0, 10, 10), image.Rect(0, 0, 10, 10)))
+	rq.InDelta(50.0/150.0, IoU(image.Rect(0, 0, 10, 10), image.Rect(5, 0, 15, 10)), 1e-9)
+	rq.Equal(0.0, IoU(image.Rect(0, 0, 10, 10), image.Rect(10, 10, 20, 20)))
+}
+
+func TestPerfect(t *testing.T) {
+	rq := require.New(t)
+
+	samples := []Sample{{
+		Truth:       []Box{box("nest", 0, 0, 10, 10, 0), box("insulator", 20, 20, 40, 40, 0)},
+		Predictions: []Box{box("nest", 0, 0, 10, 10, 0.9), box("insulator", 20, 20, 40, 40, 0.8)},
+	}}
+
+	result := Evaluate([]string{"insulator", "nest", "traverse"}, samples, 0.25)
+	rq.Equal(1, result.Images)
+	rq.Equal(1.0, result.MAP50)
+	rq.Equal(1.0, result.MAP50_95)
+	rq.Equal(1.0, result.Precision)
+	rq.Equal(1.0, result.Recall)
+
+	// classes without objects are left out
+	rq.Len(result.Classes, 2)
+	rq.Equal(ClassMetrics{Class: "insulator", Truth: 1, Predictions: 1, Precision: 1, Recall: 1, AP50: 1, AP50_95: 1}, result.Classes[0])
+
+	rq.Equal([]string{"insulator", "nest", "traverse", Background}, result.Confusion.Labels)
+	rq.Equal([][]int{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}}, result.Confusion.Matrix)
+}
+
+func TestEvaluate(t *testing.T) {
+	rq := require.New(t)
+
+	samples := []Sample{
+		{
+			Truth: []Box{box("nest", 0, 0, 10, 10, 0), box("nest", 50, 50, 60, 60, 0)},
+			Predictions: []Box{
+				// a loose box, matched at IoU 0.5 but not at 0.75
+				box("nest", 0, 0, 10, 14, 0.9),
+				box("nest", 80, 80, 90, 90, 0.8),
+				// the second object is found with a low confidence
+				box("nest", 50, 50, 60, 60, 0.1),
+			},
+		},
+		{
+			Truth:       []Box{box("insulator", 0, 0, 20, 20, 0)},
+			Predictions: []Box{box("nest", 0, 0, 20, 20, 0.7)},
+		},
+	}
+
+	result := Evaluate([]string{"nest"}, samples, 0.25)
+	rq.Equal(2, result.Images)
+	rq.Len(result.Classes, 2)
+
+	nest := result.Classes[0]
+	rq.Equal("nest", nest.Class)
+	rq.Equal(2, nest.Truth)
+	rq.Equal(3, nest.Predictions)
+	rq.InDelta(1.0/3, nest.Precision, 1e-9)
+	rq.Equal(0.5, nest.Recall)
+	// precision 1 up to recall 0.5, then 2/4 at recall 1
+	rq.InDelta((51*1.0+50*0.5)/101, nest.AP50, 1e-9)
+	rq.Less(nest.AP50_95, nest.AP50)
+
+	insulator := result.Classes[1]
+	rq.Equal("insulator", insulator.Class)
+	rq.Equal(0, insulator.Predictions)
+	rq.Equal(0.0, insulator.AP50)
+
+	rq.InDelta((nest.AP50+insulator.AP50)/2, result.MAP50, 1e-9)
+
+	rq.Equal([]string{"nest", "insulator", Background}, result.Confusion.Labels)
+	rq.Equal([][]int{
+		{1, 0, 1},
+		{1, 0, 0},
+		{1, 0, 0},
+	}, result.Confusion.Matrix)
+}
+
+func TestEmpty(t *testing.T) {
+	rq := require.New(t)
+
+	result := Evaluate([]string{"nest"}, nil, 0.25)
+	rq.Empty(result.Classes)
+	rq.Equal(0.0, result.MAP50)
+	rq.Equal([][]int{{0, 0}, {0, 0}}, result.Confusion.Matrix)
+}
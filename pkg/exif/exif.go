@@ -0,0 +1,371 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var ErrNoExif = errors.New("no exif data")
+
+const (
+	TagMake        uint16 = 0x010F
+	TagModel       uint16 = 0x0110
+	TagOrientation uint16 = 0x0112
+	TagDateTime    uint16 = 0x0132
+	TagExifIFD     uint16 = 0x8769
+	TagGPSIFD      uint16 = 0x8825
+
+	TagDateTimeOriginal   uint16 = 0x9003
+	TagOffsetTimeOriginal uint16 = 0x9011
+
+	TagGPSLatitudeRef  uint16 = 0x0001
+	TagGPSLatitude     uint16 = 0x0002
+	TagGPSLongitudeRef uint16 = 0x0003
+	TagGPSLongitude    uint16 = 0x0004
+	TagGPSAltitudeRef  uint16 = 0x0005
+	TagGPSAltitude     uint16 = 0x0006
+)
+
+const (
+	typeByte     = 1
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+	typeUndef    = 7
+	typeSLong    = 9
+	typeSRatio   = 10
+)
+
+var typeSizes = map[uint16]uint32{
+	typeByte:     1,
+	typeASCII:    1,
+	typeShort:    2,
+	typeLong:     4,
+	typeRational: 8,
+	typeUndef:    1,
+	typeSLong:    4,
+	typeSRatio:   8,
+}
+
+type Tag struct {
+	Id    uint16
+	Type  uint16
+	Count uint32
+	Value []byte
+}
+
+type Exif struct {
+	order binary.ByteOrder
+	ifd0  map[uint16]Tag
+	exif  map[uint16]Tag
+	gps   map[uint16]Tag
+}
+
+// Decode reads EXIF from a JPEG (APP1 segment) or from a TIFF file.
+func Decode(data []byte) (*Exif, error) {
+	tiff, err := findTiff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeTiff(tiff)
+}
+
+func findTiff(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return data, nil
+	}
+
+	return findApp1(data, []byte("Exif\x00\x00"))
+}
+
+// findApp1 returns the payload of the first JPEG APP1 segment starting with
+// prefix, the prefix is cut off.
+func findApp1(data, prefix []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrNoExif
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, ErrNoExif
+		}
+
+		marker := data[i+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, ErrNoExif
+		}
+
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return nil, ErrNoExif
+		}
+
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, prefix) {
+			return segment[len(prefix):], nil
+		}
+
+		i += 2 + size
+	}
+
+	return nil, ErrNoExif
+}
+
+func decodeTiff(data []byte) (*Exif, error) {
+	if len(data) < 8 {
+		return nil, ErrNoExif
+	}
+
+	e := new(Exif)
+
+	switch string(data[:2]) {
+	case "II":
+		e.order = binary.LittleEndian
+	case "MM":
+		e.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid tiff byte order")
+	}
+
+	if e.order.Uint16(data[2:]) != 42 {
+		return nil, fmt.Errorf("invalid tiff header")
+	}
+
+	ifd0, err := e.readIFD(data, e.order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+	e.ifd0 = ifd0
+
+	// broken sub IFDs are ignored, IFD0 is still useful without them
+	if tag, ok := ifd0[TagExifIFD]; ok {
+		if offset, ok := e.uint(tag); ok {
+			e.exif, _ = e.readIFD(data, offset)
+		}
+	}
+	if tag, ok := ifd0[TagGPSIFD]; ok {
+		if offset, ok := e.uint(tag); ok {
+			e.gps, _ = e.readIFD(data, offset)
+		}
+	}
+
+	return e, nil
+}
+
+func (e *Exif) readIFD(data []byte, offset uint32) (map[uint16]Tag, error) {
+	if uint64(offset)+2 > uint64(len(data)) {
+		return nil, fmt.Errorf("ifd offset out of range")
+	}
+
+	count := uint32(e.order.Uint16(data[offset:]))
+	if uint64(offset)+2+uint64(count)*12 > uint64(len(data)) {
+		return nil, fmt.Errorf("ifd out of range")
+	}
+
+	tags := make(map[uint16]Tag, count)
+
+	for i := range count {
+		entry := data[offset+2+i*12:]
+
+		tag := Tag{
+			Id:    e.order.Uint16(entry),
+			Type:  e.order.Uint16(entry[2:]),
+			Count: e.order.Uint32(entry[4:]),
+		}
+
+		typeSize, ok := typeSizes[tag.Type]
+		if !ok {
+			continue
+		}
+
+		size := uint64(typeSize) * uint64(tag.Count)
+		if size <= 4 {
+			tag.Value = entry[8 : 8+size]
+		} else {
+			valueOffset := uint64(e.order.Uint32(entry[8:]))
+			if valueOffset+size > uint64(len(data)) {
+				continue
+			}
+			tag.Value = data[valueOffset : valueOffset+size]
+		}
+
+		tags[tag.Id] = tag
+	}
+
+	return tags, nil
+}
+
+func (e *Exif) uint(tag Tag) (uint32, bool) {
+	switch {
+	case tag.Type == typeShort && len(tag.Value) >= 2:
+		return uint32(e.order.Uint16(tag.Value)), true
+	case tag.Type == typeLong && len(tag.Value) >= 4:
+		return e.order.Uint32(tag.Value), true
+	case tag.Type == typeByte && len(tag.Value) >= 1:
+		return uint32(tag.Value[0]), true
+	default:
+		return 0, false
+	}
+}
+
+// Orientation returns the EXIF orientation (1-8), 1 if the tag is absent.
+func (e *Exif) Orientation() int {
+	tag, ok := e.ifd0[TagOrientation]
+	if !ok {
+		return 1
+	}
+
+	v, ok := e.uint(tag)
+	if !ok || v < 1 || v > 8 {
+		return 1
+	}
+
+	return int(v)
+}
+
+func (e *Exif) text(tag Tag) (string, bool) {
+	if tag.Type != typeASCII {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimRight(string(tag.Value), "\x00")), true
+}
+
+func (e *Exif) rationals(tag Tag) ([]float64, bool) {
+	if tag.Type != typeRational && tag.Type != typeSRatio {
+		return nil, false
+	}
+
+	values := make([]float64, 0, tag.Count)
+	for i := 0; i+8 <= len(tag.Value); i += 8 {
+		var num, den float64
+		if tag.Type == typeSRatio {
+			num = float64(int32(e.order.Uint32(tag.Value[i:])))
+			den = float64(int32(e.order.Uint32(tag.Value[i+4:])))
+		} else {
+			num = float64(e.order.Uint32(tag.Value[i:]))
+			den = float64(e.order.Uint32(tag.Value[i+4:]))
+		}
+		if den == 0 {
+			return nil, false
+		}
+		values = append(values, num/den)
+	}
+
+	return values, len(values) > 0
+}
+
+// Make returns the camera manufacturer, empty if the tag is absent.
+func (e *Exif) Make() string {
+	v, _ := e.text(e.ifd0[TagMake])
+	return v
+}
+
+// Model returns the camera model, empty if the tag is absent.
+func (e *Exif) Model() string {
+	v, _ := e.text(e.ifd0[TagModel])
+	return v
+}
+
+// DateTimeOriginal returns the capture time. EXIF time has no zone unless
+// OffsetTimeOriginal is set, such times are returned in UTC as is.
+func (e *Exif) DateTimeOriginal() (time.Time, bool) {
+	v, ok := e.text(e.exif[TagDateTimeOriginal])
+	if !ok {
+		v, ok = e.text(e.ifd0[TagDateTime])
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+
+	loc := time.UTC
+	if offset, ok := e.text(e.exif[TagOffsetTimeOriginal]); ok {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			loc = t.Location()
+		}
+	}
+
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", v, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	// Altitude is meters above sea level, nil if the tag is absent.
+	Altitude *float64
+}
+
+// GPS returns the coordinates from the GPS IFD.
+func (e *Exif) GPS() (GPS, bool) {
+	lat, ok := e.coordinate(TagGPSLatitude, TagGPSLatitudeRef, "S", 90)
+	if !ok {
+		return GPS{}, false
+	}
+	lon, ok := e.coordinate(TagGPSLongitude, TagGPSLongitudeRef, "W", 180)
+	if !ok {
+		return GPS{}, false
+	}
+
+	gps := GPS{
+		Latitude:  lat,
+		Longitude: lon,
+	}
+
+	if alt, ok := e.rationals(e.gps[TagGPSAltitude]); ok {
+		altitude := alt[0]
+		if ref, ok := e.uint(e.gps[TagGPSAltitudeRef]); ok && ref == 1 {
+			altitude = -altitude
+		}
+		gps.Altitude = &altitude
+	}
+
+	return gps, true
+}
+
+// coordinate reads a degrees, minutes, seconds tag, values beyond limit
+// degrees are rejected.
+func (e *Exif) coordinate(tagId, refId uint16, negative string, limit float64) (float64, bool) {
+	dms, ok := e.rationals(e.gps[tagId])
+	if !ok {
+		return 0, false
+	}
+
+	v := dms[0]
+	if len(dms) > 1 {
+		v += dms[1] / 60
+	}
+	if len(dms) > 2 {
+		v += dms[2] / 3600
+	}
+
+	if ref, _ := e.text(e.gps[refId]); strings.EqualFold(ref, negative) {
+		v = -v
+	}
+
+	if math.IsNaN(v) || math.Abs(v) > limit {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// ValidCoordinates reports whether lat and lon are degrees in range, some
+// writers leave garbage in the position of photos without a GPS fix.
+func ValidCoordinates(lat, lon float64) bool {
+	return math.Abs(lat) <= 90 && math.Abs(lon) <= 180
+}
@@ -0,0 +1,209 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	id    uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// buildTiff writes a little endian TIFF with IFD0 and optional EXIF and GPS
+// sub IFDs, the sub IFD pointers are added to IFD0 automatically.
+func buildTiff(ifd0, exifIFD, gpsIFD []testEntry) []byte {
+	order := binary.LittleEndian
+
+	var buf bytes.Buffer
+	buf.WriteString("II")
+	binary.Write(&buf, order, uint16(42))
+	binary.Write(&buf, order, uint32(8))
+
+	ifdSize := func(entries []testEntry) uint32 {
+		size := uint32(2 + 12*len(entries) + 4)
+		for _, e := range entries {
+			if len(e.value) > 4 {
+				size += uint32(len(e.value))
+			}
+		}
+		return size
+	}
+
+	pointers := 0
+	if exifIFD != nil {
+		pointers++
+	}
+	if gpsIFD != nil {
+		pointers++
+	}
+
+	offset := 8 + ifdSize(ifd0) + uint32(12*pointers)
+	if exifIFD != nil {
+		ifd0 = append(ifd0, testEntry{TagExifIFD, typeLong, 1, order.AppendUint32(nil, offset)})
+		offset += ifdSize(exifIFD)
+	}
+	if gpsIFD != nil {
+		ifd0 = append(ifd0, testEntry{TagGPSIFD, typeLong, 1, order.AppendUint32(nil, offset)})
+	}
+
+	writeIFD := func(entries []testEntry) {
+		start := uint32(buf.Len())
+		extra := start + uint32(2+12*len(entries)+4)
+		var data []byte
+
+		binary.Write(&buf, order, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(&buf, order, e.id)
+			binary.Write(&buf, order, e.typ)
+			binary.Write(&buf, order, e.count)
+			if len(e.value) > 4 {
+				binary.Write(&buf, order, extra+uint32(len(data)))
+				data = append(data, e.value...)
+			} else {
+				v := make([]byte, 4)
+				copy(v, e.value)
+				buf.Write(v)
+			}
+		}
+		binary.Write(&buf, order, uint32(0))
+		buf.Write(data)
+	}
+
+	writeIFD(ifd0)
+	if exifIFD != nil {
+		writeIFD(exifIFD)
+	}
+	if gpsIFD != nil {
+		writeIFD(gpsIFD)
+	}
+
+	return buf.Bytes()
+}
+
+func ascii(s string) testEntry {
+	return testEntry{value: append([]byte(s), 0), typ: typeASCII, count: uint32(len(s) + 1)}
+}
+
+func withId(id uint16, e testEntry) testEntry {
+	e.id = id
+	return e
+}
+
+func rationals(values ...[2]uint32) testEntry {
+	var data []byte
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v[0])
+		data = binary.LittleEndian.AppendUint32(data, v[1])
+	}
+	return testEntry{typ: typeRational, count: uint32(len(values)), value: data}
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	rq := require.New(t)
+
+	data := buildTiff(
+		[]testEntry{
+			withId(TagMake, ascii("DJI")),
+			withId(TagModel, ascii("M3E")),
+		},
+		[]testEntry{
+			withId(TagDateTimeOriginal, ascii("2024:06:01 12:30:45")),
+			withId(TagOffsetTimeOriginal, ascii("+03:00")),
+		},
+		[]testEntry{
+			withId(TagGPSLatitudeRef, ascii("N")),
+			withId(TagGPSLatitude, rationals([2]uint32{55, 1}, [2]uint32{45, 1}, [2]uint32{36, 1})),
+			withId(TagGPSLongitudeRef, ascii("W")),
+			withId(TagGPSLongitude, rationals([2]uint32{37, 1}, [2]uint32{30, 1}, [2]uint32{0, 1})),
+			{TagGPSAltitudeRef, typeByte, 1, []byte{0}},
+			withId(TagGPSAltitude, rationals([2]uint32{1505, 10})),
+		},
+	)
+
+	e, err := Decode(data)
+	rq.NoError(err)
+
+	rq.Equal("DJI", e.Make())
+	rq.Equal("M3E", e.Model())
+
+	captured, ok := e.DateTimeOriginal()
+	rq.True(ok)
+	rq.True(captured.Equal(time.Date(2024, 6, 1, 9, 30, 45, 0, time.UTC)))
+
+	gps, ok := e.GPS()
+	rq.True(ok)
+	rq.InDelta(55.76, gps.Latitude, 1e-9)
+	rq.InDelta(-37.5, gps.Longitude, 1e-9)
+	rq.NotNil(gps.Altitude)
+	rq.InDelta(150.5, *gps.Altitude, 1e-9)
+}
+
+func TestDecodeWithoutGPS(t *testing.T) {
+	rq := require.New(t)
+
+	e, err := Decode(buildTiff([]testEntry{withId(TagModel, ascii("X"))}, nil, nil))
+	rq.NoError(err)
+
+	_, ok := e.GPS()
+	rq.False(ok)
+	_, ok = e.DateTimeOriginal()
+	rq.False(ok)
+	rq.Equal(1, e.Orientation())
+}
+
+func TestDecodeLatitudeOutOfRange(t *testing.T) {
+	rq := require.New(t)
+
+	e, err := Decode(buildTiff(nil, nil, []testEntry{
+		withId(TagGPSLatitude, rationals([2]uint32{120, 1}, [2]uint32{0, 1}, [2]uint32{0, 1})),
+		withId(TagGPSLongitude, rationals([2]uint32{37, 1}, [2]uint32{30, 1}, [2]uint32{0, 1})),
+	}))
+	rq.NoError(err)
+
+	_, ok := e.GPS()
+	rq.False(ok, "latitude beyond 90 degrees")
+
+	rq.True(ValidCoordinates(-90, 180))
+	rq.False(ValidCoordinates(90.5, 0))
+	rq.False(ValidCoordinates(0, -181))
+}
+
+func TestDecodeXMP(t *testing.T) {
+	rq := require.New(t)
+
+	packet := `<x:xmpmeta><rdf:Description drone-dji:GimbalPitchDegree="-90.00" drone-dji:FlightYawDegree="+12.5">` +
+		`<drone-dji:RelativeAltitude>+45.2</drone-dji:RelativeAltitude></rdf:Description></x:xmpmeta>`
+
+	segment := append(append([]byte{}, xmpPrefix...), packet...)
+
+	var jpeg []byte
+	jpeg = append(jpeg, 0xFF, 0xD8, 0xFF, 0xE1)
+	jpeg = binary.BigEndian.AppendUint16(jpeg, uint16(len(segment)+2))
+	jpeg = append(jpeg, segment...)
+	jpeg = append(jpeg, 0xFF, 0xD9)
+
+	xmp, err := DecodeXMP(jpeg)
+	rq.NoError(err)
+
+	pitch, ok := xmp.Float("GimbalPitchDegree")
+	rq.True(ok)
+	rq.Equal(-90.0, pitch)
+
+	yaw, ok := xmp.Float("FlightYawDegree")
+	rq.True(ok)
+	rq.Equal(12.5, yaw)
+
+	alt, ok := xmp.Float("RelativeAltitude")
+	rq.True(ok)
+	rq.Equal(45.2, alt)
+
+	_, err = DecodeXMP([]byte{0xFF, 0xD8, 0xFF, 0xD9})
+	rq.ErrorIs(err, ErrNoXMP)
+}
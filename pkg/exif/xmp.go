@@ -0,0 +1,54 @@
+package exif
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrNoXMP = errors.New("no xmp data")
+
+var (
+	xmpPrefix    = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpAttribute = regexp.MustCompile(`[A-Za-z][\w-]*:(\w+)="([^"]*)"`)
+	xmpElement   = regexp.MustCompile(`<[A-Za-z][\w-]*:(\w+)>([^<]*)</`)
+)
+
+// XMP holds simple properties of an XMP packet by their local names, e.g.
+// "GimbalPitchDegree" for drone-dji:GimbalPitchDegree. Structured properties
+// are not supported.
+type XMP map[string]string
+
+// DecodeXMP reads the XMP packet of a JPEG.
+func DecodeXMP(data []byte) (XMP, error) {
+	packet, err := findApp1(data, xmpPrefix)
+	if err != nil {
+		return nil, ErrNoXMP
+	}
+
+	xmp := make(XMP)
+
+	for _, m := range xmpElement.FindAllSubmatch(packet, -1) {
+		xmp[string(m[1])] = strings.TrimSpace(string(m[2]))
+	}
+	for _, m := range xmpAttribute.FindAllSubmatch(packet, -1) {
+		xmp[string(m[1])] = string(m[2])
+	}
+
+	return xmp, nil
+}
+
+func (x XMP) Float(name string) (float64, bool) {
+	v, ok := x[name]
+	if !ok {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimPrefix(v, "+"), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return f, true
+}
@@ -0,0 +1,23 @@
+package failure
+
+import (
+	"errors"
+)
+
+type UnavailableError struct {
+	baseError
+}
+
+func NewUnavailableError(msg string) error {
+	return UnavailableError{
+		baseError: newBaseError(msg),
+	}
+}
+
+func (err UnavailableError) Error() string {
+	return "unavailable: " + err.baseError.Error()
+}
+
+func IsUnavailableError(err error) bool {
+	return errors.As(err, new(UnavailableError))
+}
@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFeatures() []Feature {
+	alt := 120.5
+	return []Feature{
+		{
+			Id:    "1",
+			Name:  "nest",
+			Point: Point{Latitude: 55.75, Longitude: 37.61, Altitude: &alt},
+			Properties: map[string]any{
+				"classes":     map[string]int{"nest": 2},
+				"image_url":   "/image/1/a.jpeg",
+				"description": "<a href=\"/image/1/a.jpeg\">photo</a>",
+			},
+		},
+		{
+			Name:  "clean",
+			Point: Point{Latitude: -1.5, Longitude: 2},
+		},
+	}
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+	rq.NoError(WriteGeoJSON(&buf, testFeatures()))
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Id       string `json:"id"`
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	rq.NoError(json.Unmarshal(buf.Bytes(), &collection))
+
+	rq.Equal("FeatureCollection", collection.Type)
+	rq.Len(collection.Features, 2)
+	rq.Equal("Point", collection.Features[0].Geometry.Type)
+	rq.Equal([]float64{37.61, 55.75, 120.5}, collection.Features[0].Geometry.Coordinates)
+	rq.Equal("nest", collection.Features[0].Properties["name"])
+	rq.Equal([]float64{2, -1.5}, collection.Features[1].Geometry.Coordinates)
+}
+
+func TestWriteKML(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+	rq.NoError(WriteKML(&buf, "lap & group", testFeatures()))
+
+	kml := buf.String()
+	rq.True(strings.HasPrefix(kml, "<?xml"))
+	rq.Contains(kml, "<name>lap &amp; group</name>")
+	rq.Contains(kml, "<coordinates>37.61,55.75,120.5</coordinates>")
+	rq.Contains(kml, "<coordinates>2,-1.5</coordinates>")
+	rq.Contains(kml, `<Data name="classes">`)
+	rq.Contains(kml, "<value>{&#34;nest&#34;:2}</value>")
+	rq.Contains(kml, "<description>&lt;a href=")
+}
@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Point is a WGS84 position, Altitude is optional.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  *float64
+}
+
+// coordinates returns the GeoJSON position, longitude goes first.
+func (p Point) coordinates() []float64 {
+	if p.Altitude != nil {
+		return []float64{p.Longitude, p.Latitude, *p.Altitude}
+	}
+	return []float64{p.Longitude, p.Latitude}
+}
+
+// Feature is a point feature, Properties must be JSON encodable.
+type Feature struct {
+	Id         string
+	Name       string
+	Point      Point
+	Properties map[string]any
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Id         string          `json:"id,omitempty"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// WriteGeoJSON writes the features as a GeoJSON FeatureCollection.
+func WriteGeoJSON(w io.Writer, features []Feature) error {
+	collection := geoJSONCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoJSONFeature, len(features)),
+	}
+
+	for i, f := range features {
+		properties := make(map[string]any, len(f.Properties)+1)
+		for k, v := range f.Properties {
+			properties[k] = v
+		}
+		if f.Name != "" {
+			properties["name"] = f.Name
+		}
+
+		collection.Features[i] = geoJSONFeature{
+			Type: "Feature",
+			Id:   f.Id,
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: f.Point.coordinates(),
+			},
+			Properties: properties,
+		}
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
@@ -0,0 +1,122 @@
+package geo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Id           string           `xml:"id,attr,omitempty"`
+	Name         string           `xml:"name"`
+	Description  string           `xml:"description,omitempty"`
+	ExtendedData *kmlExtendedData `xml:"ExtendedData,omitempty"`
+	Point        kmlPoint         `xml:"Point"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	AltitudeMode string `xml:"altitudeMode,omitempty"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// WriteKML writes the features as KML placemarks of one document. Properties
+// become ExtendedData, non string values are written as JSON. The "description"
+// property is used as the placemark description.
+func WriteKML(w io.Writer, name string, features []Feature) error {
+	root := kmlRoot{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{
+			Name:       name,
+			Placemarks: make([]kmlPlacemark, len(features)),
+		},
+	}
+
+	for i, f := range features {
+		placemark := kmlPlacemark{
+			Id:   f.Id,
+			Name: f.Name,
+		}
+
+		coordinates := strconv.FormatFloat(f.Point.Longitude, 'f', -1, 64) + "," + strconv.FormatFloat(f.Point.Latitude, 'f', -1, 64)
+		if f.Point.Altitude != nil {
+			coordinates += "," + strconv.FormatFloat(*f.Point.Altitude, 'f', -1, 64)
+			placemark.Point.AltitudeMode = "absolute"
+		}
+		placemark.Point.Coordinates = coordinates
+
+		keys := make([]string, 0, len(f.Properties))
+		for k := range f.Properties {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			value, err := kmlValue(f.Properties[k])
+			if err != nil {
+				return fmt.Errorf("encode property %s: %w", k, err)
+			}
+
+			if k == "description" {
+				placemark.Description = value
+				continue
+			}
+
+			if placemark.ExtendedData == nil {
+				placemark.ExtendedData = new(kmlExtendedData)
+			}
+			placemark.ExtendedData.Data = append(placemark.ExtendedData.Data, kmlData{Name: k, Value: value})
+		}
+
+		root.Document.Placemarks[i] = placemark
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func kmlValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,137 @@
+package fake
+
+import (
+	"FairLAP/pkg/inference"
+	"context"
+	"fmt"
+	"github.com/ilyakaznacheev/cleanenv"
+	"image"
+	"math"
+)
+
+const defaultKey = "default"
+
+// Fixtures describe what the fake model returns. Results are looked up by
+// the image size ("<width>x<height>") and fall back to "default".
+// Coordinates are relative to the image size (0..1).
+type Fixtures struct {
+	ClassList  []string                      `yaml:"class-list" json:"class-list"`
+	Thresholds inference.Thresholds          `yaml:"thresholds" json:"thresholds"`
+	Detections map[string][]FixtureDetection `yaml:"detections" json:"detections"`
+	Polygons   map[string][][][2]float64     `yaml:"polygons" json:"polygons"`
+}
+
+type FixtureDetection struct {
+	Class      string     `yaml:"class" json:"class"`
+	Confidence float32    `yaml:"confidence" json:"confidence"`
+	BBox       [4]float64 `yaml:"bbox" json:"bbox"`
+}
+
+func ReadFixtures(path string) (*Fixtures, error) {
+	fixtures := new(Fixtures)
+	if err := cleanenv.ReadConfig(path, fixtures); err != nil {
+		return nil, err
+	}
+
+	return fixtures, nil
+}
+
+// Model is a deterministic in-process backend for tests and development
+// environments without OpenCV and model weights.
+type Model struct {
+	fixtures *Fixtures
+}
+
+func NewModel(fixtures *Fixtures) *Model {
+	return &Model{
+		fixtures: fixtures,
+	}
+}
+
+func (m *Model) Detect(ctx context.Context, img image.Image, override *inference.Thresholds) ([]inference.Detection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	size := img.Bounds().Size()
+	fixtures := lookup(m.fixtures.Detections, size)
+
+	thresholds := m.fixtures.Thresholds.Merge(override)
+
+	detections := make([]inference.Detection, 0, len(fixtures))
+
+	for _, f := range fixtures {
+		classId := m.classId(f.Class)
+		if classId < 0 {
+			return nil, fmt.Errorf("unknown fixture class: %s", f.Class)
+		}
+
+		threshold := thresholds.Threshold(f.Class)
+		if !thresholds.IsEnabled(f.Class) || f.Confidence < threshold {
+			continue
+		}
+
+		detections = append(detections, inference.Detection{
+			ClassID:    classId,
+			ClassName:  f.Class,
+			Confidence: f.Confidence,
+			BBox: image.Rect(
+				scale(f.BBox[0], size.X), scale(f.BBox[1], size.Y),
+				scale(f.BBox[2], size.X), scale(f.BBox[3], size.Y),
+			),
+			Threshold: threshold,
+		})
+	}
+
+	return detections, nil
+}
+
+func (m *Model) DetectPolygons(ctx context.Context, img image.Image) ([][]image.Point, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	size := img.Bounds().Size()
+	fixtures := lookup(m.fixtures.Polygons, size)
+
+	polygons := make([][]image.Point, len(fixtures))
+
+	for i, f := range fixtures {
+		polygons[i] = make([]image.Point, len(f))
+		for j, p := range f {
+			polygons[i][j] = image.Pt(scale(p[0], size.X), scale(p[1], size.Y))
+		}
+	}
+
+	return polygons, nil
+}
+
+func (m *Model) Classes() []string {
+	return m.fixtures.ClassList
+}
+
+func (m *Model) Stats() inference.PoolStats {
+	return inference.PoolStats{Size: 1}
+}
+
+func (m *Model) Close() {}
+
+func (m *Model) classId(class string) int {
+	for i, c := range m.fixtures.ClassList {
+		if c == class {
+			return i
+		}
+	}
+	return -1
+}
+
+func lookup[T any](fixtures map[string][]T, size image.Point) []T {
+	if v, ok := fixtures[fmt.Sprintf("%dx%d", size.X, size.Y)]; ok {
+		return v
+	}
+	return fixtures[defaultKey]
+}
+
+func scale(v float64, size int) int {
+	return int(math.Round(v * float64(size)))
+}
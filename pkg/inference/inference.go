@@ -0,0 +1,42 @@
+package inference
+
+import (
+	"context"
+	"image"
+)
+
+type Detection struct {
+	ClassID    int
+	ClassName  string
+	Confidence float32
+	BBox       image.Rectangle
+	// Threshold is the confidence threshold the detection has passed.
+	Threshold float32
+}
+
+type PoolStats struct {
+	Size      int     `json:"size"`
+	InUse     int     `json:"in_use"`
+	Waiting   int64   `json:"waiting"`
+	Acquired  int64   `json:"acquired"`
+	Canceled  int64   `json:"canceled"`
+	AvgWaitMs float64 `json:"avg_wait_ms"`
+	MaxWaitMs float64 `json:"max_wait_ms"`
+}
+
+// Detector is an object detection backend.
+type Detector interface {
+	// Detect runs the model, thresholds override the model configuration and may be nil.
+	Detect(ctx context.Context, img image.Image, thresholds *Thresholds) ([]Detection, error)
+	// Classes returns the class names the model is trained on.
+	Classes() []string
+	Stats() PoolStats
+	Close()
+}
+
+// Segmenter is a segmentation backend returning object contours.
+type Segmenter interface {
+	DetectPolygons(ctx context.Context, img image.Image) ([][]image.Point, error)
+	Stats() PoolStats
+	Close()
+}
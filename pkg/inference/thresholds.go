@@ -0,0 +1,78 @@
+package inference
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Thresholds select which detections are reported: Default applies to classes
+// missing in Classes, an empty Enabled list enables every class. A nil
+// Default is unset, so an override can lower it to 0.
+type Thresholds struct {
+	Default *float32           `json:"default,omitempty"`
+	Classes map[string]float32 `json:"classes,omitempty"`
+	Enabled []string           `json:"enabled,omitempty"`
+}
+
+func (t Thresholds) Threshold(class string) float32 {
+	if v, ok := t.Classes[class]; ok {
+		return v
+	}
+	if t.Default != nil {
+		return *t.Default
+	}
+	return 0
+}
+
+func (t Thresholds) IsEnabled(class string) bool {
+	return len(t.Enabled) == 0 || slices.Contains(t.Enabled, class)
+}
+
+// Merge applies a request level override on top of the model configuration.
+func (t Thresholds) Merge(override *Thresholds) Thresholds {
+	if override == nil {
+		return t
+	}
+
+	merged := Thresholds{
+		Default: t.Default,
+		Classes: make(map[string]float32, len(t.Classes)+len(override.Classes)),
+		Enabled: t.Enabled,
+	}
+
+	if override.Default != nil {
+		merged.Default = override.Default
+	}
+
+	for class, v := range t.Classes {
+		merged.Classes[class] = v
+	}
+	for class, v := range override.Classes {
+		merged.Classes[class] = v
+	}
+
+	if len(override.Enabled) > 0 {
+		merged.Enabled = override.Enabled
+	}
+
+	return merged
+}
+
+func (t Thresholds) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+func (t *Thresholds) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("unsupported thresholds type: %T", src)
+	}
+}
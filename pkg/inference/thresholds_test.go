@@ -0,0 +1,61 @@
+package inference
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ptr(v float32) *float32 {
+	return &v
+}
+
+func TestThresholds(t *testing.T) {
+	rq := require.New(t)
+
+	thresholds := Thresholds{
+		Default: ptr(0.5),
+		Classes: map[string]float32{"nest": 0.3},
+	}
+	rq.Equal(float32(0.3), thresholds.Threshold("nest"))
+	rq.Equal(float32(0.5), thresholds.Threshold("bird"))
+	rq.True(thresholds.IsEnabled("bird"))
+
+	thresholds.Enabled = []string{"nest"}
+	rq.True(thresholds.IsEnabled("nest"))
+	rq.False(thresholds.IsEnabled("bird"))
+
+	rq.Zero(Thresholds{}.Threshold("bird"))
+}
+
+func TestThresholdsMerge(t *testing.T) {
+	rq := require.New(t)
+
+	base := Thresholds{
+		Default: ptr(0.5),
+		Classes: map[string]float32{"nest": 0.3, "bird": 0.6},
+		Enabled: []string{"nest", "bird"},
+	}
+
+	rq.Equal(base, base.Merge(nil))
+
+	merged := base.Merge(&Thresholds{Classes: map[string]float32{"bird": 0.2}})
+	rq.Equal(float32(0.5), merged.Threshold("insulator"))
+	rq.Equal(float32(0.3), merged.Threshold("nest"))
+	rq.Equal(float32(0.2), merged.Threshold("bird"))
+	rq.Equal(base.Enabled, merged.Enabled)
+	rq.Equal(float32(0.6), base.Threshold("bird"), "base is not modified")
+
+	merged = base.Merge(&Thresholds{Default: ptr(0), Enabled: []string{"insulator"}})
+	rq.Zero(merged.Threshold("insulator"), "explicit zero default overrides")
+	rq.Equal([]string{"insulator"}, merged.Enabled)
+
+	// an override stored with a job keeps its explicit zero default
+	var override Thresholds
+	rq.NoError(json.Unmarshal([]byte(`{"default": 0}`), &override))
+	rq.Zero(base.Merge(&override).Threshold("insulator"))
+	var empty Thresholds
+	rq.NoError(json.Unmarshal([]byte(`{}`), &empty))
+	rq.Equal(float32(0.5), base.Merge(&empty).Threshold("insulator"))
+}
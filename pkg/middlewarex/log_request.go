@@ -1,13 +1,25 @@
 package middlewarex
 
 import (
+	"FairLAP/pkg/contextx"
 	"FairLAP/pkg/logx"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/http/httputil"
 	"strings"
 )
 
+// dumpContentTypes are the request bodies written to the log, images and
+// archives are skipped.
+var dumpContentTypes = map[string]bool{
+	"application/json":                  true,
+	"application/xml":                   true,
+	"application/x-www-form-urlencoded": true,
+	"text/xml":                          true,
+	"text/plain":                        true,
+}
+
 func RequestLogging(
 	sensitiveDataMasker logx.SensitiveDataMaskerInterface,
 	logFieldMaxLen int,
@@ -23,11 +35,11 @@ func RequestLogging(
 			}
 
 			ctx := r.Context()
-			dumpBody := true
 
-			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
-				dumpBody = false
-			}
+			// DumpRequest reads the whole body into memory, only text bodies
+			// of regular requests are dumped
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			dumpBody := dumpContentTypes[mediaType] && !contextx.IsStreaming(ctx)
 
 			dump, err := httputil.DumpRequest(r, dumpBody)
 			if err != nil {
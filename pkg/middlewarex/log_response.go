@@ -1,6 +1,7 @@
 package middlewarex
 
 import (
+	"FairLAP/pkg/contextx"
 	"FairLAP/pkg/logx"
 	"bytes"
 	"cmp"
@@ -41,7 +42,10 @@ func ResponseLogging(
 
 			var buf bytes.Buffer
 
-			lw.Tee(&buf)
+			// a streamed body is not buffered, only its status is logged
+			if !contextx.IsStreaming(ctx) {
+				lw.Tee(&buf)
+			}
 
 			next.ServeHTTP(lw, r)
 
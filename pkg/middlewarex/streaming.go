@@ -0,0 +1,35 @@
+package middlewarex
+
+import (
+	"FairLAP/pkg/contextx"
+	"FairLAP/pkg/logx"
+	"net/http"
+	"time"
+)
+
+// Streaming clears the server read and write deadlines of the matched
+// requests and marks their context, so the logging middlewares don't buffer
+// the body and WithTimeout doesn't cut it. It has to be the first
+// middleware, the deadlines are set through the server's own writer.
+func Streaming(match func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			rc := http.NewResponseController(w)
+			if err := rc.SetReadDeadline(time.Time{}); err != nil {
+				logger(ctx).Error("clear read deadline", logx.Error(err))
+			}
+			if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+				logger(ctx).Error("clear write deadline", logx.Error(err))
+			}
+
+			next.ServeHTTP(w, r.WithContext(contextx.WithStreaming(ctx)))
+		})
+	}
+}
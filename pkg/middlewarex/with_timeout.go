@@ -1,6 +1,7 @@
 package middlewarex
 
 import (
+	"FairLAP/pkg/contextx"
 	"golang.org/x/net/context"
 	"net/http"
 	"time"
@@ -9,6 +10,11 @@ import (
 func WithTimeout(timeout time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contextx.IsStreaming(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
@@ -0,0 +1,358 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrPending means the binary has migrations not applied to the database.
+	ErrPending = errors.New("schema has pending migrations")
+	// ErrDrift means the applied migrations don't match the binary ones.
+	ErrDrift = errors.New("schema drift")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Applied struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Load reads migrations from the root of fsys ordered by version. Every
+// version must have an up file, down files are optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		m := fileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, migration.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+
+		migrations = append(migrations, *migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return int(a.Version - b.Version)
+	})
+
+	return migrations, nil
+}
+
+// Diff compares the applied migrations with the known ones. It returns the
+// migrations to apply and the drift problems: applied migrations unknown to
+// the binary, changed after they were applied or pending migrations older
+// than the last applied one.
+func Diff(migrations []Migration, applied []Applied) ([]Migration, []string) {
+	known := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	var (
+		problems    []string
+		lastApplied int64
+	)
+
+	appliedVersions := make(map[int64]struct{}, len(applied))
+
+	for _, a := range applied {
+		appliedVersions[a.Version] = struct{}{}
+		lastApplied = max(lastApplied, a.Version)
+
+		m, ok := known[a.Version]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("migration %d_%s is applied but unknown", a.Version, a.Name))
+			continue
+		}
+		if m.Checksum != a.Checksum {
+			problems = append(problems, fmt.Sprintf("migration %d_%s was changed after it was applied", a.Version, a.Name))
+		}
+	}
+
+	var pending []Migration
+
+	for _, m := range migrations {
+		if _, ok := appliedVersions[m.Version]; ok {
+			continue
+		}
+		if m.Version < lastApplied {
+			problems = append(problems, fmt.Sprintf("migration %d_%s is older than the applied %d", m.Version, m.Name, lastApplied))
+			continue
+		}
+		pending = append(pending, m)
+	}
+
+	return pending, problems
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func New(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+const createTable = `
+create table if not exists schema_migrations
+(
+    version    bigint       not null
+        primary key,
+    name       varchar(255) not null,
+    checksum   char(64)     not null,
+    applied_at timestamp    not null
+)`
+
+func (m *Migrator) applied(ctx context.Context) ([]Applied, error) {
+	if _, err := m.db.ExecContext(ctx, createTable); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var applied []Applied
+	if err := m.db.SelectContext(ctx, &applied, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version"); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("read schema_migrations: %w", err)
+		}
+	}
+
+	return applied, nil
+}
+
+// Check returns ErrDrift or ErrPending if the database schema doesn't match
+// the migrations.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending, problems := Diff(m.migrations, applied)
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrDrift, strings.Join(problems, "; "))
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d to apply, first %d_%s", ErrPending, len(pending), pending[0].Version, pending[0].Name)
+	}
+
+	return nil
+}
+
+// Up applies pending migrations in order and returns the number of applied
+// ones. Nothing is applied if the schema has drifted.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending, problems := Diff(m.migrations, applied)
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrDrift, strings.Join(problems, "; "))
+	}
+
+	for i, migration := range pending {
+		if err := m.exec(ctx, migration.Up, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, tx.Rebind("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"),
+				migration.Version, migration.Name, migration.Checksum, time.Now().In(time.UTC))
+			return err
+		}); err != nil {
+			return i, fmt.Errorf("apply %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return len(pending), nil
+}
+
+// Baseline records migrations up to version as applied without running them,
+// for databases created before the migrations were versioned.
+func (m *Migrator) Baseline(ctx context.Context, version int64) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending, problems := Diff(m.migrations, applied)
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrDrift, strings.Join(problems, "; "))
+	}
+
+	done := 0
+
+	for _, migration := range pending {
+		if migration.Version > version {
+			break
+		}
+
+		if _, err := m.db.ExecContext(ctx, m.db.Rebind("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"),
+			migration.Version, migration.Name, migration.Checksum, time.Now().In(time.UTC)); err != nil {
+			return done, fmt.Errorf("baseline %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done++
+	}
+
+	return done, nil
+}
+
+// Down rolls back up to steps last applied migrations and returns the number
+// of rolled back ones.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	done := 0
+
+	for i := len(applied) - 1; i >= 0 && done < steps; i-- {
+		migration, ok := known[applied[i].Version]
+		if !ok || migration.Down == "" {
+			return done, fmt.Errorf("migration %d_%s can't be rolled back", applied[i].Version, applied[i].Name)
+		}
+
+		if err := m.exec(ctx, migration.Down, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, tx.Rebind("DELETE FROM schema_migrations WHERE version=?"), migration.Version)
+			return err
+		}); err != nil {
+			return done, fmt.Errorf("roll back %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done++
+	}
+
+	return done, nil
+}
+
+// exec runs the migration script and records it in one transaction. MySQL
+// commits DDL implicitly, so a failed script may leave partial changes there.
+func (m *Migrator) exec(ctx context.Context, script string, record func(tx *sqlx.Tx) error) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Status lists the known migrations with their apply time, nil for pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[int64]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	status := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if t, ok := appliedAt[migration.Version]; ok {
+			status[i].AppliedAt = &t
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	rq := require.New(t)
+
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_column.up.sql":   {Data: []byte("alter table a add b int;")},
+		"0001_init.up.sql":         {Data: []byte("create table a (id int);")},
+		"0001_init.down.sql":       {Data: []byte("drop table a;")},
+		"migrations.go":            {Data: []byte("package migrations")},
+		"0002_add_column.down.sql": {Data: []byte("alter table a drop b;")},
+	})
+	rq.NoError(err)
+	rq.Len(migrations, 2)
+
+	rq.Equal(int64(1), migrations[0].Version)
+	rq.Equal("init", migrations[0].Name)
+	rq.Equal("drop table a;", migrations[0].Down)
+	rq.Len(migrations[0].Checksum, 64)
+	rq.Equal(int64(2), migrations[1].Version)
+	rq.NotEqual(migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	rq := require.New(t)
+
+	_, err := Load(fstest.MapFS{"init.sql": {Data: []byte("")}})
+	rq.Error(err)
+
+	_, err = Load(fstest.MapFS{"0001_init.down.sql": {Data: []byte("drop table a;")}})
+	rq.ErrorContains(err, "no up file")
+}
+
+func TestDiff(t *testing.T) {
+	rq := require.New(t)
+
+	migrations := []Migration{
+		{Version: 1, Name: "init", Checksum: "a"},
+		{Version: 2, Name: "second", Checksum: "b"},
+		{Version: 3, Name: "third", Checksum: "c"},
+	}
+
+	pending, problems := Diff(migrations, nil)
+	rq.Len(pending, 3)
+	rq.Empty(problems)
+
+	pending, problems = Diff(migrations, []Applied{{Version: 1, Checksum: "a"}})
+	rq.Len(pending, 2)
+	rq.Equal(int64(2), pending[0].Version)
+	rq.Empty(problems)
+
+	_, problems = Diff(migrations, []Applied{{Version: 1, Name: "init", Checksum: "x"}})
+	rq.Len(problems, 1)
+	rq.Contains(problems[0], "changed")
+
+	_, problems = Diff(migrations, []Applied{{Version: 1, Checksum: "a"}, {Version: 4, Name: "future", Checksum: "d"}})
+	rq.Contains(problems, "migration 4_future is applied but unknown")
+
+	pending, problems = Diff(migrations, []Applied{{Version: 1, Checksum: "a"}, {Version: 3, Checksum: "c"}})
+	rq.Empty(pending)
+	rq.Len(problems, 1)
+	rq.Contains(problems[0], "older than the applied 3")
+}
@@ -0,0 +1,78 @@
+package yolo_model
+
+import (
+	"fmt"
+	"gocv.io/x/gocv"
+	"image"
+	"image/color"
+	"math"
+)
+
+var letterboxColor = color.RGBA{R: 114, G: 114, B: 114}
+
+// inputTransform maps coordinates of the model input back to the original frame.
+type inputTransform struct {
+	scaleX, scaleY float32
+	padX, padY     float32
+	// content is the part of the model input occupied by the image.
+	content image.Rectangle
+}
+
+func (t inputTransform) toOrig(x, y float32) (float32, float32) {
+	return (x - t.padX) * t.scaleX, (y - t.padY) * t.scaleY
+}
+
+// letterboxGeometry returns the size of the resized frame and its offset inside the model input.
+func letterboxGeometry(width, height int, size Size) (image.Rectangle, float32) {
+	ratio := math.Min(float64(size.Width)/float64(width), float64(size.Height)/float64(height))
+
+	w := int(math.Round(float64(width) * ratio))
+	h := int(math.Round(float64(height) * ratio))
+	left := (size.Width - w) / 2
+	top := (size.Height - h) / 2
+
+	return image.Rect(left, top, left+w, top+h), float32(ratio)
+}
+
+// blobFromMat prepares the net input. With letterbox the frame is resized
+// keeping the aspect ratio and padded to the model size, otherwise it is stretched.
+func blobFromMat(mat gocv.Mat, size Size, letterbox bool) (gocv.Mat, inputTransform, error) {
+	modelSize := image.Pt(size.Width, size.Height)
+
+	if !letterbox {
+		blob := gocv.BlobFromImage(mat, 1.0/255.0, modelSize, gocv.NewScalar(0, 0, 0, 0), true, false)
+		return blob, inputTransform{
+			scaleX:  float32(mat.Cols()) / float32(size.Width),
+			scaleY:  float32(mat.Rows()) / float32(size.Height),
+			content: image.Rect(0, 0, size.Width, size.Height),
+		}, nil
+	}
+
+	content, ratio := letterboxGeometry(mat.Cols(), mat.Rows(), size)
+
+	resized := gocv.NewMat()
+	defer resized.Close()
+	if err := gocv.Resize(mat, &resized, content.Size(), 0, 0, gocv.InterpolationLinear); err != nil {
+		return gocv.Mat{}, inputTransform{}, fmt.Errorf("gocv.Resize: %w", err)
+	}
+
+	padded := gocv.NewMat()
+	defer padded.Close()
+	if err := gocv.CopyMakeBorder(resized, &padded,
+		content.Min.Y, size.Height-content.Max.Y,
+		content.Min.X, size.Width-content.Max.X,
+		gocv.BorderConstant, letterboxColor,
+	); err != nil {
+		return gocv.Mat{}, inputTransform{}, fmt.Errorf("gocv.CopyMakeBorder: %w", err)
+	}
+
+	blob := gocv.BlobFromImage(padded, 1.0/255.0, modelSize, gocv.NewScalar(0, 0, 0, 0), true, false)
+
+	return blob, inputTransform{
+		scaleX:  1 / ratio,
+		scaleY:  1 / ratio,
+		padX:    float32(content.Min.X),
+		padY:    float32(content.Min.Y),
+		content: content,
+	}, nil
+}
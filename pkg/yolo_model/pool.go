@@ -0,0 +1,107 @@
+package yolo_model
+
+import (
+	"FairLAP/pkg/inference"
+	"context"
+	"fmt"
+	"gocv.io/x/gocv"
+	"sync/atomic"
+	"time"
+)
+
+// netPool holds several copies of the same network so that requests can be
+// processed in parallel. A gocv.Net is not safe for concurrent use, so every
+// call must acquire a net and release it when done.
+type netPool struct {
+	nets []*gocv.Net
+	free chan *gocv.Net
+
+	waiting   atomic.Int64
+	acquired  atomic.Int64
+	canceled  atomic.Int64
+	totalWait atomic.Int64
+	maxWait   atomic.Int64
+}
+
+func newNetPool(modelPath string, size int) (*netPool, error) {
+	size = max(size, 1)
+
+	p := &netPool{
+		nets: make([]*gocv.Net, 0, size),
+		free: make(chan *gocv.Net, size),
+	}
+
+	for range size {
+		net := gocv.ReadNetFromONNX(modelPath)
+		if net.Empty() {
+			p.Close()
+			return nil, fmt.Errorf("failed to load ONNX model from %s", modelPath)
+		}
+
+		if err := net.SetPreferableBackend(gocv.NetBackendOpenCV); err != nil {
+			net.Close()
+			p.Close()
+			return nil, err
+		}
+
+		p.nets = append(p.nets, &net)
+		p.free <- &net
+	}
+
+	return p, nil
+}
+
+func (p *netPool) acquire(ctx context.Context) (*gocv.Net, error) {
+	start := time.Now()
+
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+
+	select {
+	case net := <-p.free:
+		p.observeWait(time.Since(start))
+		return net, nil
+	case <-ctx.Done():
+		p.canceled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+func (p *netPool) release(net *gocv.Net) {
+	p.free <- net
+}
+
+func (p *netPool) observeWait(wait time.Duration) {
+	p.acquired.Add(1)
+	p.totalWait.Add(int64(wait))
+
+	for {
+		current := p.maxWait.Load()
+		if int64(wait) <= current || p.maxWait.CompareAndSwap(current, int64(wait)) {
+			return
+		}
+	}
+}
+
+func (p *netPool) stats() inference.PoolStats {
+	stats := inference.PoolStats{
+		Size:      len(p.nets),
+		InUse:     len(p.nets) - len(p.free),
+		Waiting:   p.waiting.Load(),
+		Acquired:  p.acquired.Load(),
+		Canceled:  p.canceled.Load(),
+		MaxWaitMs: float64(p.maxWait.Load()) / float64(time.Millisecond),
+	}
+
+	if stats.Acquired > 0 {
+		stats.AvgWaitMs = float64(p.totalWait.Load()) / float64(stats.Acquired) / float64(time.Millisecond)
+	}
+
+	return stats
+}
+
+func (p *netPool) Close() {
+	for _, net := range p.nets {
+		net.Close()
+	}
+}
@@ -0,0 +1,89 @@
+package yolo_model
+
+import (
+	"FairLAP/pkg/inference"
+	"image"
+	"sort"
+)
+
+// Tiling configures sliced inference: the frame is cut into overlapping tiles
+// that are fed to the net one by one, so small objects are not lost when a
+// high-resolution photo is resized to the model input size.
+type Tiling struct {
+	Enabled   bool    `yaml:"enabled" json:"enabled"`
+	Size      Size    `yaml:"size" json:"size"`
+	Overlap   float32 `yaml:"overlap" json:"overlap"`
+	FullFrame bool    `yaml:"full-frame" json:"full-frame"`
+}
+
+// tiles returns tile rectangles covering a width x height frame.
+// Tile size defaults to the model input size.
+func (t Tiling) tiles(width, height int, modelSize Size) []image.Rectangle {
+	size := t.Size
+	if size.Width <= 0 || size.Height <= 0 {
+		size = modelSize
+	}
+
+	overlap := min(max(t.Overlap, 0), 0.9)
+
+	xs := tileOffsets(width, size.Width, overlap)
+	ys := tileOffsets(height, size.Height, overlap)
+
+	tiles := make([]image.Rectangle, 0, len(xs)*len(ys))
+	for _, y := range ys {
+		for _, x := range xs {
+			tiles = append(tiles, image.Rect(x, y, min(x+size.Width, width), min(y+size.Height, height)))
+		}
+	}
+
+	return tiles
+}
+
+func tileOffsets(length, tile int, overlap float32) []int {
+	if length <= tile {
+		return []int{0}
+	}
+
+	stride := max(int(float32(tile)*(1-overlap)), 1)
+
+	var offsets []int
+	for offset := 0; ; offset += stride {
+		if offset+tile >= length {
+			offsets = append(offsets, length-tile)
+			break
+		}
+		offsets = append(offsets, offset)
+	}
+
+	return offsets
+}
+
+// nmsByClass suppresses overlapping boxes of the same class, keeping the most confident one.
+func nmsByClass(detections []inference.Detection, threshold float32) []inference.Detection {
+	sort.SliceStable(detections, func(i, j int) bool {
+		return detections[i].Confidence > detections[j].Confidence
+	})
+
+	result := make([]inference.Detection, 0, len(detections))
+	suppressed := make([]bool, len(detections))
+
+	for i := range detections {
+		if suppressed[i] {
+			continue
+		}
+
+		result = append(result, detections[i])
+
+		for j := i + 1; j < len(detections); j++ {
+			if suppressed[j] || detections[i].ClassID != detections[j].ClassID {
+				continue
+			}
+
+			if calculateIoU(detections[i].BBox, detections[j].BBox) > threshold {
+				suppressed[j] = true
+			}
+		}
+	}
+
+	return result
+}
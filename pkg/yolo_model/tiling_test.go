@@ -0,0 +1,74 @@
+package yolo_model
+
+import (
+	"FairLAP/pkg/inference"
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTilingTiles(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name   string
+		tiling Tiling
+		width  int
+		height int
+		tiles  []image.Rectangle
+	}{
+		{
+			name:   "Frame smaller than tile",
+			tiling: Tiling{Enabled: true},
+			width:  320,
+			height: 240,
+			tiles:  []image.Rectangle{image.Rect(0, 0, 320, 240)},
+		},
+		{
+			name:   "No overlap",
+			tiling: Tiling{Enabled: true},
+			width:  1280,
+			height: 640,
+			tiles:  []image.Rectangle{image.Rect(0, 0, 640, 640), image.Rect(640, 0, 1280, 640)},
+		},
+		{
+			name:   "Overlap with last tile aligned to the edge",
+			tiling: Tiling{Enabled: true, Size: Size{Width: 400, Height: 400}, Overlap: 0.25},
+			width:  1000,
+			height: 400,
+			tiles: []image.Rectangle{
+				image.Rect(0, 0, 400, 400),
+				image.Rect(300, 0, 700, 400),
+				image.Rect(600, 0, 1000, 400),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(*testing.T) {
+			tiles := tc.tiling.tiles(tc.width, tc.height, Size{Width: 640, Height: 640})
+
+			rq.Equal(tc.tiles, tiles)
+		})
+	}
+}
+
+func TestNMSByClass(t *testing.T) {
+	rq := require.New(t)
+
+	detections := []inference.Detection{
+		{ClassID: 0, Confidence: 0.6, BBox: image.Rect(0, 0, 100, 100)},
+		{ClassID: 0, Confidence: 0.9, BBox: image.Rect(5, 5, 105, 105)},
+		{ClassID: 1, Confidence: 0.8, BBox: image.Rect(0, 0, 100, 100)},
+		{ClassID: 0, Confidence: 0.7, BBox: image.Rect(500, 500, 600, 600)},
+	}
+
+	result := nmsByClass(detections, 0.5)
+
+	rq.Equal([]inference.Detection{
+		{ClassID: 0, Confidence: 0.9, BBox: image.Rect(5, 5, 105, 105)},
+		{ClassID: 1, Confidence: 0.8, BBox: image.Rect(0, 0, 100, 100)},
+		{ClassID: 0, Confidence: 0.7, BBox: image.Rect(500, 500, 600, 600)},
+	}, result)
+}
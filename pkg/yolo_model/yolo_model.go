@@ -1,12 +1,13 @@
 package yolo_model
 
 import (
+	"FairLAP/pkg/inference"
+	"context"
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"gocv.io/x/gocv"
 	"image"
 	"log"
-	"sync"
 )
 
 type ModelConfig struct {
@@ -14,6 +15,13 @@ type ModelConfig struct {
 	Size          Size     `yaml:"size" json:"size"`
 	ConfThreshold float32  `yaml:"conf-threshold" json:"conf-threshold"`
 	NMSThreshold  float32  `yaml:"NMS-threshold" json:"NMS-threshold"`
+	// ClassThresholds override ConfThreshold for particular classes.
+	ClassThresholds map[string]float32 `yaml:"class-thresholds" json:"class-thresholds"`
+	// EnabledClasses limits reported classes, all classes are reported if empty.
+	EnabledClasses []string `yaml:"enabled-classes" json:"enabled-classes"`
+	Tiling         Tiling   `yaml:"tiling" json:"tiling"`
+	PoolSize       int      `yaml:"pool-size" json:"pool-size"`
+	Letterbox      bool     `yaml:"letterbox" json:"letterbox"`
 }
 
 type Size struct {
@@ -31,37 +39,24 @@ func ReadConfig(path string) (*ModelConfig, error) {
 }
 
 type Model struct {
-	net *gocv.Net
-	cfg *ModelConfig
-	mu  sync.Mutex
+	pool *netPool
+	cfg  *ModelConfig
 }
 
 func NewModel(modelPath string, cfg *ModelConfig) *Model {
-	net := gocv.ReadNetFromONNX(modelPath)
-	if net.Empty() {
-		log.Fatal("failed to load ONNX model from ", modelPath)
-	}
-
-	if err := net.SetPreferableBackend(gocv.NetBackendOpenCV); err != nil {
+	pool, err := newNetPool(modelPath, cfg.PoolSize)
+	if err != nil {
 		log.Fatal(err)
 	}
 
 	return &Model{
-		net: &net,
-		cfg: cfg,
+		pool: pool,
+		cfg:  cfg,
 	}
 }
 
-type Detection struct {
-	ClassID    int
-	ClassName  string
-	Confidence float32
-	BBox       image.Rectangle
-}
-
-func (m *Model) Detect(img image.Image) ([]Detection, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Model) Detect(ctx context.Context, img image.Image, override *inference.Thresholds) ([]inference.Detection, error) {
+	thresholds := m.thresholds().Merge(override)
 
 	mat, err := imageToMat(img)
 	if err != nil {
@@ -69,14 +64,65 @@ func (m *Model) Detect(img image.Image) ([]Detection, error) {
 	}
 	defer mat.Close()
 
-	blob := gocv.BlobFromImage(mat, 1.0/255.0, image.Pt(m.cfg.Size.Width, m.cfg.Size.Height), gocv.NewScalar(0, 0, 0, 0), true, false)
+	net, err := m.pool.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire net failed: %w", err)
+	}
+	defer m.pool.release(net)
+
+	if !m.cfg.Tiling.Enabled {
+		return m.detectMat(net, mat, thresholds)
+	}
+
+	var detections []inference.Detection
+
+	for _, tile := range m.cfg.Tiling.tiles(mat.Cols(), mat.Rows(), m.cfg.Size) {
+		region := mat.Region(tile)
+		tileDetections, err := m.detectMat(net, region, thresholds)
+		region.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range tileDetections {
+			d.BBox = d.BBox.Add(tile.Min)
+			detections = append(detections, d)
+		}
+	}
+
+	if m.cfg.Tiling.FullFrame {
+		frameDetections, err := m.detectMat(net, mat, thresholds)
+		if err != nil {
+			return nil, err
+		}
+		detections = append(detections, frameDetections...)
+	}
+
+	return nmsByClass(detections, m.cfg.NMSThreshold), nil
+}
+
+func (m *Model) thresholds() inference.Thresholds {
+	conf := m.cfg.ConfThreshold
+
+	return inference.Thresholds{
+		Default: &conf,
+		Classes: m.cfg.ClassThresholds,
+		Enabled: m.cfg.EnabledClasses,
+	}
+}
+
+func (m *Model) detectMat(net *gocv.Net, mat gocv.Mat, thresholds inference.Thresholds) ([]inference.Detection, error) {
+	blob, transform, err := blobFromMat(mat, m.cfg.Size, m.cfg.Letterbox)
+	if err != nil {
+		return nil, err
+	}
 	defer blob.Close()
 
-	m.net.SetInput(blob, "images")
-	output := m.net.Forward("output0")
+	net.SetInput(blob, "images")
+	output := net.Forward("output0")
 	defer output.Close()
 
-	detections, err := m.processYOLOv8Output(output, mat.Cols(), mat.Rows())
+	detections, err := m.processYOLOv8Output(output, mat.Cols(), mat.Rows(), transform, thresholds)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +130,7 @@ func (m *Model) Detect(img image.Image) ([]Detection, error) {
 	return detections, nil
 }
 
-func (m *Model) processYOLOv8Output(output gocv.Mat, origWidth, origHeight int) ([]Detection, error) {
+func (m *Model) processYOLOv8Output(output gocv.Mat, origWidth, origHeight int, transform inputTransform, thresholds inference.Thresholds) ([]inference.Detection, error) {
 	sizes := output.Size()
 	if len(sizes) != 3 || sizes[0] != 1 {
 		log.Fatalf("Неожиданный формат вывода: %v", sizes)
@@ -109,39 +155,25 @@ func (m *Model) processYOLOv8Output(output gocv.Mat, origWidth, origHeight int)
 		}
 	}
 
+	if len(m.cfg.ClassList) < numFeatures-4 {
+		log.Fatal("Class id out of range. Check model settings.")
+	}
+
 	var boxes []image.Rectangle
 	var confidences []float32
 	var classIDs []int
+	var classThresholds []float32
 
 	for _, pred := range predictions {
 		cx, cy, w, h := pred[0], pred[1], pred[2], pred[3]
 
-		classData := pred[4:]
-		maxProb := float32(0)
-		maxClass := 0
-		for i, val := range classData {
-			if val > maxProb {
-				maxProb = val
-				maxClass = i
-			}
-		}
-
-		if maxProb < m.cfg.ConfThreshold {
+		maxClass, maxProb, threshold, ok := bestClass(pred[4:], m.cfg.ClassList, thresholds)
+		if !ok {
 			continue
 		}
 
-		x1 := cx - w/2
-		y1 := cy - h/2
-		x2 := cx + w/2
-		y2 := cy + h/2
-
-		scaleX := float32(origWidth) / float32(m.cfg.Size.Width)
-		scaleY := float32(origHeight) / float32(m.cfg.Size.Height)
-
-		x1 = x1 * scaleX
-		y1 = y1 * scaleY
-		x2 = x2 * scaleX
-		y2 = y2 * scaleY
+		x1, y1 := transform.toOrig(cx-w/2, cy-h/2)
+		x2, y2 := transform.toOrig(cx+w/2, cy+h/2)
 
 		ix1 := int(x1)
 		iy1 := int(y1)
@@ -160,28 +192,26 @@ func (m *Model) processYOLOv8Output(output gocv.Mat, origWidth, origHeight int)
 		boxes = append(boxes, image.Rect(ix1, iy1, ix2, iy2))
 		confidences = append(confidences, maxProb)
 		classIDs = append(classIDs, maxClass)
+		classThresholds = append(classThresholds, threshold)
 	}
 
 	if len(boxes) == 0 {
-		return []Detection{}, nil
+		return []inference.Detection{}, nil
 	}
 
-	indices := gocv.NMSBoxes(boxes, confidences, m.cfg.ConfThreshold, m.cfg.NMSThreshold)
+	// boxes are already filtered by the per-class thresholds
+	indices := gocv.NMSBoxes(boxes, confidences, 0, m.cfg.NMSThreshold)
 
-	var detections []Detection
+	var detections []inference.Detection
 	for _, idx := range indices {
-		d := Detection{
+		d := inference.Detection{
 			ClassID:    classIDs[idx],
+			ClassName:  m.cfg.ClassList[classIDs[idx]],
 			Confidence: confidences[idx],
 			BBox:       boxes[idx],
+			Threshold:  classThresholds[idx],
 		}
 
-		if len(m.cfg.ClassList) <= d.ClassID {
-			log.Fatal("Class id out of range. Check model settings.")
-		}
-
-		d.ClassName = m.cfg.ClassList[d.ClassID]
-
 		detections = append(detections, d)
 
 	}
@@ -189,6 +219,28 @@ func (m *Model) processYOLOv8Output(output gocv.Mat, origWidth, origHeight int)
 	return detections, nil
 }
 
+// bestClass picks the top scoring class of a prediction. The box is dropped
+// if that class is disabled or below its threshold, it is not relabelled with
+// the best enabled class.
+func bestClass(scores []float32, classes []string, thresholds inference.Thresholds) (int, float32, float32, bool) {
+	maxProb := float32(0)
+	maxClass := 0
+	for i, val := range scores {
+		if val > maxProb {
+			maxProb = val
+			maxClass = i
+		}
+	}
+
+	class := classes[maxClass]
+	threshold := thresholds.Threshold(class)
+	if maxProb == 0 || maxProb < threshold || !thresholds.IsEnabled(class) {
+		return 0, 0, 0, false
+	}
+
+	return maxClass, maxProb, threshold, true
+}
+
 func clamp(value, min, max int) int {
 	if value < min {
 		return min
@@ -216,6 +268,14 @@ func imageToMat(img image.Image) (gocv.Mat, error) {
 	return gocv.NewMatFromBytes(y, x, gocv.MatTypeCV8UC3, bytes)
 }
 
+func (m *Model) Classes() []string {
+	return m.cfg.ClassList
+}
+
+func (m *Model) Stats() inference.PoolStats {
+	return m.pool.stats()
+}
+
 func (m *Model) Close() {
-	m.net.Close()
+	m.pool.Close()
 }
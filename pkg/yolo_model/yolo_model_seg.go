@@ -1,6 +1,8 @@
 package yolo_model
 
 import (
+	"FairLAP/pkg/inference"
+	"context"
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"gocv.io/x/gocv"
@@ -9,13 +11,15 @@ import (
 	"log"
 	"math"
 	"sort"
-	"sync"
 )
 
 type ModelSegConfig struct {
 	Size          Size    `yaml:"size" json:"size"`
 	ConfThreshold float32 `yaml:"conf-threshold" json:"conf-threshold"`
 	NMSThreshold  float32 `yaml:"NMS-threshold" json:"NMS-threshold"`
+	Tiling        Tiling  `yaml:"tiling" json:"tiling"`
+	PoolSize      int     `yaml:"pool-size" json:"pool-size"`
+	Letterbox     bool    `yaml:"letterbox" json:"letterbox"`
 }
 
 func ReadSegConfig(path string) (*ModelSegConfig, error) {
@@ -28,53 +32,66 @@ func ReadSegConfig(path string) (*ModelSegConfig, error) {
 }
 
 type ModelSeg struct {
-	net *gocv.Net
-	cfg *ModelSegConfig
-	mu  sync.Mutex
+	pool *netPool
+	cfg  *ModelSegConfig
 }
 
 func NewModelSeg(modelPath string, cfg *ModelSegConfig) *ModelSeg {
-	net := gocv.ReadNetFromONNX(modelPath)
-	if net.Empty() {
-		log.Fatal("failed to load ONNX-seg model from ", modelPath)
-	}
-
-	if err := net.SetPreferableBackend(gocv.NetBackendOpenCV); err != nil {
+	pool, err := newNetPool(modelPath, cfg.PoolSize)
+	if err != nil {
 		log.Fatal(err)
 	}
 
 	return &ModelSeg{
-		net: &net,
-		cfg: cfg,
+		pool: pool,
+		cfg:  cfg,
 	}
 }
 
-func (m *ModelSeg) DetectPolygons(img image.Image) ([][]image.Point, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
+func (m *ModelSeg) DetectPolygons(ctx context.Context, img image.Image) ([][]image.Point, error) {
 	mat, err := imageToMat(img)
 	if err != nil {
 		return nil, fmt.Errorf("read image failed: %w", err)
 	}
 	defer mat.Close()
 
-	blob := gocv.BlobFromImage(mat, 1.0/255.0, image.Pt(m.cfg.Size.Width, m.cfg.Size.Height), gocv.NewScalar(0, 0, 0, 0), true, false)
-	defer blob.Close()
+	net, err := m.pool.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire net failed: %w", err)
+	}
+	defer m.pool.release(net)
 
-	m.net.SetInput(blob, "images")
+	var polygons []DetectionSeg
 
-	outputDet := m.net.Forward("output0") // [1,37,8400]
-	defer outputDet.Close()
+	if m.cfg.Tiling.Enabled {
+		for _, tile := range m.cfg.Tiling.tiles(mat.Cols(), mat.Rows(), m.cfg.Size) {
+			region := mat.Region(tile)
+			tilePolygons, err := m.detectMat(net, region)
+			region.Close()
+			if err != nil {
+				return nil, err
+			}
 
-	outputMask := m.net.Forward("output1") // [1,32,160,160]
-	defer outputMask.Close()
+			for _, polygon := range tilePolygons {
+				polygon.BBox = polygon.BBox.Add(tile.Min)
+				for i := range polygon.Mask {
+					polygon.Mask[i] = polygon.Mask[i].Add(tile.Min)
+				}
+				polygons = append(polygons, polygon)
+			}
+		}
+	}
 
-	polygons, err := m.postProcess(outputDet, outputMask, m.cfg.ConfThreshold, m.cfg.NMSThreshold,
-		image.Pt(img.Bounds().Dx(), img.Bounds().Dy()),
-	)
-	if err != nil {
-		return nil, err
+	if !m.cfg.Tiling.Enabled || m.cfg.Tiling.FullFrame {
+		framePolygons, err := m.detectMat(net, mat)
+		if err != nil {
+			return nil, err
+		}
+		polygons = append(polygons, framePolygons...)
+	}
+
+	if m.cfg.Tiling.Enabled {
+		polygons = nms(polygons, m.cfg.NMSThreshold)
 	}
 
 	polygons, err = filterDuplicateMasks(polygons, 0.8)
@@ -91,8 +108,32 @@ func (m *ModelSeg) DetectPolygons(img image.Image) ([][]image.Point, error) {
 	return points, nil
 }
 
+func (m *ModelSeg) detectMat(net *gocv.Net, mat gocv.Mat) ([]DetectionSeg, error) {
+	blob, transform, err := blobFromMat(mat, m.cfg.Size, m.cfg.Letterbox)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	net.SetInput(blob, "images")
+
+	outputDet := net.Forward("output0") // [1,37,8400]
+	defer outputDet.Close()
+
+	outputMask := net.Forward("output1") // [1,32,160,160]
+	defer outputMask.Close()
+
+	return m.postProcess(outputDet, outputMask, m.cfg.ConfThreshold, m.cfg.NMSThreshold,
+		image.Pt(mat.Cols(), mat.Rows()), transform,
+	)
+}
+
+func (m *ModelSeg) Stats() inference.PoolStats {
+	return m.pool.stats()
+}
+
 func (m *ModelSeg) Close() {
-	m.net.Close()
+	m.pool.Close()
 }
 
 type DetectionSeg struct {
@@ -101,7 +142,7 @@ type DetectionSeg struct {
 	BBox  image.Rectangle
 }
 
-func (m *ModelSeg) postProcess(outputDet gocv.Mat, outputMask gocv.Mat, confThreshold float32, nmsThreshold float32, origSize image.Point) ([]DetectionSeg, error) {
+func (m *ModelSeg) postProcess(outputDet gocv.Mat, outputMask gocv.Mat, confThreshold float32, nmsThreshold float32, origSize image.Point, transform inputTransform) ([]DetectionSeg, error) {
 	var detections []DetectionSeg
 
 	sizes := outputDet.Size()
@@ -114,9 +155,6 @@ func (m *ModelSeg) postProcess(outputDet gocv.Mat, outputMask gocv.Mat, confThre
 		return nil, fmt.Errorf("error get detection data: %v", err)
 	}
 
-	scaleX := float32(origSize.X) / float32(m.cfg.Size.Width)
-	scaleY := float32(origSize.Y) / float32(m.cfg.Size.Height)
-
 	for i := 0; i < 8400; i++ {
 		rawData := make([]float32, 37)
 		for j := 0; j < 37; j++ {
@@ -139,10 +177,8 @@ func (m *ModelSeg) postProcess(outputDet gocv.Mat, outputMask gocv.Mat, confThre
 			continue
 		}
 
-		x1 := (x - w/2) * scaleX
-		y1 := (y - h/2) * scaleY
-		x2 := (x + w/2) * scaleX
-		y2 := (y + h/2) * scaleY
+		x1, y1 := transform.toOrig(x-w/2, y-h/2)
+		x2, y2 := transform.toOrig(x+w/2, y+h/2)
 
 		rect := image.Rect(
 			int(math.Max(0, float64(x1))),
@@ -153,7 +189,7 @@ func (m *ModelSeg) postProcess(outputDet gocv.Mat, outputMask gocv.Mat, confThre
 
 		maskWeights := rawData[5:37]
 
-		maskPoints, err := processMask(maskWeights, outputMask, origSize)
+		maskPoints, err := processMask(maskWeights, outputMask, origSize, protoContent(transform.content, m.cfg.Size, outputMask))
 		if err != nil {
 			return nil, err
 		}
@@ -170,7 +206,24 @@ func (m *ModelSeg) postProcess(outputDet gocv.Mat, outputMask gocv.Mat, confThre
 	return nms(detections, nmsThreshold), nil
 }
 
-func processMask(maskWeights []float32, maskProto gocv.Mat, origSize image.Point) ([]image.Point, error) {
+// protoContent scales the image area of the model input to the prototype mask resolution.
+func protoContent(content image.Rectangle, modelSize Size, maskProto gocv.Mat) image.Rectangle {
+	protoSize := maskProto.Size()
+	if len(protoSize) != 4 {
+		return image.Rectangle{}
+	}
+
+	protoH, protoW := protoSize[2], protoSize[3]
+
+	return image.Rect(
+		content.Min.X*protoW/modelSize.Width,
+		content.Min.Y*protoH/modelSize.Height,
+		content.Max.X*protoW/modelSize.Width,
+		content.Max.Y*protoH/modelSize.Height,
+	)
+}
+
+func processMask(maskWeights []float32, maskProto gocv.Mat, origSize image.Point, content image.Rectangle) ([]image.Point, error) {
 	protoSize := maskProto.Size()
 	if len(protoSize) != 4 || protoSize[0] != 1 || protoSize[1] != 32 {
 		return nil, fmt.Errorf("incorrect detection output: %v, expected [1,32,160,160]", protoSize)
@@ -206,9 +259,17 @@ func processMask(maskWeights []float32, maskProto gocv.Mat, origSize image.Point
 		}
 	}
 
+	content = content.Intersect(image.Rect(0, 0, protoW, protoH))
+	if content.Empty() {
+		content = image.Rect(0, 0, protoW, protoH)
+	}
+
+	maskContent := mask160.Region(content)
+	defer maskContent.Close()
+
 	fullSizeMask := gocv.NewMat()
 	defer fullSizeMask.Close()
-	if err := gocv.Resize(mask160, &fullSizeMask, image.Pt(origSize.X, origSize.Y), 0, 0, gocv.InterpolationLinear); err != nil {
+	if err := gocv.Resize(maskContent, &fullSizeMask, image.Pt(origSize.X, origSize.Y), 0, 0, gocv.InterpolationLinear); err != nil {
 		return nil, fmt.Errorf("gocv.Resize: %w", err)
 	}
 
@@ -0,0 +1,39 @@
+package yolo_model
+
+import (
+	"FairLAP/pkg/inference"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBestClass(t *testing.T) {
+	rq := require.New(t)
+
+	classes := []string{"nest", "bird", "insulator"}
+	conf := float32(0.25)
+	thresholds := inference.Thresholds{
+		Default: &conf,
+		Classes: map[string]float32{"insulator": 0.7},
+	}
+
+	class, prob, threshold, ok := bestClass([]float32{0.1, 0.8, 0.3}, classes, thresholds)
+	rq.True(ok)
+	rq.Equal(1, class)
+	rq.Equal(float32(0.8), prob)
+	rq.Equal(conf, threshold)
+
+	_, _, _, ok = bestClass([]float32{0.1, 0.2, 0.6}, classes, thresholds)
+	rq.False(ok, "below the class threshold")
+
+	_, _, _, ok = bestClass([]float32{0, 0, 0}, classes, thresholds)
+	rq.False(ok)
+
+	thresholds.Enabled = []string{"nest"}
+	_, _, _, ok = bestClass([]float32{0.3, 0.8, 0.1}, classes, thresholds)
+	rq.False(ok, "a disabled top class drops the box instead of relabelling it")
+
+	class, _, _, ok = bestClass([]float32{0.8, 0.3, 0.1}, classes, thresholds)
+	rq.True(ok)
+	rq.Equal(0, class)
+}